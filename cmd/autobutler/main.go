@@ -1,3 +1,7 @@
+// Folder uploads send a path along with every file, so a thousand files make
+// two thousand parts, twice the default limit.
+//
+//go:debug multipartmaxparts=10000
 package main
 
 import (
@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"autobutler/pkg/activity"
+	"autobutler/pkg/api"
+	"autobutler/pkg/stars"
+	"autobutler/pkg/util/serverutil"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type activityResponse struct {
+	Events []activity.Event `json:"events"`
+}
+
+type filesResponse struct {
+	Files []fileResponse `json:"files"`
+}
+
+type starRequest struct {
+	Paths []string `form:"paths" json:"paths"`
+}
+
+func SetupActivityRoutes(apiV1Group *gin.RouterGroup) {
+	listActivityRoute(apiV1Group)
+	listRecentRoute(apiV1Group)
+	listStarsRoute(apiV1Group)
+	starFilesRoute(apiV1Group)
+	unstarFilesRoute(apiV1Group)
+}
+
+// listActivityRoute lists what happened to files, newest first. Older pages
+// are fetched with the ID of the last event seen as before.
+func listActivityRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/activity", func(c *gin.Context) *api.Response {
+		before, err := strconv.ParseInt(c.DefaultQuery("before", "0"), 10, 64)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("invalid before"))
+		}
+		limit, resp := activityLimit(c)
+		if resp != nil {
+			return resp
+		}
+		events, err := activity.List(before, limit)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(activityResponse{Events: events})
+	})
+}
+
+// listRecentRoute lists the files and folders opened or changed most
+// recently, newest first.
+func listRecentRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/recent", func(c *gin.Context) *api.Response {
+		limit, resp := activityLimit(c)
+		if resp != nil {
+			return resp
+		}
+		paths, err := activity.RecentPaths(limit)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(filesResponse{Files: statFileResponses(paths)})
+	})
+}
+
+func listStarsRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/stars", func(c *gin.Context) *api.Response {
+		paths, err := stars.Paths()
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(filesResponse{Files: statFileResponses(paths)})
+	})
+}
+
+// starFilesRoute stars every path given. Files that are already starred stay
+// that way.
+func starFilesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/stars", func(c *gin.Context) *api.Response {
+		var request starRequest
+		if err := c.ShouldBind(&request); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		if len(request.Paths) == 0 {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("no paths given"))
+		}
+		for _, filePath := range request.Paths {
+			if err := stars.Add(filePath); err != nil {
+				return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(starErrorStatus(err)).WithError(fmt.Errorf("%s: %w", path.Base(filePath), err))
+			}
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+// unstarFilesRoute unstars the paths given as filePaths in the query.
+func unstarFilesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "DELETE", "/stars", func(c *gin.Context) *api.Response {
+		filePaths := c.QueryArray("filePaths")
+		if len(filePaths) == 0 {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("no paths given"))
+		}
+		for _, filePath := range filePaths {
+			if err := stars.Remove(filePath); err != nil {
+				return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(starErrorStatus(err)).WithError(err)
+			}
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+// statFileResponses describes the files and folders at paths, leaving out
+// ones that are gone.
+func statFileResponses(paths []string) []fileResponse {
+	files := make([]fileResponse, 0, len(paths))
+	for _, filePath := range paths {
+		if file, err := statFileResponse(filePath, false); err == nil {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// activityLimit parses the limit of a route listing activity, or returns the
+// response for one that isn't a positive number.
+func activityLimit(c *gin.Context) (int, *api.Response) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(activity.DefaultLimit)))
+	if err != nil || limit <= 0 {
+		return 0, api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("invalid limit"))
+	}
+	return min(limit, activity.MaxLimit), nil
+}
+
+// starErrorStatus maps an error from the stars package onto an HTTP status
+// code.
+func starErrorStatus(err error) int {
+	if errors.Is(err, stars.ErrInvalid) {
+		return http.StatusBadRequest
+	}
+	return fileErrorStatus(err)
+}
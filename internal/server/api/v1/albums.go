@@ -0,0 +1,279 @@
+package v1
+
+import (
+	"autobutler/pkg/albums"
+	"autobutler/pkg/api"
+	"autobutler/pkg/photos"
+	"autobutler/pkg/util/fileutil"
+	"autobutler/pkg/util/serverutil"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// albumRequest is accepted as JSON or as a form. When updating an album,
+// fields that aren't given are left as they are.
+type albumRequest struct {
+	Name        *string `form:"name" json:"name"`
+	Description *string `form:"description" json:"description"`
+	CoverPath   *string `form:"coverPath" json:"coverPath"`
+}
+
+type albumPhotosRequest struct {
+	Paths []string `form:"paths" json:"paths"`
+}
+
+type albumsResponse struct {
+	Albums []albums.Album `json:"albums"`
+}
+
+type albumPhotosResponse struct {
+	Album      albums.Album   `json:"album"`
+	Photos     []photos.Photo `json:"photos"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+}
+
+func SetupAlbumRoutes(apiV1Group *gin.RouterGroup) {
+	addAlbumPhotosRoute(apiV1Group)
+	createAlbumRoute(apiV1Group)
+	deleteAlbumRoute(apiV1Group)
+	downloadAlbumRoute(apiV1Group)
+	getAlbumRoute(apiV1Group)
+	listAlbumPhotosRoute(apiV1Group)
+	listAlbumsRoute(apiV1Group)
+	removeAlbumPhotosRoute(apiV1Group)
+	reorderAlbumRoute(apiV1Group)
+	updateAlbumRoute(apiV1Group)
+}
+
+// addAlbumPhotosRoute adds the photos given as paths to the end of an album.
+func addAlbumPhotosRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/albums/:id/photos", func(c *gin.Context) *api.Response {
+		id, resp := albumID(c)
+		if resp != nil {
+			return resp
+		}
+		var request albumPhotosRequest
+		if err := c.ShouldBind(&request); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		if len(request.Paths) == 0 {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("no paths given"))
+		}
+		if err := albums.AddPhotos(id, request.Paths); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(albumErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+func createAlbumRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/albums", func(c *gin.Context) *api.Response {
+		var request albumRequest
+		if err := c.ShouldBind(&request); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		var name, description string
+		if request.Name != nil {
+			name = *request.Name
+		}
+		if request.Description != nil {
+			description = *request.Description
+		}
+		album, err := albums.Create(name, description)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(albumErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusCreated).WithData(album)
+	})
+}
+
+// deleteAlbumRoute deletes an album. Its photos stay where they are.
+func deleteAlbumRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "DELETE", "/albums/:id", func(c *gin.Context) *api.Response {
+		id, resp := albumID(c)
+		if resp != nil {
+			return resp
+		}
+		if err := albums.Delete(id); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(albumErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+// downloadAlbumRoute streams the photos of an album as one zip archive named
+// after it, in album order.
+func downloadAlbumRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/albums/:id/zip", func(c *gin.Context) *api.Response {
+		id, resp := albumID(c)
+		if resp != nil {
+			return resp
+		}
+		compression, err := fileutil.ParseZipCompression(c.Query("compression"))
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		album, err := albums.Get(id)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(albumErrorStatus(err)).WithError(err)
+		}
+		paths, err := albums.Paths(id)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(albumErrorStatus(err)).WithError(err)
+		}
+		if len(paths) == 0 {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("the album has no photos"))
+		}
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", contentDisposition("attachment", album.Name+".zip"))
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusOK)
+		c.Writer.WriteHeaderNow()
+		if err := fileutil.GetFilesRoot().WriteZip(c.Writer, paths, compression); err != nil {
+			// Only writing can fail, most likely because the client went
+			// away, so there is nobody left to tell.
+			fmt.Printf("Error streaming zip of album %d: %v\n", id, err)
+		}
+		return api.Ok()
+	})
+}
+
+func getAlbumRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/albums/:id", func(c *gin.Context) *api.Response {
+		id, resp := albumID(c)
+		if resp != nil {
+			return resp
+		}
+		album, err := albums.Get(id)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(albumErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(album)
+	})
+}
+
+// listAlbumPhotosRoute lists the photos of an album in album order. Later
+// pages are fetched with the nextCursor of the page before as cursor.
+func listAlbumPhotosRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/albums/:id/photos", func(c *gin.Context) *api.Response {
+		id, resp := albumID(c)
+		if resp != nil {
+			return resp
+		}
+		limit, err := queryInt(c, "limit", photos.DefaultLimit)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		album, err := albums.Get(id)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(albumErrorStatus(err)).WithError(err)
+		}
+		page, err := albums.Photos(id, c.Query("cursor"), limit)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(albumErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(albumPhotosResponse{
+			Album:      album,
+			Photos:     page.Photos,
+			NextCursor: page.NextCursor,
+		})
+	})
+}
+
+func listAlbumsRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/albums", func(c *gin.Context) *api.Response {
+		list, err := albums.List()
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(albumsResponse{Albums: list})
+	})
+}
+
+// removeAlbumPhotosRoute takes the paths given as filePaths in the query out
+// of an album.
+func removeAlbumPhotosRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "DELETE", "/albums/:id/photos", func(c *gin.Context) *api.Response {
+		id, resp := albumID(c)
+		if resp != nil {
+			return resp
+		}
+		filePaths := c.QueryArray("filePaths")
+		if len(filePaths) == 0 {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("no paths given"))
+		}
+		if err := albums.RemovePhotos(id, filePaths); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(albumErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+// reorderAlbumRoute moves the photos given as paths to the start of an album,
+// in the order given.
+func reorderAlbumRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "PUT", "/albums/:id/order", func(c *gin.Context) *api.Response {
+		id, resp := albumID(c)
+		if resp != nil {
+			return resp
+		}
+		var request albumPhotosRequest
+		if err := c.ShouldBind(&request); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		if err := albums.Reorder(id, request.Paths); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(albumErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+func updateAlbumRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "PATCH", "/albums/:id", func(c *gin.Context) *api.Response {
+		id, resp := albumID(c)
+		if resp != nil {
+			return resp
+		}
+		var request albumRequest
+		if err := c.ShouldBind(&request); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		album, err := albums.Update(id, albums.Changes{
+			Name:        request.Name,
+			Description: request.Description,
+			CoverPath:   request.CoverPath,
+		})
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(albumErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(album)
+	})
+}
+
+// albumID parses the album ID of a route, or returns the response for one
+// that can't be an album.
+func albumID(c *gin.Context) (int64, *api.Response) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("invalid album ID"))
+	}
+	return id, nil
+}
+
+// albumErrorStatus maps an error from the albums package onto an HTTP status
+// code.
+func albumErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, albums.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, albums.ErrInvalid), errors.Is(err, photos.ErrInvalidCursor):
+		return http.StatusBadRequest
+	default:
+		return fileErrorStatus(err)
+	}
+}
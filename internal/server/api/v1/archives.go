@@ -0,0 +1,152 @@
+package v1
+
+import (
+	"autobutler/pkg/api"
+	"autobutler/pkg/archive"
+	"autobutler/pkg/filechanges"
+	"autobutler/pkg/jobs"
+	"autobutler/pkg/util/fileutil"
+	"autobutler/pkg/util/serverutil"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+type archiveEntriesResponse struct {
+	Entries []archive.Entry `json:"entries"`
+}
+
+// extractRequest is accepted as JSON or as a form, with entries repeated once
+// per name. No entries extracts the whole archive, and no destination
+// extracts it into a folder named after it, next to it.
+type extractRequest struct {
+	Entries     []string `form:"entries" json:"entries"`
+	Destination string   `form:"destination" json:"destination"`
+}
+
+// Like the batch file operations, the archive routes live outside of /files
+// to stay clear of its catch-all parameter.
+func SetupArchiveRoutes(apiV1Group *gin.RouterGroup) {
+	archiveEntriesRoute(apiV1Group)
+	extractArchiveRoute(apiV1Group)
+}
+
+// archiveEntriesRoute lists the entries of an archive, or downloads the one
+// named by the entry query parameter.
+func archiveEntriesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/archive/files/*filePath", func(c *gin.Context) *api.Response {
+		filePath := c.Param("filePath")
+		if name, ok := c.GetQuery("entry"); ok {
+			return downloadArchiveEntry(c, filePath, name)
+		}
+		entries, err := archive.List(filePath)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(archiveErrorStatus(err)).WithError(err)
+		}
+		if entries == nil {
+			entries = []archive.Entry{}
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(archiveEntriesResponse{Entries: entries})
+	})
+}
+
+// downloadArchiveEntry streams a single file out of an archive. Nothing is
+// written until the entry has been found, so that errors can still be
+// reported with a proper status.
+func downloadArchiveEntry(c *gin.Context, filePath string, name string) *api.Response {
+	err := archive.ReadEntry(filePath, name, func(entry archive.Entry, content io.Reader) error {
+		buffered := bufio.NewReader(content)
+		head, err := buffered.Peek(512)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		fileName := path.Base(entry.Name)
+		c.Header("Content-Type", fileutil.SniffMimeType(fileName, head))
+		c.Header("Content-Length", strconv.FormatInt(entry.SizeBytes, 10))
+		c.Header("Content-Disposition", contentDisposition("attachment", fileName))
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusOK)
+		c.Writer.WriteHeaderNow()
+		if _, err := io.Copy(c.Writer, buffered); err != nil {
+			// The status has already been sent, so the client only notices
+			// the missing bytes.
+			fmt.Printf("Error streaming %s from %s: %v\n", entry.Name, filePath, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(archiveErrorStatus(err)).WithError(err)
+	}
+	return api.Ok()
+}
+
+func extractArchiveRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/extract/files/*filePath", func(c *gin.Context) *api.Response {
+		filePath := c.Param("filePath")
+		var request extractRequest
+		if err := c.ShouldBind(&request); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		if !archive.Supported(filePath) {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusUnsupportedMediaType).WithError(fmt.Errorf("%s: %w", filepath.Base(filePath), archive.ErrUnsupported))
+		}
+		destination := request.Destination
+		if destination == "" {
+			destination = filepath.Join(filepath.Dir(filePath), archive.Stem(filePath))
+		}
+
+		var mutex sync.Mutex
+		var extractErr error
+		description := fmt.Sprintf("Extract %s to %s", filePath, destination)
+		job, err := jobs.Submit("extract", description, int64(len(request.Entries)), []string{filePath, destination}, func(ctx context.Context, progress *jobs.Progress) (any, error) {
+			created, err := archive.Extract(ctx, filePath, request.Entries, destination, progress)
+			for _, createdPath := range created {
+				filechanges.Added(createdPath)
+			}
+			mutex.Lock()
+			extractErr = err
+			mutex.Unlock()
+			return created, err
+		})
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		job, finished, err := jobs.Wait(c.Request.Context(), job.ID, inlineJobTimeout)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		if finished && extractErr != nil && !errors.Is(extractErr, context.Canceled) {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(archiveErrorStatus(extractErr)).WithError(extractErr)
+		}
+		return jobResponse(job, finished)
+	})
+}
+
+// archiveErrorStatus maps an error from reading or extracting an archive onto
+// an HTTP status code.
+func archiveErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, archive.ErrUnsupported):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, archive.ErrEntryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, archive.ErrTooLarge), errors.Is(err, archive.ErrTooManyFiles):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, archive.ErrUnsafePath):
+		return http.StatusUnprocessableEntity
+	default:
+		return fileErrorStatus(err)
+	}
+}
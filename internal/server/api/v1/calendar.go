@@ -7,13 +7,26 @@ import (
 	"autobutler/pkg/calendar"
 	"autobutler/pkg/db"
 	"autobutler/pkg/util/serverutil"
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
+	"github.com/a-h/templ"
 	"github.com/gin-gonic/gin"
 )
 
+// calendarMonthResponse lists the events starting in a month.
+type calendarMonthResponse struct {
+	Year   int                       `json:"year"`
+	Month  int                       `json:"month"`
+	Events []*calendar.CalendarEvent `json:"events"`
+}
+
 func SetupCalendarRoutes(apiV1Group *gin.RouterGroup) {
 	deleteCalendarEvent(apiV1Group)
 	getCalendarEvent(apiV1Group)
@@ -26,36 +39,16 @@ func deleteCalendarEvent(apiV1Group *gin.RouterGroup) {
 	serverutil.ApiRoute(apiV1Group, "DELETE", "/calendar/events/:eventId", func(c *gin.Context) *api.Response {
 		eventId, err := strconv.Atoi(c.Param("eventId"))
 		if err != nil {
-			return api.NewResponse().WithStatusCode(400).WithData(`<span class="text-red-500">Invalid event ID</span>`)
+			return api.NewResponse().WithStatusCode(400).WithError(errors.New("invalid event ID"))
 		}
 
-		viewYearString := c.Query("viewYear")
-		viewMonthString := c.Query("viewMonth")
-
 		if err := db.Instance.DeleteCalendarEvent(eventId); err != nil {
-			return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">` + err.Error() + `</span>`)
+			return api.NewResponse().WithStatusCode(500).WithError(err)
 		}
-
-		// Return to the month the user was viewing
-		if viewYearString != "" && viewMonthString != "" {
-			viewYear, err := strconv.Atoi(viewYearString)
-			if err == nil {
-				viewMonth, err := strconv.Atoi(viewMonthString)
-				if err == nil && viewMonth >= 1 && viewMonth <= 12 {
-					targetTime := time.Date(viewYear, time.Month(viewMonth), 1, 0, 0, 0, 0, time.UTC)
-					if err := cal.ComponentWithTime(calendar.CalendarViewMonth, targetTime).Render(c.Request.Context(), c.Writer); err != nil {
-						return api.NewResponse().WithStatusCode(400)
-					}
-					return api.Ok()
-				}
-			}
+		if serverutil.WantsJSON(c) {
+			return api.NewResponse().WithStatusCode(http.StatusNoContent)
 		}
-
-		// Fallback to current month if no view context provided
-		if err := cal.Component(calendar.CalendarViewMonth).Render(c.Request.Context(), c.Writer); err != nil {
-			return api.NewResponse().WithStatusCode(400)
-		}
-		return api.Ok()
+		return api.Ok().WithComponent(viewedMonthComponent(c.Query("viewYear"), c.Query("viewMonth")))
 	})
 }
 
@@ -63,17 +56,15 @@ func getCalendarEvent(apiV1Group *gin.RouterGroup) {
 	serverutil.ApiRoute(apiV1Group, "GET", "/calendar/:eventId", func(c *gin.Context) *api.Response {
 		eventId, err := strconv.Atoi(c.Param("eventId"))
 		if err != nil {
-			return api.NewResponse().WithStatusCode(400).WithData(`<span class="text-red-500">Invalid event ID</span>`)
+			return api.NewResponse().WithStatusCode(400).WithError(errors.New("invalid event ID"))
 		}
 
 		event, err := db.DatabaseQueries.GetCalendarEvent(context.Background(), int64(eventId))
 		if err != nil {
-			return api.NewResponse().WithStatusCode(404).WithData(`<span class="text-red-500">Event not found</span>`)
-		}
-		if err := event_editor.ComponentWithEvent(*db.NewCalendarEvent(event)).Render(c.Request.Context(), c.Writer); err != nil {
-			return api.NewResponse().WithStatusCode(500)
+			return api.NewResponse().WithStatusCode(404).WithError(errors.New("event not found"))
 		}
-		return api.Ok()
+		calendarEvent := db.NewCalendarEvent(event)
+		return api.NewResponse().WithData(calendarEvent).WithComponent(event_editor.ComponentWithEvent(*calendarEvent))
 	})
 }
 
@@ -84,166 +75,130 @@ func getCalendarMonth(apiV1Group *gin.RouterGroup) {
 
 		year, err := strconv.Atoi(yearStr)
 		if err != nil {
-			return api.NewResponse().WithStatusCode(400).WithData(`<span class="text-red-500">Invalid year</span>`)
+			return api.NewResponse().WithStatusCode(400).WithError(errors.New("invalid year"))
 		}
 
 		month, err := strconv.Atoi(monthStr)
 		if err != nil {
-			return api.NewResponse().WithStatusCode(400).WithData(`<span class="text-red-500">Invalid month</span>`)
+			return api.NewResponse().WithStatusCode(400).WithError(errors.New("invalid month"))
 		}
 
 		targetTime := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-		if err := cal.ComponentWithTime(calendar.CalendarViewMonth, targetTime).Render(c.Request.Context(), c.Writer); err != nil {
-			return api.NewResponse().WithStatusCode(500)
+		if !serverutil.WantsJSON(c) {
+			return api.Ok().WithComponent(cal.ComponentWithTime(calendar.CalendarViewMonth, targetTime))
+		}
+		if month < 1 || month > 12 {
+			return api.NewResponse().WithStatusCode(400).WithError(errors.New("invalid month"))
+		}
+		eventMap, err := db.Instance.QueryCalendarEventsForMonth(db.DefaultCalendarId, year, time.Month(month), false)
+		if err != nil {
+			return api.NewResponse().WithStatusCode(500).WithError(err)
 		}
-		return api.Ok()
+		events := []*calendar.CalendarEvent{}
+		for _, dayEvents := range eventMap {
+			events = append(events, dayEvents...)
+		}
+		slices.SortFunc(events, func(a, b *calendar.CalendarEvent) int {
+			return cmp.Or(a.StartTime.Compare(b.StartTime), cmp.Compare(a.ID, b.ID))
+		})
+		return api.NewResponse().WithData(calendarMonthResponse{Year: year, Month: month, Events: events})
 	})
 }
 
 func newCalendarEvent(apiV1Group *gin.RouterGroup) {
 	serverutil.ApiRoute(apiV1Group, "POST", "/calendar/events", func(c *gin.Context) *api.Response {
-		yearString := c.PostForm("year")
-		monthString := c.PostForm("month")
-		dayString := c.PostForm("day")
-		title := c.PostForm("title")
-		startTimeString := c.PostForm("startTime")
-		endTimeString := c.PostForm("endTime")
-		description := c.PostForm("description")
-		location := c.PostForm("location")
-		viewYearString := c.PostForm("viewYear")
-		viewMonthString := c.PostForm("viewMonth")
-
-		startTime, err := makeTime(yearString, monthString, dayString, startTimeString)
-		if err != nil {
-			return api.NewResponse().WithStatusCode(400).WithData(`<span class="text-red-500">Invalid start time: ` + err.Error() + `</span>`)
-		}
-		var calendarEvent *calendar.CalendarEvent
-		if endTimeString == "" {
-			calendarEvent = calendar.NewCalendarEvent(
-				title,
-				description,
-				*startTime,
-				false,
-				location,
-				db.DefaultCalendarId,
-			)
-		} else {
-			endTime, err := makeTime(yearString, monthString, dayString, endTimeString)
-			if err != nil {
-				return api.NewResponse().WithStatusCode(400).WithData(`<span class="text-red-500">Invalid end time: ` + err.Error() + `</span>`)
-			}
-			calendarEvent = calendar.NewCalendarEventWithEnd(
-				title,
-				description,
-				*startTime,
-				*endTime,
-				false,
-				location,
-				db.DefaultCalendarId,
-			)
-		}
-		if _, err := db.Instance.UpsertCalendarEvent(*calendarEvent); err != nil {
-			return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">` + err.Error() + `</span>`)
-		}
-
-		// Return to the month the user was viewing
-		if viewYearString != "" && viewMonthString != "" {
-			viewYear, err := strconv.Atoi(viewYearString)
-			if err == nil {
-				viewMonth, err := strconv.Atoi(viewMonthString)
-				if err == nil && viewMonth >= 1 && viewMonth <= 12 {
-					targetTime := time.Date(viewYear, time.Month(viewMonth), 1, 0, 0, 0, 0, time.UTC)
-					if err := cal.ComponentWithTime(calendar.CalendarViewMonth, targetTime).Render(c.Request.Context(), c.Writer); err != nil {
-						return api.NewResponse().WithStatusCode(400)
-					}
-					return api.Ok()
-				}
-			}
+		calendarEvent, resp := calendarEventFromForm(c)
+		if resp != nil {
+			return resp
 		}
-
-		// Fallback to current month if no view context provided
-		if err := cal.Component(calendar.CalendarViewMonth).Render(c.Request.Context(), c.Writer); err != nil {
-			return api.NewResponse().WithStatusCode(400)
+		saved, err := db.Instance.UpsertCalendarEvent(*calendarEvent)
+		if err != nil {
+			return api.NewResponse().WithStatusCode(500).WithError(err)
 		}
-		return api.Ok()
+		return api.NewResponse().
+			WithStatusCode(http.StatusCreated).
+			WithData(db.NewCalendarEvent(*saved)).
+			WithComponent(viewedMonthComponent(c.PostForm("viewYear"), c.PostForm("viewMonth")))
 	})
 }
 
 func updateCalendarEvent(apiV1Group *gin.RouterGroup) {
 	serverutil.ApiRoute(apiV1Group, "PUT", "/calendar/events", func(c *gin.Context) *api.Response {
 		eventId := c.PostForm("id")
-		yearString := c.PostForm("year")
-		monthString := c.PostForm("month")
-		dayString := c.PostForm("day")
-		title := c.PostForm("title")
-		startTimeString := c.PostForm("startTime")
-		endTimeString := c.PostForm("endTime")
-		description := c.PostForm("description")
-		location := c.PostForm("location")
-		viewYearString := c.PostForm("viewYear")
-		viewMonthString := c.PostForm("viewMonth")
-
-		startTime, err := makeTime(yearString, monthString, dayString, startTimeString)
-		if err != nil {
-			return api.NewResponse().WithStatusCode(400).WithData(`<span class="text-red-500">Invalid start time: ` + err.Error() + `</span>`)
-		}
-		var calendarEvent *calendar.CalendarEvent
-		if endTimeString == "" {
-			calendarEvent = calendar.NewCalendarEvent(
-				title,
-				description,
-				*startTime,
-				false,
-				location,
-				db.DefaultCalendarId,
-			)
-		} else {
-			endTime, err := makeTime(yearString, monthString, dayString, endTimeString)
-			if err != nil {
-				return api.NewResponse().WithStatusCode(400).WithData(`<span class="text-red-500">` + "Invalid end time: " + err.Error() + `</span>`)
-			}
-			calendarEvent = calendar.NewCalendarEventWithEnd(
-				title,
-				description,
-				*startTime,
-				*endTime,
-				false,
-				location,
-				db.DefaultCalendarId,
-			)
+		calendarEvent, resp := calendarEventFromForm(c)
+		if resp != nil {
+			return resp
 		}
 		if eventId != "" {
 			eventId, err := strconv.Atoi(eventId)
 			calendarEvent.ID = int64(eventId)
 			if err != nil {
-				return api.NewResponse().WithStatusCode(400).WithData(`<span class="text-red-500">` + "Invalid event ID: " + err.Error() + `</span>`)
+				return api.NewResponse().WithStatusCode(400).WithError(fmt.Errorf("invalid event ID: %w", err))
 			}
 		}
-		if _, err := db.Instance.UpsertCalendarEvent(*calendarEvent); err != nil {
-			return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">` + err.Error() + `</span>`)
+		saved, err := db.Instance.UpsertCalendarEvent(*calendarEvent)
+		if err != nil {
+			return api.NewResponse().WithStatusCode(500).WithError(err)
 		}
+		return api.NewResponse().
+			WithData(db.NewCalendarEvent(*saved)).
+			WithComponent(viewedMonthComponent(c.PostForm("viewYear"), c.PostForm("viewMonth")))
+	})
+}
 
-		// Return to the month the user was viewing
-		if viewYearString != "" && viewMonthString != "" {
-			viewYear, err := strconv.Atoi(viewYearString)
-			if err == nil {
-				viewMonth, err := strconv.Atoi(viewMonthString)
-				if err == nil && viewMonth >= 1 && viewMonth <= 12 {
-					targetTime := time.Date(viewYear, time.Month(viewMonth), 1, 0, 0, 0, 0, time.UTC)
-					if err := cal.ComponentWithTime(calendar.CalendarViewMonth, targetTime).Render(c.Request.Context(), c.Writer); err != nil {
-						return api.NewResponse().WithStatusCode(400)
-					}
-					return api.Ok()
-				}
-			}
-		}
+// calendarEventFromForm reads the event being created or updated from the
+// form of a request, or returns the response for a form that doesn't make
+// sense.
+func calendarEventFromForm(c *gin.Context) (*calendar.CalendarEvent, *api.Response) {
+	yearString := c.PostForm("year")
+	monthString := c.PostForm("month")
+	dayString := c.PostForm("day")
+	title := c.PostForm("title")
+	startTimeString := c.PostForm("startTime")
+	endTimeString := c.PostForm("endTime")
+	description := c.PostForm("description")
+	location := c.PostForm("location")
+
+	startTime, err := makeTime(yearString, monthString, dayString, startTimeString)
+	if err != nil {
+		return nil, api.NewResponse().WithStatusCode(400).WithError(fmt.Errorf("invalid start time: %w", err))
+	}
+	if endTimeString == "" {
+		return calendar.NewCalendarEvent(
+			title,
+			description,
+			*startTime,
+			false,
+			location,
+			db.DefaultCalendarId,
+		), nil
+	}
+	endTime, err := makeTime(yearString, monthString, dayString, endTimeString)
+	if err != nil {
+		return nil, api.NewResponse().WithStatusCode(400).WithError(fmt.Errorf("invalid end time: %w", err))
+	}
+	return calendar.NewCalendarEventWithEnd(
+		title,
+		description,
+		*startTime,
+		*endTime,
+		false,
+		location,
+		db.DefaultCalendarId,
+	), nil
+}
 
-		// Fallback to current month if no view context provided
-		if err := cal.Component(calendar.CalendarViewMonth).Render(c.Request.Context(), c.Writer); err != nil {
-			return api.NewResponse().WithStatusCode(400)
+// viewedMonthComponent renders the month the user was viewing, falling back
+// to the current month if no view context was provided.
+func viewedMonthComponent(viewYearString string, viewMonthString string) templ.Component {
+	if viewYear, err := strconv.Atoi(viewYearString); err == nil {
+		viewMonth, err := strconv.Atoi(viewMonthString)
+		if err == nil && viewMonth >= 1 && viewMonth <= 12 {
+			targetTime := time.Date(viewYear, time.Month(viewMonth), 1, 0, 0, 0, 0, time.UTC)
+			return cal.ComponentWithTime(calendar.CalendarViewMonth, targetTime)
 		}
-		return api.Ok()
-	})
+	}
+	return cal.Component(calendar.CalendarViewMonth)
 }
 
 func makeTime(yearString, monthString, dayString string, startTime string) (*time.Time, error) {
@@ -0,0 +1,238 @@
+package v1
+
+import (
+	"autobutler/pkg/filechanges"
+	"autobutler/pkg/fileops"
+	"autobutler/pkg/quotas"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/webdav"
+)
+
+// davMethods are the methods of WebDAV class 1 and 2, for mounting the files
+// root from file managers.
+var davMethods = []string{
+	"OPTIONS", "GET", "HEAD", "POST", "PUT", "DELETE",
+	"MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK", "PROPFIND", "PROPPATCH",
+}
+
+// contentRangePattern matches the Content-Range of a partial PUT, which
+// writes a range of bytes into an existing file. The total may be unknown.
+var contentRangePattern = regexp.MustCompile(`^bytes (\d+)-(\d+)/(\d+|\*)$`)
+
+// partialPutOffset is the context key for the offset a partial PUT writes at.
+type partialPutOffset struct{}
+
+func SetupDavRoutes(davGroup *gin.RouterGroup) {
+	handler := &webdav.Handler{
+		Prefix:     davGroup.BasePath(),
+		FileSystem: davFileSystem{},
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				fmt.Printf("Error handling WebDAV %s %s: %v\n", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	for _, method := range davMethods {
+		// ApiRoute only knows the standard methods, so register directly
+		davGroup.Handle(method, "/*filePath", func(c *gin.Context) {
+			if c.Request.Method == http.MethodPut && c.GetHeader("Content-Range") != "" {
+				if status, err := preparePartialPut(c); err != nil {
+					c.String(status, err.Error())
+					return
+				}
+			}
+			if c.Request.Method == http.MethodPut {
+				if err := checkPutSpace(c); err != nil {
+					c.String(fileErrorStatus(err), err.Error())
+					return
+				}
+			}
+			if c.Request.Method == "COPY" {
+				if err := checkCopySpace(c, handler.Prefix); err != nil {
+					c.String(fileErrorStatus(err), err.Error())
+					return
+				}
+			}
+			handler.ServeHTTP(c.Writer, c.Request)
+		})
+	}
+}
+
+// preparePartialPut checks the Content-Range of a PUT, and passes the offset
+// it starts at on to davFileSystem.OpenFile. Ranges may start anywhere up to
+// the end of the file, so that uploads can be resumed.
+func preparePartialPut(c *gin.Context) (int, error) {
+	match := contentRangePattern.FindStringSubmatch(c.GetHeader("Content-Range"))
+	if match == nil {
+		return http.StatusBadRequest, errors.New("invalid Content-Range")
+	}
+	start, startErr := strconv.ParseInt(match[1], 10, 64)
+	end, endErr := strconv.ParseInt(match[2], 10, 64)
+	if startErr != nil || endErr != nil || end < start {
+		return http.StatusBadRequest, errors.New("invalid Content-Range")
+	}
+	length := end - start + 1
+	if c.Request.ContentLength >= 0 && c.Request.ContentLength != length {
+		return http.StatusBadRequest, errors.New("Content-Length doesn't match Content-Range")
+	}
+	var size int64
+	info, err := fileutil.GetFilesRoot().Stat(c.Param("filePath"))
+	if err == nil {
+		size = info.Size()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fileErrorStatus(err), err
+	}
+	if start > size {
+		return http.StatusRequestedRangeNotSatisfiable, fmt.Errorf("range starts after the end of the file, at %d bytes", size)
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, length)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), partialPutOffset{}, start))
+	return 0, nil
+}
+
+// checkPutSpace checks that what a PUT adds to its file fits, when the size
+// of the body is known.
+func checkPutSpace(c *gin.Context) error {
+	filePath := c.Param("filePath")
+	var size, files int64 = 0, 1
+	if info, err := fileutil.GetFilesRoot().Stat(filePath); err == nil {
+		size, files = info.Size(), 0
+	}
+	end := c.Request.ContentLength
+	if end < 0 {
+		return nil
+	}
+	if offset, partial := c.Request.Context().Value(partialPutOffset{}).(int64); partial {
+		end += offset
+	}
+	return quotas.Check(path.Dir(filePath), max(end-size, 0), files)
+}
+
+// checkCopySpace checks that what a COPY creates fits in the folder of its
+// Destination. Sources and destinations the handler turns away anyway are
+// left to it.
+func checkCopySpace(c *gin.Context, prefix string) error {
+	destination, err := url.Parse(c.GetHeader("Destination"))
+	if err != nil {
+		return nil
+	}
+	destPath, ok := strings.CutPrefix(destination.Path, prefix)
+	if !ok || destPath == "" {
+		return nil
+	}
+	err = quotas.CheckCopy([]string{c.Param("filePath")}, path.Dir(path.Clean(destPath)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// davFileSystem serves the files root over WebDAV. Changes are passed on like
+// changes made through the rest of the API, and deleted files go to the
+// trash.
+type davFileSystem struct{}
+
+func (davFileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	if err := fileutil.GetFilesRoot().Mkdir(name, perm); err != nil {
+		return err
+	}
+	filechanges.Added(name)
+	return nil
+}
+
+func (davFileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	root := fileutil.GetFilesRoot()
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) == 0 {
+		file, err := root.OpenFile(name, flag, perm)
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	offset, partial := ctx.Value(partialPutOffset{}).(int64)
+	if partial {
+		flag &^= os.O_TRUNC
+	}
+	previous, _ := root.Stat(name)
+	release := filechanges.Hold(name)
+	file, err := root.OpenFile(name, flag, perm)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if partial {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			release()
+			return nil, err
+		}
+	}
+	return &davFile{File: file, name: name, previous: previous, release: release}, nil
+}
+
+func (davFileSystem) RemoveAll(ctx context.Context, name string) error {
+	if _, err := fileutil.GetFilesRoot().Lstat(name); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	release := filechanges.Hold(name)
+	defer release()
+	results := fileops.Delete(ctx, []string{name}, nil)
+	notifyFileOperation(results)
+	return results[0].Err()
+}
+
+func (davFileSystem) Rename(ctx context.Context, oldName string, newName string) error {
+	root := fileutil.GetFilesRoot()
+	if err := quotas.CheckMove(oldName, path.Dir(newName)); err != nil {
+		return err
+	}
+	replaced, _ := root.Lstat(newName)
+	release := filechanges.Hold(oldName, newName)
+	defer release()
+	if err := fileutil.MoveBetweenRootsContext(ctx, root, oldName, root, newName, nil); err != nil {
+		return err
+	}
+	filechanges.Moved(oldName, newName, replaced)
+	return nil
+}
+
+func (davFileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	return fileutil.GetFilesRoot().Stat(name)
+}
+
+// davFile is a file opened for writing, which reports the change once it's
+// closed.
+type davFile struct {
+	*os.File
+	name string
+	// previous is what the file was before it was opened, or nil if it's new.
+	previous fs.FileInfo
+	// release lets the file watcher look at the file again.
+	release func()
+}
+
+func (f *davFile) Close() error {
+	err := f.File.Close()
+	if f.previous != nil {
+		filechanges.Modified(f.name, f.previous.Size())
+	} else {
+		filechanges.Added(f.name)
+	}
+	f.release()
+	return err
+}
@@ -2,10 +2,10 @@ package v1
 
 import (
 	"autobutler/pkg/api"
+	"autobutler/pkg/filechanges"
 	"autobutler/pkg/util/fileutil"
 	"fmt"
-	"html"
-	"path/filepath"
+	"os"
 
 	"autobutler/pkg/quill"
 	"autobutler/pkg/util/serverutil"
@@ -26,15 +26,33 @@ func saveDocRoute(apiV1Group *gin.RouterGroup) {
 			fmt.Println("Saving DOCX file:", filePath)
 			var delta quill.Delta
 			if err := c.BindJSON(&delta); err != nil {
-				return api.NewResponse().WithStatusCode(400).WithData(`<span class="text-red-500">Failed to parse delta: ` + html.EscapeString(err.Error()) + `</span>`)
+				return api.NewResponse().WithStatusCode(400).WithError(fmt.Errorf("failed to parse delta: %w", err))
 			}
-			fullPath := filepath.Join(fileutil.GetFilesDir(), filePath)
-			if err := delta.SaveDocxFile(fullPath); err != nil {
-				return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">Failed to save DOCX file: ` + html.EscapeString(err.Error()) + `</span>`)
+			previous, statErr := fileutil.GetFilesRoot().Stat(filePath)
+			file, err := fileutil.GetFilesRoot().OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(fmt.Errorf("failed to save DOCX file: %w", err))
+			}
+			defer file.Close()
+			if err := delta.WriteDocx(file); err != nil {
+				return api.NewResponse().WithStatusCode(500).WithError(fmt.Errorf("failed to save DOCX file: %w", err))
+			}
+			if err := file.Close(); err != nil {
+				return api.NewResponse().WithStatusCode(500).WithError(fmt.Errorf("failed to save DOCX file: %w", err))
+			}
+			if statErr == nil {
+				filechanges.Modified(filePath, previous.Size())
+			} else {
+				filechanges.Added(filePath)
+			}
+			if serverutil.WantsJSON(c) {
+				if saved, err := statFileResponse(filePath, false); err == nil {
+					return api.Ok().WithData(saved)
+				}
 			}
 			return api.Ok()
 		default:
-			return api.NewResponse().WithStatusCode(400).WithData(`<span class="text-red-500">Unsupported file type for saving a doc: ` + html.EscapeString(string(fileType)) + `</span>`)
+			return api.NewResponse().WithStatusCode(400).WithError(fmt.Errorf("unsupported file type for saving a doc: %s", fileType))
 		}
 	})
 }
@@ -0,0 +1,146 @@
+package v1
+
+import (
+	"autobutler/pkg/api"
+	"autobutler/pkg/dedup"
+	"autobutler/pkg/fileops"
+	"autobutler/pkg/jobs"
+	"autobutler/pkg/util/serverutil"
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type duplicatesResponse struct {
+	Clusters []dedup.Cluster `json:"clusters"`
+	// ScannedAt is when the last scan finished, if one has since startup
+	ScannedAt *time.Time `json:"scannedAt,omitempty"`
+}
+
+// resolveDuplicatesRequest is accepted as JSON or as a form. Paths are every
+// photo of the cluster as it was reviewed, including the one to keep.
+type resolveDuplicatesRequest struct {
+	Keep  string   `form:"keep" json:"keep"`
+	Paths []string `form:"paths" json:"paths"`
+}
+
+func SetupDuplicateRoutes(apiV1Group *gin.RouterGroup) {
+	getDuplicatesRoute(apiV1Group)
+	listDuplicatesRoute(apiV1Group)
+	resolveDuplicatesRoute(apiV1Group)
+	scanDuplicatesRoute(apiV1Group)
+}
+
+func getDuplicatesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/duplicates/:id", func(c *gin.Context) *api.Response {
+		id, resp := duplicatesID(c)
+		if resp != nil {
+			return resp
+		}
+		cluster, err := dedup.Get(id)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(duplicatesErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(cluster)
+	})
+}
+
+// listDuplicatesRoute lists the clusters of duplicate photos found by the
+// last scan, each with the best copy first.
+func listDuplicatesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/duplicates", func(c *gin.Context) *api.Response {
+		clusters, err := dedup.List()
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		response := duplicatesResponse{Clusters: clusters}
+		if response.Clusters == nil {
+			response.Clusters = []dedup.Cluster{}
+		}
+		if scannedAt := dedup.ScannedAt(); !scannedAt.IsZero() {
+			response.ScannedAt = &scannedAt
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(response)
+	})
+}
+
+// resolveDuplicatesRoute keeps one photo of a cluster and moves the rest to
+// the trash, like deleting them through the files API. Clusters that changed
+// since they were reviewed are left alone, so that only photos the user saw
+// are trashed.
+func resolveDuplicatesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/duplicates/:id/resolve", func(c *gin.Context) *api.Response {
+		id, resp := duplicatesID(c)
+		if resp != nil {
+			return resp
+		}
+		var request resolveDuplicatesRequest
+		if err := c.ShouldBind(&request); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		if request.Keep == "" {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("no photo to keep given"))
+		}
+		if len(request.Paths) == 0 {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("no reviewed photos given"))
+		}
+		cluster, err := dedup.Get(id)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(duplicatesErrorStatus(err)).WithError(err)
+		}
+		others, err := cluster.Others(request.Keep, request.Paths)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(duplicatesErrorStatus(err)).WithError(err)
+		}
+		sources := make([]string, len(others))
+		for i, other := range others {
+			sources[i] = "/" + other
+		}
+		job, finished, err := submitFileOperation(c, "delete", "Trash duplicates of "+path.Base(request.Keep), sources, "", func(ctx context.Context, progress *jobs.Progress) []fileops.Result {
+			return fileops.Delete(ctx, sources, progress)
+		})
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		return jobResponse(job, finished)
+	})
+}
+
+// scanDuplicatesRoute scans for duplicates now, rather than after the next
+// change to the photo catalogue.
+func scanDuplicatesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/duplicates/scan", func(c *gin.Context) *api.Response {
+		dedup.Scan()
+		return api.NewResponse().WithStatusCode(http.StatusAccepted)
+	})
+}
+
+// duplicatesID parses the cluster ID of a route, or returns the response for
+// one that can't be a cluster.
+func duplicatesID(c *gin.Context) (int64, *api.Response) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("invalid duplicates ID"))
+	}
+	return id, nil
+}
+
+// duplicatesErrorStatus maps an error from the dedup package onto an HTTP
+// status code.
+func duplicatesErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dedup.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dedup.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, dedup.ErrChanged):
+		return http.StatusConflict
+	default:
+		return fileErrorStatus(err)
+	}
+}
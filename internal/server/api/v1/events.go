@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"autobutler/pkg/api"
+	"autobutler/pkg/fsevents"
+	"autobutler/pkg/jobs"
+	"autobutler/pkg/util/serverutil"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// keepAliveInterval is how often idle event streams get a comment, so that
+// proxies don't close them.
+const keepAliveInterval = 30 * time.Second
+
+func SetupEventRoutes(apiV1Group *gin.RouterGroup) {
+	fileEventsRoute(apiV1Group)
+	jobEventsRoute(apiV1Group)
+}
+
+// fileEventsRoute streams changes to the files root as Server-Sent Events.
+// Each "fs-change" event carries a fsevents.Event as JSON.
+func fileEventsRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/events/files", func(c *gin.Context) *api.Response {
+		events, unsubscribe := fsevents.Subscribe()
+		defer unsubscribe()
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		startEventStream(c)
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case event := <-events:
+				c.SSEvent("fs-change", event)
+			case <-keepAlive.C:
+				io.WriteString(w, ": keep-alive\n\n")
+			}
+			return true
+		})
+		return api.Ok()
+	})
+}
+
+// jobEventsRoute streams the progress of jobs as Server-Sent Events. Each
+// "job" event carries a jobs.Job as JSON, sent whenever the job changes.
+func jobEventsRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/events/jobs", func(c *gin.Context) *api.Response {
+		updates, unsubscribe := jobs.Subscribe()
+		defer unsubscribe()
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		startEventStream(c)
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case job := <-updates:
+				c.SSEvent("job", job)
+			case <-keepAlive.C:
+				io.WriteString(w, ": keep-alive\n\n")
+			}
+			return true
+		})
+		return api.Ok()
+	})
+}
+
+// startEventStream sends the headers of a Server-Sent Events stream right
+// away, so clients know the stream is open before the first event.
+func startEventStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+}
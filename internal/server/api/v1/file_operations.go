@@ -0,0 +1,172 @@
+package v1
+
+import (
+	"autobutler/pkg/api"
+	"autobutler/pkg/filechanges"
+	"autobutler/pkg/fileops"
+	"autobutler/pkg/jobs"
+	"autobutler/pkg/util/serverutil"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inlineJobTimeout is how long a request waits for the job it submitted.
+// Jobs that take longer carry on in the background, and the request returns
+// 202 Accepted with the job to follow instead.
+const inlineJobTimeout = 3 * time.Second
+
+// fileOperationRequest is accepted as JSON or as a form, with sources
+// repeated once per path.
+type fileOperationRequest struct {
+	Sources     []string `form:"sources" json:"sources"`
+	Destination string   `form:"destination" json:"destination"`
+	OnCollision string   `form:"onCollision" json:"onCollision"`
+}
+
+// The batch routes live outside of /files, whose catch-all parameter can't
+// share a prefix with static paths. Every item is attempted, and the outcome
+// of each is reported so partial failures can be shown.
+func SetupFileOperationRoutes(apiV1Group *gin.RouterGroup) {
+	copyFilesRoute(apiV1Group)
+	duplicateFilesRoute(apiV1Group)
+	moveFilesRoute(apiV1Group)
+}
+
+func copyFilesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/copy/files", func(c *gin.Context) *api.Response {
+		request, policy, err := bindFileOperation(c)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		if err := fileops.CheckCopy(request.Sources, request.Destination); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(fileErrorStatus(err)).WithError(err)
+		}
+		description := fmt.Sprintf("Copy %s to %s", describeSources(request.Sources), request.Destination)
+		return runFileOperation(c, "copy", description, request.Sources, request.Destination, func(ctx context.Context, progress *jobs.Progress) []fileops.Result {
+			return fileops.Copy(ctx, request.Sources, request.Destination, policy, progress)
+		})
+	})
+}
+
+func duplicateFilesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/duplicate/files", func(c *gin.Context) *api.Response {
+		request, _, err := bindFileOperation(c)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		if err := fileops.CheckDuplicate(request.Sources); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(fileErrorStatus(err)).WithError(err)
+		}
+		description := "Duplicate " + describeSources(request.Sources)
+		return runFileOperation(c, "duplicate", description, request.Sources, "", func(ctx context.Context, progress *jobs.Progress) []fileops.Result {
+			return fileops.Duplicate(ctx, request.Sources, progress)
+		})
+	})
+}
+
+func moveFilesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/move/files", func(c *gin.Context) *api.Response {
+		request, policy, err := bindFileOperation(c)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		description := fmt.Sprintf("Move %s to %s", describeSources(request.Sources), request.Destination)
+		return runFileOperation(c, "move", description, request.Sources, request.Destination, func(ctx context.Context, progress *jobs.Progress) []fileops.Result {
+			return fileops.Move(ctx, request.Sources, request.Destination, policy, progress)
+		})
+	})
+}
+
+func bindFileOperation(c *gin.Context) (fileOperationRequest, fileops.CollisionPolicy, error) {
+	var request fileOperationRequest
+	if err := c.ShouldBind(&request); err != nil {
+		return request, "", err
+	}
+	if len(request.Sources) == 0 {
+		return request, "", errors.New("no sources given")
+	}
+	policy, err := fileops.ParseCollisionPolicy(request.OnCollision)
+	return request, policy, err
+}
+
+// runFileOperation submits an operation as a job, and responds with its
+// results if it finishes in time.
+func runFileOperation(c *gin.Context, kind string, description string, sources []string, destination string, operation func(ctx context.Context, progress *jobs.Progress) []fileops.Result) *api.Response {
+	job, finished, err := submitFileOperation(c, kind, description, sources, destination, operation)
+	if err != nil {
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+	}
+	return jobResponse(job, finished)
+}
+
+// submitFileOperation runs an operation as a job, passing its changes on as
+// it finishes, and waits up to inlineJobTimeout for it. destination is the
+// folder the operation writes to, if it writes anywhere but next to its
+// sources.
+func submitFileOperation(c *gin.Context, kind string, description string, sources []string, destination string, operation func(ctx context.Context, progress *jobs.Progress) []fileops.Result) (jobs.Job, bool, error) {
+	paths := sources
+	if destination != "" {
+		paths = append(slices.Clone(sources), destination)
+	}
+	// The whole destination folder isn't held, as the watcher would then
+	// miss changes made in it by anything else while the job runs
+	held := slices.Clone(sources)
+	for _, source := range sources {
+		if destination != "" {
+			held = append(held, path.Join(destination, path.Base(source)))
+		}
+	}
+	job, err := jobs.Submit(kind, description, int64(len(sources)), paths, func(ctx context.Context, progress *jobs.Progress) (any, error) {
+		release := filechanges.Hold(held...)
+		results := operation(ctx, progress)
+		notifyFileOperation(results)
+		release()
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		failed := 0
+		for _, result := range results {
+			if result.Status == fileops.StatusFailed {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return results, fmt.Errorf("%d of %d items failed", failed, len(results))
+		}
+		return results, nil
+	})
+	if err != nil {
+		return jobs.Job{}, false, err
+	}
+	return jobs.Wait(c.Request.Context(), job.ID, inlineJobTimeout)
+}
+
+func describeSources(sources []string) string {
+	if len(sources) == 1 {
+		return sources[0]
+	}
+	return fmt.Sprintf("%d items", len(sources))
+}
+
+func notifyFileOperation(results []fileops.Result) {
+	for _, result := range results {
+		switch result.Status {
+		case fileops.StatusMoved:
+			filechanges.Moved(result.Source, result.Destination, result.Replaced)
+		case fileops.StatusCopied:
+			if result.Replaced != nil {
+				filechanges.Removed(result.Destination, result.Replaced)
+			}
+			filechanges.Added(result.Destination)
+		case fileops.StatusDeleted:
+			filechanges.Removed(result.Source, result.Removed)
+		}
+	}
+}
@@ -1,15 +1,29 @@
 package v1
 
 import (
-	"archive/zip"
 	"autobutler/pkg/api"
+	"autobutler/pkg/dirsize"
+	"autobutler/pkg/filechanges"
+	"autobutler/pkg/fileops"
+	"autobutler/pkg/jobs"
+	"autobutler/pkg/quotas"
+	"autobutler/pkg/stars"
+	"autobutler/pkg/tags"
 	"autobutler/pkg/util/fileutil"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"html"
 	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
 	"net/http"
-	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"autobutler/internal/server/ui"
 	"autobutler/internal/server/ui/components/file_explorer/load"
@@ -23,101 +37,329 @@ import (
 func SetupFilesRoutes(apiV1Group *gin.RouterGroup) {
 	deleteFilesRoute(apiV1Group)
 	downloadFileRoute(apiV1Group)
+	downloadZipRoute(apiV1Group)
 	newFolderRoute(apiV1Group)
 	moveFileRoute(apiV1Group)
+	saveFileRoute(apiV1Group)
 	uploadFileRoute(apiV1Group)
 }
 
+// fileResponse describes a file or folder to JSON clients. Entries are only
+// listed for the folder a request was about.
+type fileResponse struct {
+	Path      string            `json:"path"`
+	Name      string            `json:"name"`
+	FileType  fileutil.FileType `json:"fileType"`
+	IsDir     bool              `json:"isDir"`
+	SizeBytes int64             `json:"sizeBytes"`
+	ModTime   time.Time         `json:"modTime"`
+	Tags      []tags.Tag        `json:"tags,omitempty"`
+	Starred   bool              `json:"starred,omitempty"`
+	Entries   []fileResponse    `json:"entries,omitempty"`
+}
+
+func newFileResponse(filePath string, info fs.FileInfo) fileResponse {
+	file := fileResponse{
+		Path:      "/" + strings.TrimPrefix(filepath.ToSlash(filepath.Clean(filePath)), "/"),
+		Name:      info.Name(),
+		IsDir:     info.IsDir(),
+		SizeBytes: info.Size(),
+		ModTime:   info.ModTime().UTC(),
+	}
+	if info.IsDir() {
+		file.FileType = fileutil.FileTypeFolder
+		file.SizeBytes, _ = dirsize.Get(filePath)
+	} else {
+		file.FileType = fileutil.DetermineFileTypeFromPath(filePath)
+	}
+	return file
+}
+
+// statFileResponse describes the file or folder at filePath, listing the
+// entries of folders when withEntries is set.
+func statFileResponse(filePath string, withEntries bool) (fileResponse, error) {
+	root := fileutil.GetFilesRoot()
+	local, err := root.Clean(filePath)
+	if err != nil {
+		return fileResponse{}, err
+	}
+	info, err := root.Stat(local)
+	if err != nil {
+		return fileResponse{}, err
+	}
+	file := newFileResponse(local, info)
+	// The files root can't be tagged
+	file.Tags, _ = tags.ForPath(local)
+	file.Starred, _ = stars.IsStarred(local)
+	if !info.IsDir() || !withEntries {
+		return file, nil
+	}
+	entries, err := root.ReadDir(local)
+	if err != nil {
+		return fileResponse{}, err
+	}
+	entryTags, err := tags.InDir(local)
+	if err != nil {
+		return fileResponse{}, err
+	}
+	entryStars, err := stars.InDir(local)
+	if err != nil {
+		return fileResponse{}, err
+	}
+	file.Entries = make([]fileResponse, 0, len(entries))
+	for _, entry := range entries {
+		// Entries that can't be stated, like symlinks out of the root, are
+		// left out as they are in the explorer
+		if entryInfo, err := root.Stat(filepath.Join(local, entry.Name())); err == nil {
+			entryFile := newFileResponse(filepath.Join(local, entry.Name()), entryInfo)
+			entryFile.Tags = entryTags[entry.Name()]
+			entryFile.Starred = entryStars[entry.Name()]
+			file.Entries = append(file.Entries, entryFile)
+		}
+	}
+	return file, nil
+}
+
+// fileExplorerComponent renders the file explorer over rootDir, only loading
+// it once it is rendered, so that JSON clients never pay for it.
+func fileExplorerComponent(c *gin.Context, rootDir string, viewContentOnly bool) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		var component templ.Component
+		if viewContentOnly {
+			component = ui.GetFileExplorerViewContent(c, rootDir, "")
+		} else {
+			component = ui.GetFileExplorer(c, rootDir)
+		}
+		if component == nil {
+			return fmt.Errorf("failed to load files of %s", rootDir)
+		}
+		return component.Render(ctx, w)
+	})
+}
+
 func deleteFilesRoute(apiV1Group *gin.RouterGroup) {
 	serverutil.ApiRoute(apiV1Group, "DELETE", "/files", func(c *gin.Context) *api.Response {
 		rootDir := c.Query("rootDir")
 		filePaths := c.QueryArray("filePaths")
-		fmt.Printf("Deleting multiple files: %s\n", filePaths)
-		fileDir := fileutil.GetFilesDir()
-		for _, filePath := range filePaths {
-			fullPath := filepath.Join(fileDir, rootDir, filePath)
-			if err := os.RemoveAll(fullPath); err != nil {
-				return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">` + html.EscapeString(err.Error()) + `</span>`)
+		fmt.Printf("Moving files to trash: %s\n", filePaths)
+		sources := make([]string, len(filePaths))
+		for i, filePath := range filePaths {
+			sources[i] = filepath.Join(rootDir, filePath)
+		}
+		// Deletes that take longer than the wait carry on as a job, and show up
+		// through the live updates as they go
+		var failedMutex sync.Mutex
+		var failed error
+		job, finished, err := submitFileOperation(c, "delete", "Delete "+describeSources(sources), sources, "", func(ctx context.Context, progress *jobs.Progress) []fileops.Result {
+			results := fileops.Delete(ctx, sources, progress)
+			for _, result := range results {
+				if result.Err() != nil {
+					failedMutex.Lock()
+					failed = result.Err()
+					failedMutex.Unlock()
+					break
+				}
 			}
+			return results
+		})
+		if err != nil {
+			return api.NewResponse().WithStatusCode(http.StatusInternalServerError).WithError(err)
 		}
-		// Always render the full file explorer (button targets #file-explorer)
-		component := ui.GetFileExplorer(c, rootDir)
-		if err := component.Render(c.Request.Context(), c.Writer); err != nil {
-			return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">Failed to render file explorer: ` + html.EscapeString(err.Error()) + `</span>`)
+		if serverutil.WantsJSON(c) {
+			return jobResponse(job, finished)
+		}
+		failedMutex.Lock()
+		defer failedMutex.Unlock()
+		if finished && failed != nil {
+			return api.NewResponse().WithStatusCode(fileErrorStatus(failed)).WithError(failed)
 		}
-		return api.Ok()
+		// Always render the full file explorer (button targets #file-explorer)
+		return api.Ok().WithComponent(fileExplorerComponent(c, rootDir, false))
 	})
 }
 
+// DownloadFile serves a file of the files root like a static file server:
+// with a sniffed Content-Type, strong ETag and Last-Modified validators, and
+// support for conditional and (multi-)range requests. Folders are streamed as
+// a zip archive.
 func DownloadFile(c *gin.Context, filePath string) {
-	rootDir := fileutil.GetFilesDir()
-	fullPath := filepath.Join(rootDir, filePath)
+	root := fileutil.GetFilesRoot()
+	info, err := root.Stat(filePath)
+	if err != nil {
+		c.AbortWithStatus(fileErrorStatus(err))
+		return
+	}
 
-	fileType := fileutil.DetermineFileTypeFromPath(fullPath)
+	if info.IsDir() {
+		downloadFolder(c, filePath, info)
+		return
+	}
 
-	if fileType == fileutil.FileTypeFolder {
-		zipWriter := zip.NewWriter(c.Writer)
-		defer zipWriter.Close()
-		dirFs := os.DirFS(fullPath)
-		err := zipWriter.AddFS(dirFs)
-		if err != nil {
-			c.Status(http.StatusInternalServerError)
-			return
-		}
-		c.Writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.zip", filepath.Base(fullPath)))
-		c.Writer.Header().Set("Content-Type", "application/zip")
-	} else {
-		file, err := os.Open(fullPath)
-		if err != nil {
-			c.Status(http.StatusNotFound)
-			return
-		}
-		defer file.Close()
+	file, err := root.Open(filePath)
+	if err != nil {
+		c.AbortWithStatus(fileErrorStatus(err))
+		return
+	}
+	defer file.Close()
 
-		disposition := "inline"
-		contentType := "application/octet-stream"
-		if fileType == fileutil.FileTypePDF {
-			disposition = "inline"
-			contentType = "application/pdf"
-		}
-		c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%s", disposition, filepath.Base(fullPath)))
-		c.Header("Content-Type", contentType)
-		c.File(fullPath)
+	contentType, err := fileutil.DetectMimeType(info.Name(), file)
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
 	}
+	// ServeContent evaluates If-None-Match, If-Range and friends against the
+	// ETag header, so it has to be set before handing over.
+	c.Header("ETag", fileutil.ETag(info))
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", contentDisposition("inline", info.Name()))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "no-cache")
+	if isActiveContent(contentType) {
+		// Never let user files run scripts with the app's origin
+		c.Header("Content-Security-Policy", "sandbox")
+	}
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), file)
+	// gin buffers the status until the body is written, which never happens
+	// for a 304, so flush it before the route wrapper can overwrite it.
+	c.Writer.WriteHeaderNow()
+}
+
+// isActiveContent reports whether a browser would run scripts embedded in
+// content of the given MIME type.
+func isActiveContent(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/html", "application/xhtml+xml", "image/svg+xml", "text/xml", "application/xml":
+		return true
+	default:
+		return false
+	}
+}
+
+// downloadFolder streams a folder as a zip archive. Every header is written
+// before the first byte of the body, since nothing can be changed after that.
+func downloadFolder(c *gin.Context, filePath string, info fs.FileInfo) {
+	name := info.Name()
+	if name == "." {
+		name = "files"
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", contentDisposition("attachment", name+".zip"))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	if c.Request.Method == http.MethodHead {
+		return
+	}
+	c.Writer.WriteHeaderNow()
+
+	if err := fileutil.GetFilesRoot().WriteZip(c.Writer, []string{filePath}, fileutil.ZipCompressionAuto); err != nil {
+		// The status has already been sent, so leave the archive without its
+		// central directory for the client to detect it as truncated.
+		fmt.Printf("Error zipping %s: %v\n", filePath, err)
+	}
+}
+
+// contentDisposition formats a Content-Disposition header, quoting and
+// encoding the file name as needed.
+func contentDisposition(disposition string, fileName string) string {
+	if header := mime.FormatMediaType(disposition, map[string]string{"filename": fileName}); header != "" {
+		return header
+	}
+	return disposition
 }
 
 func downloadFileRoute(apiV1Group *gin.RouterGroup) {
-	serverutil.ApiRoute(apiV1Group, "GET", "/files/*filePath", func(c *gin.Context) *api.Response {
-		filePath := c.Param("filePath")
-		DownloadFile(c, filePath)
-		return api.Ok()
-	})
+	for _, method := range []string{"GET", "HEAD"} {
+		serverutil.ApiRoute(apiV1Group, method, "/files/*filePath", func(c *gin.Context) *api.Response {
+			filePath := c.Param("filePath")
+			// JSON clients get the file's details, and what's in folders
+			if serverutil.WantsJSON(c) {
+				file, err := statFileResponse(filePath, true)
+				if err != nil {
+					return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
+				}
+				return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(file)
+			}
+			DownloadFile(c, filePath)
+			return api.Ok()
+		})
+	}
+}
+
+// zipRequest is accepted as a query, a form or JSON, with paths repeated once
+// per file or folder.
+type zipRequest struct {
+	Paths       []string `form:"paths" json:"paths"`
+	Compression string   `form:"compression" json:"compression"`
+	Name        string   `form:"name" json:"name"`
+}
+
+// downloadZipRoute streams any selection of files and folders as one zip
+// archive. It lives outside of /files, whose catch-all parameter can't share
+// a prefix with static paths.
+func downloadZipRoute(apiV1Group *gin.RouterGroup) {
+	for _, method := range []string{"GET", "POST"} {
+		serverutil.ApiRoute(apiV1Group, method, "/zip/files", func(c *gin.Context) *api.Response {
+			var request zipRequest
+			if err := c.ShouldBind(&request); err != nil {
+				return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+			}
+			if len(request.Paths) == 0 {
+				return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("no paths given"))
+			}
+			compression, err := fileutil.ParseZipCompression(request.Compression)
+			if err != nil {
+				return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+			}
+			root := fileutil.GetFilesRoot()
+			for _, filePath := range request.Paths {
+				if _, err := root.Clean(filePath); err != nil {
+					return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(fileErrorStatus(err)).WithError(err)
+				}
+			}
+			name := strings.TrimSuffix(request.Name, ".zip")
+			if name == "" {
+				name = "files"
+				if base := filepath.Base(request.Paths[0]); len(request.Paths) == 1 && base != "/" && base != "." {
+					name = base
+				}
+			}
+			c.Header("Content-Type", "application/zip")
+			c.Header("Content-Disposition", contentDisposition("attachment", name+".zip"))
+			c.Header("X-Content-Type-Options", "nosniff")
+			c.Header("Cache-Control", "no-store")
+			c.Status(http.StatusOK)
+			c.Writer.WriteHeaderNow()
+			if err := root.WriteZip(c.Writer, request.Paths, compression); err != nil {
+				// Only writing can fail, most likely because the client went
+				// away, so there is nobody left to tell.
+				fmt.Printf("Error streaming zip of %v: %v\n", request.Paths, err)
+			}
+			return api.Ok()
+		})
+	}
 }
 
 func newFolderRoute(apiV1Group *gin.RouterGroup) {
 	serverutil.ApiRoute(apiV1Group, "POST", "/folder/files/*folderDir", func(c *gin.Context) *api.Response {
 		folderDir := c.Param("folderDir")
 		folderName := c.PostForm("folderName")
-		rootDir := fileutil.GetFilesDir()
-		fullPath := filepath.Join(rootDir, folderDir, folderName)
+		folderPath := filepath.Join(folderDir, folderName)
 
-		if err := os.MkdirAll(fullPath, 0755); err != nil {
-			return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">` + html.EscapeString(err.Error()) + `</span>`)
+		if err := fileutil.GetFilesRoot().MkdirAll(folderPath, 0755); err != nil {
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
 		}
-
-		// Stay in the current directory instead of navigating into the new folder
-		currentDir := folderDir
-		// Check if it's an HTMX request targeting just the content
-		var component templ.Component
-		if c.GetHeader("HX-Request") == "true" {
-			component = ui.GetFileExplorerViewContent(c, currentDir, "")
-		} else {
-			component = ui.GetFileExplorer(c, currentDir)
-		}
-		if err := component.Render(c.Request.Context(), c.Writer); err != nil {
-			return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">Failed to render file explorer: ` + html.EscapeString(err.Error()) + `</span>`)
+		filechanges.Added(folderPath)
+		folder, err := statFileResponse(folderPath, false)
+		if err != nil {
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
 		}
-		return api.Ok()
+
+		// Stay in the current directory instead of navigating into the new folder,
+		// and only re-render the content for HTMX requests targeting it
+		component := fileExplorerComponent(c, folderDir, c.GetHeader("HX-Request") == "true")
+		return api.NewResponse().WithStatusCode(http.StatusCreated).WithData(folder).WithComponent(component)
 	})
 }
 
@@ -125,97 +367,437 @@ func moveFileRoute(apiV1Group *gin.RouterGroup) {
 	serverutil.ApiRoute(apiV1Group, "PUT", "/files/*filePath", func(c *gin.Context) *api.Response {
 		filePath := c.Param("filePath")
 		newFilePath := c.PostForm("newFilePath")
-		filesDir := fileutil.GetFilesDir()
-		oldFullPath := filepath.Join(filesDir, filePath)
-		newFullPath := filepath.Join(filesDir, newFilePath)
+		root := fileutil.GetFilesRoot()
 
-		newFullDir := filepath.Dir(newFullPath)
-		if err := os.MkdirAll(newFullDir, 0755); err != nil {
-			return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">` + html.EscapeString(err.Error()) + `</span>`)
+		if err := quotas.CheckMove(filePath, filepath.Dir(newFilePath)); err != nil {
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
+		}
+		if err := root.MkdirAll(filepath.Dir(newFilePath), 0755); err != nil {
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
 		}
-		if err := os.Rename(oldFullPath, newFullPath); err != nil {
-			return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">` + html.EscapeString(err.Error()) + `</span>`)
+		// Whatever is at the destination gets replaced
+		replaced, _ := root.Lstat(newFilePath)
+		// Unlike a plain rename, this also works onto another device
+		if err := fileutil.MoveBetweenRoots(root, filePath, root, newFilePath); err != nil {
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
+		}
+		filechanges.Moved(filePath, newFilePath, replaced)
+		moved, err := statFileResponse(newFilePath, false)
+		if err != nil {
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
 		}
 		newDir := filepath.Dir(newFilePath)
 		if newDir == "." {
 			newDir = ""
 		}
 		// Always render the full file explorer (JS function targets #file-explorer)
-		component := ui.GetFileExplorer(c, newDir)
-		if err := component.Render(c.Request.Context(), c.Writer); err != nil {
-			return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">Failed to render file explorer: ` + html.EscapeString(err.Error()) + `</span>`)
+		return api.NewResponse().WithData(moved).WithComponent(fileExplorerComponent(c, newDir, false))
+	})
+}
+
+// maxSavedFileBytes caps the content saved in one request, which is read
+// into memory to keep its line endings.
+const maxSavedFileBytes = 64 << 20
+
+// ErrPreconditionRequired is returned for saves that don't say which version
+// of the file they replace.
+var ErrPreconditionRequired = errors.New("an If-Match or If-Unmodified-Since header is required")
+
+// ErrModified is returned for saves over a file that has changed since the
+// version they replace.
+var ErrModified = errors.New("file has been modified since it was loaded")
+
+// saveMutex keeps saves from checking a file's version while another save is
+// replacing it.
+var saveMutex sync.Mutex
+
+// checkSavePreconditions checks that the file described by info is still the
+// version the request was made against, going by If-Match, or by
+// If-Unmodified-Since when there is no ETag to go by.
+func checkSavePreconditions(c *gin.Context, info fs.FileInfo) error {
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		etag := fileutil.ETag(info)
+		for _, candidate := range strings.Split(ifMatch, ",") {
+			if candidate = strings.TrimSpace(candidate); candidate == "*" || candidate == etag {
+				return nil
+			}
+		}
+		return ErrModified
+	}
+	if ifUnmodifiedSince := c.GetHeader("If-Unmodified-Since"); ifUnmodifiedSince != "" {
+		since, err := http.ParseTime(ifUnmodifiedSince)
+		if err != nil {
+			return fmt.Errorf("invalid If-Unmodified-Since: %w", err)
 		}
-		return api.Ok()
+		// HTTP dates only have whole seconds
+		if info.ModTime().Truncate(time.Second).After(since) {
+			return ErrModified
+		}
+		return nil
+	}
+	return ErrPreconditionRequired
+}
+
+func saveErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrPreconditionRequired):
+		return http.StatusPreconditionRequired
+	case errors.Is(err, ErrModified):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, fileutil.ErrNotRegular):
+		return http.StatusConflict
+	default:
+		return fileErrorStatus(err)
+	}
+}
+
+// saveFileRoute replaces the content of an existing file with the request
+// body, for editors saving in place. The file keeps its line endings.
+func saveFileRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "PUT", "/content/files/*filePath", func(c *gin.Context) *api.Response {
+		filePath := c.Param("filePath")
+		root := fileutil.GetFilesRoot()
+		content, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSavedFileBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return api.NewResponse().WithStatusCode(http.StatusRequestEntityTooLarge).WithError(err)
+		} else if err != nil {
+			return api.NewResponse().WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+
+		saveMutex.Lock()
+		defer saveMutex.Unlock()
+		info, err := root.Stat(filePath)
+		if err != nil {
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
+		}
+		if err := checkSavePreconditions(c, info); err != nil {
+			if errors.Is(err, ErrModified) {
+				// Let the client know what it would be overwriting
+				c.Header("ETag", fileutil.ETag(info))
+			}
+			return api.NewResponse().WithStatusCode(saveErrorStatus(err)).WithError(err)
+		}
+		if head, err := readHead(filePath); err == nil {
+			if ending := fileutil.LineEnding(head); ending != "" {
+				content = fileutil.WithLineEnding(content, ending)
+			}
+		}
+		if err := quotas.Check(filepath.Dir(filePath), max(int64(len(content))-info.Size(), 0), 0); err != nil {
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
+		}
+		if _, err := root.ReplaceFile(filePath, bytes.NewReader(content)); err != nil {
+			return api.NewResponse().WithStatusCode(saveErrorStatus(err)).WithError(err)
+		}
+		filechanges.Modified(filePath, info.Size())
+
+		saved, err := root.Stat(filePath)
+		if err != nil {
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
+		}
+		c.Header("ETag", fileutil.ETag(saved))
+		c.Header("Last-Modified", saved.ModTime().UTC().Format(http.TimeFormat))
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(newFileResponse(filePath, saved))
 	})
 }
 
-func uploadFileRouteImpl(c *gin.Context, rootDir string) {
-	// Parse the multipart form with a max memory size
-	err := c.Request.ParseMultipartForm(32 << 20)
+// readHead reads up to the first 4KiB of the file at filePath.
+func readHead(filePath string) ([]byte, error) {
+	file, err := fileutil.GetFilesRoot().Open(filePath)
 	if err != nil {
-		c.Writer.WriteString(`<span class="text-red-500">Failed to parse multipart form: ` + html.EscapeString(err.Error()) + `</span>`)
-		return
+		return nil, err
+	}
+	defer file.Close()
+	head := make([]byte, 4096)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// uploadedFilesResponse sums up an upload: the files written, and the
+// folders created for them.
+type uploadedFilesResponse struct {
+	Files   []fileResponse `json:"files"`
+	Folders []fileResponse `json:"folders"`
+}
+
+// plannedUpload is a file of a multipart upload, and where it goes.
+type plannedUpload struct {
+	header *multipart.FileHeader
+	// dir is the folder it goes in, relative to the files root
+	dir  string
+	name string
+}
+
+// planUploads works out where every uploaded file goes. Files can carry a
+// path relative to rootDir in a "paths" field parallel to "files", such as
+// a browser's webkitRelativePath, to upload a whole folder tree. Otherwise
+// they go straight into rootDir.
+func planUploads(rootDir string, headers []*multipart.FileHeader, paths []string) ([]plannedUpload, error) {
+	if len(paths) > 0 && len(paths) != len(headers) {
+		return nil, fmt.Errorf("%w: got %d paths for %d files", errInvalidUploadPath, len(paths), len(headers))
+	}
+	root := fileutil.GetFilesRoot()
+	base, err := root.Clean(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	planned := make([]plannedUpload, 0, len(headers))
+	for i, header := range headers {
+		relPath := filepath.Base(header.Filename)
+		if len(paths) > 0 {
+			relPath = filepath.Clean(filepath.FromSlash(paths[i]))
+		}
+		// "." would be the folder itself rather than a file in it
+		if !filepath.IsLocal(relPath) || relPath == "." {
+			return nil, fmt.Errorf("%w: %q", errInvalidUploadPath, filepath.ToSlash(relPath))
+		}
+		local, err := root.Join(base, relPath)
+		if err != nil {
+			return nil, err
+		}
+		planned = append(planned, plannedUpload{header: header, dir: filepath.Dir(local), name: filepath.Base(local)})
+	}
+	return planned, nil
+}
+
+// errInvalidUploadPath is returned for upload paths that aren't relative to
+// the folder being uploaded to.
+var errInvalidUploadPath = errors.New("invalid upload path")
+
+// checkUploadSpace checks every top-level folder being uploaded to has room
+// for its share of the upload, and the device for all of it.
+func checkUploadSpace(planned []plannedUpload) error {
+	type share struct{ bytes, files int64 }
+	shares := map[string]*share{}
+	var total share
+	for _, upload := range planned {
+		top, _, _ := strings.Cut(filepath.ToSlash(upload.dir), "/")
+		if shares[top] == nil {
+			shares[top] = &share{}
+		}
+		shares[top].bytes += upload.header.Size
+		shares[top].files++
+		total.bytes += upload.header.Size
+		total.files++
+	}
+	if err := quotas.Check("", total.bytes, total.files); err != nil {
+		return err
+	}
+	for top, share := range shares {
+		if err := quotas.Check(top, share.bytes, share.files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createUploadDir creates dir and any missing folders above it, merging into
+// ones that already exist. created tracks the folders made so far, and newly
+// made ones are appended to it.
+func createUploadDir(dir string, created map[string]bool, order *[]string) error {
+	root := fileutil.GetFilesRoot()
+	if dir == "." {
+		return nil
+	}
+	if created[dir] {
+		return nil
+	}
+	info, err := root.Lstat(dir)
+	if err == nil {
+		if !info.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: dir, Err: syscall.ENOTDIR}
+		}
+		return nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := createUploadDir(filepath.Dir(dir), created, order); err != nil {
+		return err
+	}
+	if err := root.Mkdir(dir, 0755); err != nil {
+		return err
+	}
+	created[dir] = true
+	*order = append(*order, dir)
+	return nil
+}
+
+// saveUpload writes an uploaded file to filePath, closing both before
+// returning so that big batches don't run out of file handles. A file that
+// can't be written in full is removed again.
+func saveUpload(header *multipart.FileHeader, filePath string) (fs.FileInfo, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	root := fileutil.GetFilesRoot()
+	newFile, err := root.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer newFile.Close()
+	if _, err := io.Copy(newFile, file); err != nil {
+		newFile.Close()
+		if removeErr := root.Remove(filePath); removeErr != nil {
+			fmt.Printf("Error removing partial upload %s: %v\n", filePath, removeErr)
+		}
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	info, err := newFile.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return info, newFile.Close()
+}
+
+// reportCreatedDirs passes on the folders created by an upload, with the
+// files saved in them.
+func reportCreatedDirs(createdOrder []string, created map[string]bool) {
+	for _, dir := range createdOrder {
+		if !hasCreatedParent(dir, created) {
+			filechanges.Added(dir)
+		}
+	}
+}
+
+// saveUploadReported is saveUpload for a file the file watcher is told about,
+// which is passed on once saved, unless it's in a folder the upload created.
+func saveUploadReported(header *multipart.FileHeader, filePath string, inCreatedDir bool) (fs.FileInfo, error) {
+	if inCreatedDir {
+		return saveUpload(header, filePath)
+	}
+	release := filechanges.Hold(filePath)
+	defer release()
+	info, err := saveUpload(header, filePath)
+	if err != nil {
+		return nil, err
+	}
+	filechanges.Added(filePath)
+	return info, nil
+}
+
+// hasCreatedParent reports whether filePath is in a folder that was created
+// by the same upload.
+func hasCreatedParent(filePath string, created map[string]bool) bool {
+	for dir := filepath.Dir(filePath); dir != "."; dir = filepath.Dir(dir) {
+		if created[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+func uploadFileRouteImpl(c *gin.Context, rootDir string) *api.Response {
+	// Bodies that can't fit are turned away before they are read, as parsing
+	// spills large files to temporary ones
+	if c.Request.ContentLength > 0 {
+		if err := quotas.Check(rootDir, c.Request.ContentLength, 0); err != nil {
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
+		}
+	}
+	room, err := quotas.Room(rootDir)
+	if err != nil {
+		return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, room)
+	// Parse the multipart form with a max memory size
+	if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return api.NewResponse().WithStatusCode(http.StatusInsufficientStorage).WithError(fmt.Errorf("upload is larger than the %s left: %w", fileutil.SizeBytesToString(room), quotas.ErrInsufficientStorage))
+		}
+		return api.NewResponse().WithStatusCode(http.StatusBadRequest).WithError(fmt.Errorf("failed to parse multipart form: %w", err))
 	}
 
 	form, err := c.MultipartForm()
 	if err != nil {
-		c.Writer.WriteString(`<span class="text-red-500">Failed to get file: ` + html.EscapeString(err.Error()) + `</span>`)
-		return
+		return api.NewResponse().WithStatusCode(http.StatusBadRequest).WithError(fmt.Errorf("failed to get file: %w", err))
+	}
+	// Check everything up front, so that nothing is left half written
+	planned, err := planUploads(rootDir, form.File["files"], form.Value["paths"])
+	if errors.Is(err, errInvalidUploadPath) {
+		return api.NewResponse().WithStatusCode(http.StatusBadRequest).WithError(err)
+	} else if err != nil {
+		return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
+	}
+	if err := checkUploadSpace(planned); err != nil {
+		return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
+	}
+	root := fileutil.GetFilesRoot()
+	created := map[string]bool{}
+	var createdOrder []string
+	for _, upload := range planned {
+		if err := createUploadDir(upload.dir, created, &createdOrder); err != nil {
+			reportCreatedDirs(createdOrder, created)
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
+		}
 	}
-	fileHeaders := form.File["files"]
-	for _, header := range fileHeaders {
-		file, err := header.Open()
+
+	// The folders uploaded to aren't held, only the folders the upload creates
+	// in them until they are passed on, and each file while it's written
+	release := filechanges.Hold(createdOrder...)
+	defer release()
+
+	uploaded := make([]fileResponse, 0, len(planned))
+	for _, upload := range planned {
+		newFilePath, releasePath, err := root.AvailablePath(filepath.Join(upload.dir, upload.name))
 		if err != nil {
-			c.Writer.WriteString(`<span class="text-red-500">Failed to open file: ` + html.EscapeString(err.Error()) + `</span>`)
-			return
-		}
-		defer file.Close()
-
-		fileDir := fileutil.GetFilesDir()
-		newFilePath := filepath.Join(fileDir, rootDir, header.Filename)
-		if _, err := os.Stat(newFilePath); err == nil {
-			ext := filepath.Ext(header.Filename)
-			name := header.Filename[:len(header.Filename)-len(ext)]
-			i := 1
-			for {
-				newFileName := fmt.Sprintf("%s_(%d)%s", name, i, ext)
-				newFilePath = filepath.Join(fileDir, rootDir, newFileName)
-				if _, err := os.Stat(newFilePath); os.IsNotExist(err) {
-					break
-				}
-				i++
-			}
+			// What was saved before stays
+			reportCreatedDirs(createdOrder, created)
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(fmt.Errorf("failed to create file: %w", err))
 		}
-		newFile, err := os.Create(newFilePath)
+		info, err := saveUploadReported(upload.header, newFilePath, hasCreatedParent(newFilePath, created))
+		releasePath()
 		if err != nil {
-			c.Writer.WriteString(`<span class="text-red-500">Failed to create file: ` + html.EscapeString(err.Error()) + `</span>`)
-			return
+			reportCreatedDirs(createdOrder, created)
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
 		}
-		defer newFile.Close()
-		if _, err := io.Copy(newFile, file); err != nil {
-			c.Writer.WriteString(`<span class="text-red-500">Failed to write file: ` + html.EscapeString(err.Error()) + `</span>`)
-			return
+		uploaded = append(uploaded, newFileResponse(newFilePath, info))
+	}
+	folders := make([]fileResponse, 0, len(createdOrder))
+	for _, dir := range createdOrder {
+		if info, err := root.Stat(dir); err == nil {
+			folders = append(folders, newFileResponse(dir, info))
 		}
 	}
+	reportCreatedDirs(createdOrder, created)
+
 	returnDir := form.Value["returnDir"]
 	if len(returnDir) > 0 {
 		rootDir = returnDir[0]
 	}
-	loadComponent := load.Component(types.NewPageState().WithRootDir(rootDir))
-	if err := loadComponent.Render(c.Request.Context(), c.Writer); err != nil {
-		c.Status(500)
-		return
-	}
+	return api.NewResponse().
+		WithStatusCode(http.StatusCreated).
+		WithData(uploadedFilesResponse{Files: uploaded, Folders: folders}).
+		WithComponent(load.Component(types.NewPageState().WithRootDir(rootDir)))
 }
 
 func uploadFileRoute(apiV1Group *gin.RouterGroup) {
 	serverutil.ApiRoute(apiV1Group, "POST", "/files", func(c *gin.Context) *api.Response {
-		uploadFileRouteImpl(c, "")
-		return api.Ok()
+		return uploadFileRouteImpl(c, "")
 	})
 	serverutil.ApiRoute(apiV1Group, "POST", "/files/*rootDir", func(c *gin.Context) *api.Response {
 		rootDir := c.Param("rootDir")
-		uploadFileRouteImpl(c, rootDir)
-		return api.Ok()
+		return uploadFileRouteImpl(c, rootDir)
 	})
 }
+
+// fileErrorStatus maps an error from the files root onto an HTTP status code.
+func fileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, fileutil.ErrEscapesRoot), errors.Is(err, fileutil.ErrRootPath):
+		return http.StatusForbidden
+	case errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, quotas.ErrInsufficientStorage):
+		return http.StatusInsufficientStorage
+	case errors.Is(err, syscall.ENOTDIR):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"autobutler/pkg/api"
+	"autobutler/pkg/jobs"
+	"autobutler/pkg/util/serverutil"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type jobsResponse struct {
+	Jobs []jobs.Job `json:"jobs"`
+}
+
+// submittedJobResponse carries a job submitted by a request, along with its
+// results once it has finished.
+type submittedJobResponse struct {
+	Job     jobs.Job        `json:"job"`
+	Results json.RawMessage `json:"results,omitempty"`
+}
+
+func SetupJobRoutes(apiV1Group *gin.RouterGroup) {
+	cancelJobRoute(apiV1Group)
+	getJobRoute(apiV1Group)
+	listJobsRoute(apiV1Group)
+}
+
+func cancelJobRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/jobs/:id/cancel", func(c *gin.Context) *api.Response {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("invalid job ID"))
+		}
+		if err := jobs.Cancel(id); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(jobErrorStatus(err)).WithError(err)
+		}
+		job, err := jobs.Get(id)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(jobErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusAccepted).WithData(job)
+	})
+}
+
+func getJobRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/jobs/:id", func(c *gin.Context) *api.Response {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("invalid job ID"))
+		}
+		job, err := jobs.Get(id)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(jobErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(job)
+	})
+}
+
+func listJobsRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/jobs", func(c *gin.Context) *api.Response {
+		limit, err := queryInt(c, "limit", jobs.DefaultListLimit)
+		if err != nil || limit <= 0 {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("invalid limit"))
+		}
+		list, err := jobs.List(limit)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(jobsResponse{Jobs: list})
+	})
+}
+
+// jobResponse responds with a job the request submitted and waited for: its
+// results if it finished in time, or otherwise 202 Accepted with the job to
+// follow.
+func jobResponse(job jobs.Job, finished bool) *api.Response {
+	if !finished {
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusAccepted).WithData(submittedJobResponse{Job: job})
+	}
+	results := job.Result
+	job.Result = nil
+	return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(submittedJobResponse{
+		Job:     job,
+		Results: results,
+	})
+}
+
+// jobErrorStatus maps an error from the jobs package onto an HTTP status code.
+func jobErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, jobs.ErrFinished):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
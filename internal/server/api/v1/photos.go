@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"autobutler/pkg/api"
+	"autobutler/pkg/photos"
+	"autobutler/pkg/util/serverutil"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type photoTimelineResponse struct {
+	Group   photos.Group    `json:"group"`
+	Periods []photos.Period `json:"periods"`
+}
+
+func SetupPhotoRoutes(apiV1Group *gin.RouterGroup) {
+	listPhotosRoute(apiV1Group)
+	photoTimelineRoute(apiV1Group)
+}
+
+// listPhotosRoute lists the catalogued photos, newest first, optionally
+// those in rootDir or taken between from and to. Later pages are fetched
+// with the nextCursor of the page before as cursor.
+func listPhotosRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/photos", func(c *gin.Context) *api.Response {
+		limit, err := queryInt(c, "limit", photos.DefaultLimit)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		page, err := photos.List(photoFilter(c), c.Query("cursor"), limit)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(photoErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(page)
+	})
+}
+
+// photoTimelineRoute counts the catalogued photos by the year, month or day
+// they were taken, with the same filters as listing them.
+func photoTimelineRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/photos/timeline", func(c *gin.Context) *api.Response {
+		group, err := photos.ParseGroup(c.Query("group"))
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		periods, err := photos.Timeline(photoFilter(c), group)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(photoErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(photoTimelineResponse{
+			Group:   group,
+			Periods: periods,
+		})
+	})
+}
+
+func photoFilter(c *gin.Context) photos.Filter {
+	return photos.Filter{
+		RootDir: c.Query("rootDir"),
+		From:    c.Query("from"),
+		To:      c.Query("to"),
+	}
+}
+
+func photoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, photos.ErrInvalidCursor), errors.Is(err, photos.ErrInvalidDate), errors.Is(err, photos.ErrInvalidGroup):
+		return http.StatusBadRequest
+	default:
+		return fileErrorStatus(err)
+	}
+}
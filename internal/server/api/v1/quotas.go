@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"autobutler/pkg/api"
+	"autobutler/pkg/quotas"
+	"autobutler/pkg/util/serverutil"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quotaRequest is accepted as JSON or as a form. Zero lifts a limit.
+type quotaRequest struct {
+	MaxBytes int64 `form:"maxBytes" json:"maxBytes"`
+	MaxFiles int64 `form:"maxFiles" json:"maxFiles"`
+}
+
+type quotasResponse struct {
+	Quotas []quotas.Usage `json:"quotas"`
+}
+
+func SetupQuotaRoutes(apiV1Group *gin.RouterGroup) {
+	deleteQuotaRoute(apiV1Group)
+	getQuotaRoute(apiV1Group)
+	listQuotasRoute(apiV1Group)
+	setQuotaRoute(apiV1Group)
+}
+
+func deleteQuotaRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "DELETE", "/quotas/*folder", func(c *gin.Context) *api.Response {
+		if err := quotas.Delete(c.Param("folder")); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(quotaErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+func getQuotaRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/quotas/*folder", func(c *gin.Context) *api.Response {
+		usage, err := quotas.Get(c.Param("folder"))
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(quotaErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(usage)
+	})
+}
+
+func listQuotasRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/quotas", func(c *gin.Context) *api.Response {
+		usages, err := quotas.Usages()
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(quotasResponse{Quotas: usages})
+	})
+}
+
+// setQuotaRoute sets or replaces the quota of a top-level folder. Folders may
+// already hold more than their new quota, in which case nothing more can be
+// added until enough is removed.
+func setQuotaRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "PUT", "/quotas/*folder", func(c *gin.Context) *api.Response {
+		var request quotaRequest
+		if err := c.ShouldBind(&request); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		usage, err := quotas.Set(c.Param("folder"), request.MaxBytes, request.MaxFiles)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(quotaErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(usage)
+	})
+}
+
+func quotaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, quotas.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, quotas.ErrInvalid):
+		return http.StatusBadRequest
+	default:
+		return fileErrorStatus(err)
+	}
+}
@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"autobutler/pkg/api"
+	"autobutler/pkg/search"
+	"autobutler/pkg/util/fileutil"
+	"autobutler/pkg/util/serverutil"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type searchResponse struct {
+	Query   string          `json:"query"`
+	Results []search.Result `json:"results"`
+}
+
+func SetupSearchRoutes(apiV1Group *gin.RouterGroup) {
+	searchRoute(apiV1Group)
+}
+
+func searchRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/search", func(c *gin.Context) *api.Response {
+		query := c.Query("q")
+		fileTypes, err := parseSearchFileTypes(c.QueryArray("type"))
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		limit, err := queryInt(c, "limit", search.DefaultLimit)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		offset, err := queryInt(c, "offset", 0)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		results, err := search.Search(query, fileTypes, limit, offset)
+		if errors.Is(err, search.ErrEmptyQuery) {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		if results == nil {
+			results = []search.Result{}
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(searchResponse{
+			Query:   query,
+			Results: results,
+		})
+	})
+}
+
+func parseSearchFileTypes(values []string) ([]fileutil.FileType, error) {
+	fileTypes := make([]fileutil.FileType, 0, len(values))
+	for _, value := range values {
+		fileType := fileutil.FileType(value)
+		if !slices.Contains(fileutil.FileTypes(), fileType) {
+			return nil, fmt.Errorf("unknown file type: %s", value)
+		}
+		fileTypes = append(fileTypes, fileType)
+	}
+	return fileTypes, nil
+}
+
+func queryInt(c *gin.Context, key string, defaultValue int) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: %s", key, value)
+	}
+	return n, nil
+}
@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"autobutler/pkg/api"
+	"autobutler/pkg/shares"
+	"autobutler/pkg/util/serverutil"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// createShareRequest is accepted as JSON or as a form. Leaving out expiresAt
+// shares forever, and a maxDownloads of 0 allows any number of downloads.
+type createShareRequest struct {
+	Path         string     `form:"path" json:"path"`
+	ExpiresAt    *time.Time `form:"expiresAt" json:"expiresAt" time_format:"2006-01-02T15:04:05Z07:00"`
+	Password     string     `form:"password" json:"password"`
+	MaxDownloads int64      `form:"maxDownloads" json:"maxDownloads"`
+}
+
+type sharesResponse struct {
+	Shares []shares.Share `json:"shares"`
+}
+
+func SetupShareRoutes(apiV1Group *gin.RouterGroup) {
+	createShareRoute(apiV1Group)
+	listSharesRoute(apiV1Group)
+	revokeShareRoute(apiV1Group)
+}
+
+func createShareRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/shares", func(c *gin.Context) *api.Response {
+		var request createShareRequest
+		if err := c.ShouldBind(&request); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		if request.Path == "" {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("no path given"))
+		}
+		share, err := shares.Create(request.Path, shares.Options{
+			ExpiresAt:    request.ExpiresAt,
+			Password:     request.Password,
+			MaxDownloads: request.MaxDownloads,
+		})
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(shareErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusCreated).WithData(share)
+	})
+}
+
+// listSharesRoute lists the shares that can still be used.
+func listSharesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/shares", func(c *gin.Context) *api.Response {
+		list, err := shares.List()
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(sharesResponse{Shares: list})
+	})
+}
+
+func revokeShareRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "DELETE", "/shares/:token", func(c *gin.Context) *api.Response {
+		if err := shares.Revoke(c.Param("token")); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(shareErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+// shareErrorStatus maps an error from the shares package onto an HTTP status
+// code.
+func shareErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, shares.ErrNotFound), errors.Is(err, shares.ErrOutsideShare):
+		return http.StatusNotFound
+	case errors.Is(err, shares.ErrExpired):
+		return http.StatusGone
+	case errors.Is(err, shares.ErrInvalidOptions):
+		return http.StatusBadRequest
+	default:
+		return fileErrorStatus(err)
+	}
+}
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"autobutler/pkg/api"
 	"autobutler/pkg/storage"
 	"net/http"
 
@@ -26,10 +27,10 @@ func getStorageDevices(c *gin.Context) {
 	// READ-ONLY: Detect devices using system commands
 	devices, err := detector.DetectDevices()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to detect storage devices",
-			"details": err.Error(),
-		})
+		c.JSON(http.StatusInternalServerError, api.ErrorEnvelope{Error: api.ErrorBody{
+			Code:    api.ErrorCode(http.StatusInternalServerError),
+			Message: "Failed to detect storage devices: " + err.Error(),
+		}})
 		return
 	}
 
@@ -55,10 +56,10 @@ func getStorageSummary(c *gin.Context) {
 	// READ-ONLY: Detect devices
 	devices, err := detector.DetectDevices()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to detect storage devices",
-			"details": err.Error(),
-		})
+		c.JSON(http.StatusInternalServerError, api.ErrorEnvelope{Error: api.ErrorBody{
+			Code:    api.ErrorCode(http.StatusInternalServerError),
+			Message: "Failed to detect storage devices: " + err.Error(),
+		}})
 		return
 	}
 
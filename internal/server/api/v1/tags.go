@@ -0,0 +1,189 @@
+package v1
+
+import (
+	"autobutler/pkg/api"
+	"autobutler/pkg/tags"
+	"autobutler/pkg/util/serverutil"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tagRequest is accepted as JSON or as a form. When updating a tag, empty
+// fields are left as they are.
+type tagRequest struct {
+	Name  string `form:"name" json:"name"`
+	Color string `form:"color" json:"color"`
+}
+
+type tagFilesRequest struct {
+	Paths []string `form:"paths" json:"paths"`
+}
+
+type tagsResponse struct {
+	Tags []tags.Tag `json:"tags"`
+}
+
+type taggedFilesResponse struct {
+	Tag   tags.Tag       `json:"tag"`
+	Files []fileResponse `json:"files"`
+}
+
+func SetupTagRoutes(apiV1Group *gin.RouterGroup) {
+	createTagRoute(apiV1Group)
+	deleteTagRoute(apiV1Group)
+	listTaggedFilesRoute(apiV1Group)
+	listTagsRoute(apiV1Group)
+	tagFilesRoute(apiV1Group)
+	untagFilesRoute(apiV1Group)
+	updateTagRoute(apiV1Group)
+}
+
+func createTagRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/tags", func(c *gin.Context) *api.Response {
+		var request tagRequest
+		if err := c.ShouldBind(&request); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		tag, err := tags.Create(request.Name, request.Color)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(tagErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusCreated).WithData(tag)
+	})
+}
+
+func deleteTagRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "DELETE", "/tags/:id", func(c *gin.Context) *api.Response {
+		id, resp := tagID(c)
+		if resp != nil {
+			return resp
+		}
+		if err := tags.Delete(id); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(tagErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+// listTaggedFilesRoute lists the files and folders with a tag. Ones that are
+// gone from the files root, like those on a disk that isn't mounted, are left
+// out.
+func listTaggedFilesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/tags/:id/files", func(c *gin.Context) *api.Response {
+		id, resp := tagID(c)
+		if resp != nil {
+			return resp
+		}
+		tag, err := tags.Get(id)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(tagErrorStatus(err)).WithError(err)
+		}
+		paths, err := tags.Paths(id)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(tagErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(taggedFilesResponse{Tag: tag, Files: statFileResponses(paths)})
+	})
+}
+
+func listTagsRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/tags", func(c *gin.Context) *api.Response {
+		list, err := tags.List()
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusInternalServerError).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(tagsResponse{Tags: list})
+	})
+}
+
+// tagFilesRoute tags every path given. Files that already have the tag keep
+// it.
+func tagFilesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/tags/:id/files", func(c *gin.Context) *api.Response {
+		id, resp := tagID(c)
+		if resp != nil {
+			return resp
+		}
+		var request tagFilesRequest
+		if err := c.ShouldBind(&request); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		if len(request.Paths) == 0 {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("no paths given"))
+		}
+		for _, filePath := range request.Paths {
+			if err := tags.Add(filePath, id); err != nil {
+				return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(tagErrorStatus(err)).WithError(fmt.Errorf("%s: %w", path.Base(filePath), err))
+			}
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+// untagFilesRoute takes a tag off the paths given as filePaths in the query.
+func untagFilesRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "DELETE", "/tags/:id/files", func(c *gin.Context) *api.Response {
+		id, resp := tagID(c)
+		if resp != nil {
+			return resp
+		}
+		filePaths := c.QueryArray("filePaths")
+		if len(filePaths) == 0 {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("no paths given"))
+		}
+		for _, filePath := range filePaths {
+			if err := tags.Remove(filePath, id); err != nil {
+				return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(tagErrorStatus(err)).WithError(err)
+			}
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+func updateTagRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "PATCH", "/tags/:id", func(c *gin.Context) *api.Response {
+		id, resp := tagID(c)
+		if resp != nil {
+			return resp
+		}
+		var request tagRequest
+		if err := c.ShouldBind(&request); err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(err)
+		}
+		tag, err := tags.Update(id, request.Name, request.Color)
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(tagErrorStatus(err)).WithError(err)
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(tag)
+	})
+}
+
+// tagID parses the tag ID of a route, or returns the response for one that
+// can't be a tag.
+func tagID(c *gin.Context) (int64, *api.Response) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(http.StatusBadRequest).WithError(errors.New("invalid tag ID"))
+	}
+	return id, nil
+}
+
+// tagErrorStatus maps an error from the tags package onto an HTTP status
+// code.
+func tagErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, tags.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, tags.ErrExists):
+		return http.StatusConflict
+	case errors.Is(err, tags.ErrInvalid):
+		return http.StatusBadRequest
+	default:
+		return fileErrorStatus(err)
+	}
+}
@@ -2,63 +2,55 @@ package v1
 
 import (
 	"autobutler/pkg/api"
-	"autobutler/pkg/util/fileutil"
+	"autobutler/pkg/thumbnails"
 	"autobutler/pkg/util/imageutil"
 	"autobutler/pkg/util/serverutil"
-	"fmt"
-	"image/jpeg"
-	"image/png"
+	"errors"
 	"net/http"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-const (
-	thumbnailWidth  = 400
-	thumbnailHeight = 400
-)
-
 func SetupThumbnailRoutes(apiV1Group *gin.RouterGroup) {
 	getThumbnailRoute(apiV1Group)
 }
 
+// getThumbnailRoute serves cached thumbnails in one of thumbnails.Sizes,
+// picked with the size query. URLs with the version of the file, from
+// thumbnails.URL, can be cached for good.
 func getThumbnailRoute(apiV1Group *gin.RouterGroup) {
 	serverutil.ApiRoute(apiV1Group, "GET", "/thumbnails/*filePath", func(c *gin.Context) *api.Response {
 		filePath := c.Param("filePath")
-		filesDir := fileutil.GetFilesDir()
-		fullPath := filepath.Join(filesDir, filePath)
-
-		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-			return api.NewResponse().WithStatusCode(http.StatusNotFound)
+		size, err := thumbnails.ParseSize(c.Query("size"))
+		if err != nil {
+			return api.NewResponse().WithStatusCode(http.StatusBadRequest).WithError(err)
 		}
-
-		thumbnail, format, err := imageutil.ImageToThumbnail(fullPath, thumbnailWidth, thumbnailHeight)
+		thumbnail, err := thumbnails.Get(filePath, size)
+		if errors.Is(err, imageutil.ErrNoThumbnail) {
+			return api.NewResponse().WithStatusCode(http.StatusUnsupportedMediaType).WithError(err)
+		} else if err != nil {
+			return api.NewResponse().WithStatusCode(fileErrorStatus(err)).WithError(err)
+		}
+		file, err := os.Open(thumbnail.Path)
 		if err != nil {
 			return api.NewResponse().WithStatusCode(http.StatusInternalServerError).WithError(err)
 		}
-
-		ext := strings.ToLower(filepath.Ext(filePath))
-		switch ext {
-		case ".png":
-			c.Header("Content-Type", "image/png")
-			if err := png.Encode(c.Writer, thumbnail); err != nil {
-				return api.NewResponse().WithStatusCode(http.StatusInternalServerError)
-			}
-		case ".jpg", ".jpeg":
-			c.Header("Content-Type", "image/jpeg")
-			if err := jpeg.Encode(c.Writer, thumbnail, &jpeg.Options{Quality: 85}); err != nil {
-				return api.NewResponse().WithStatusCode(http.StatusInternalServerError)
-			}
-		default:
-			// For other formats, try to encode as JPEG
-			c.Header("Content-Type", fmt.Sprintf("image/%s", format))
-			if err := jpeg.Encode(c.Writer, thumbnail, &jpeg.Options{Quality: 85}); err != nil {
-				return api.NewResponse().WithStatusCode(http.StatusInternalServerError)
-			}
+		defer file.Close()
+
+		c.Header("ETag", thumbnail.ETag)
+		c.Header("Content-Type", thumbnail.ContentType)
+		c.Header("X-Content-Type-Options", "nosniff")
+		if c.Query("v") == thumbnail.Version {
+			c.Header("Cache-Control", "private, max-age=31536000, immutable")
+		} else {
+			c.Header("Cache-Control", "private, no-cache")
 		}
+		http.ServeContent(c.Writer, c.Request, "", thumbnail.ModTime, file)
+		// gin buffers the status until the body is written, which never
+		// happens for a 304, so flush it before the route wrapper can
+		// overwrite it.
+		c.Writer.WriteHeaderNow()
 		return api.Ok()
 	})
 }
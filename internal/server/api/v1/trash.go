@@ -0,0 +1,136 @@
+package v1
+
+import (
+	"autobutler/internal/server/ui"
+	"autobutler/pkg/api"
+	"autobutler/pkg/filechanges"
+	"autobutler/pkg/jobs"
+	"autobutler/pkg/trash"
+	"autobutler/pkg/util/serverutil"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type trashItemResponse struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	OriginalPath string    `json:"originalPath"`
+	IsDir        bool      `json:"isDir"`
+	SizeBytes    int64     `json:"sizeBytes"`
+	DeletedAt    time.Time `json:"deletedAt"`
+}
+
+// restoredResponse tells where a trash item was restored to.
+type restoredResponse struct {
+	Path string `json:"path"`
+}
+
+func SetupTrashRoutes(apiV1Group *gin.RouterGroup) {
+	deleteTrashItemRoute(apiV1Group)
+	emptyTrashRoute(apiV1Group)
+	listTrashRoute(apiV1Group)
+	restoreTrashItemRoute(apiV1Group)
+}
+
+func deleteTrashItemRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "DELETE", "/trash/:id", func(c *gin.Context) *api.Response {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return api.NewResponse().WithStatusCode(400).WithError(errors.New("invalid trash item ID"))
+		}
+		if err := trash.Delete(id); err != nil {
+			return api.NewResponse().WithStatusCode(trashErrorStatus(err)).WithError(err)
+		}
+		if serverutil.WantsJSON(c) {
+			return api.NewResponse().WithStatusCode(http.StatusNoContent)
+		}
+		return renderTrashExplorer(c)
+	})
+}
+
+func emptyTrashRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "DELETE", "/trash", func(c *gin.Context) *api.Response {
+		// The trash runs its own operations one at a time, so emptying it
+		// waits for no other job
+		job, err := jobs.Submit("empty-trash", "Empty the trash", 0, nil, func(ctx context.Context, progress *jobs.Progress) (any, error) {
+			return nil, trash.Empty(ctx, progress)
+		})
+		if err != nil {
+			return api.NewResponse().WithStatusCode(500).WithError(err)
+		}
+		// A large trash keeps emptying in the background
+		job, finished, err := jobs.Wait(c.Request.Context(), job.ID, inlineJobTimeout)
+		if err != nil {
+			return api.NewResponse().WithStatusCode(500).WithError(err)
+		}
+		if serverutil.WantsJSON(c) {
+			return jobResponse(job, finished)
+		}
+		if job.Status == jobs.StatusFailed {
+			return api.NewResponse().WithStatusCode(500).WithError(errors.New(job.Error))
+		}
+		return renderTrashExplorer(c)
+	})
+}
+
+func listTrashRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "GET", "/trash", func(c *gin.Context) *api.Response {
+		files, err := trash.ListFileInfos()
+		if err != nil {
+			return api.NewResponse().WithContentType(api.ContentTypeJSON).WithStatusCode(500).WithError(err)
+		}
+		items := make([]trashItemResponse, len(files))
+		for i, file := range files {
+			item := file.(trash.ItemInfo).Item
+			items[i] = trashItemResponse{
+				ID:           item.ID,
+				Name:         file.Name(),
+				OriginalPath: item.OriginalPath,
+				IsDir:        item.IsDir,
+				SizeBytes:    item.SizeBytes,
+				DeletedAt:    item.DeletedAt,
+			}
+		}
+		return api.NewResponse().WithContentType(api.ContentTypeJSON).WithData(gin.H{"items": items})
+	})
+}
+
+func restoreTrashItemRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/trash/:id/restore", func(c *gin.Context) *api.Response {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return api.NewResponse().WithStatusCode(400).WithError(errors.New("invalid trash item ID"))
+		}
+		restorePath, err := trash.Restore(id)
+		if err != nil {
+			return api.NewResponse().WithStatusCode(trashErrorStatus(err)).WithError(err)
+		}
+		c.Header("X-Restored-Path", "/"+restorePath)
+		filechanges.Added(restorePath)
+		return renderTrashExplorer(c).WithData(restoredResponse{Path: "/" + restorePath})
+	})
+}
+
+// renderTrashExplorer re-renders the whole trash explorer, which the trash
+// buttons target.
+func renderTrashExplorer(c *gin.Context) *api.Response {
+	component := ui.GetTrashExplorer(c)
+	if component == nil {
+		return api.NewResponse().WithStatusCode(500).WithError(errors.New("failed to list the trash"))
+	}
+	return api.Ok().WithComponent(component)
+}
+
+// trashErrorStatus maps an error from the trash onto an HTTP status code.
+func trashErrorStatus(err error) int {
+	if errors.Is(err, sql.ErrNoRows) {
+		return http.StatusNotFound
+	}
+	return fileErrorStatus(err)
+}
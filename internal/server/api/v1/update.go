@@ -5,11 +5,30 @@ import (
 	"autobutler/internal/update"
 	"autobutler/pkg/api"
 	"autobutler/pkg/util/serverutil"
-	"html"
+	"autobutler/pkg/util/versionutil"
 
+	"github.com/a-h/templ"
 	"github.com/gin-gonic/gin"
 )
 
+// updateResponse confirms an update was installed.
+type updateResponse struct {
+	Version    string `json:"version"`
+	Restarting bool   `json:"restarting"`
+}
+
+// versionsResponse lists the releases newer than the running version.
+type versionsResponse struct {
+	Current  string            `json:"current"`
+	Releases []releaseResponse `json:"releases"`
+}
+
+type releaseResponse struct {
+	Version string `json:"version"`
+	// Assets are the download URLs of the builds for each platform.
+	Assets []string `json:"assets"`
+}
+
 func SetupUpdateRoutes(apiV1Group *gin.RouterGroup) {
 	updateRoute(apiV1Group)
 	listVersionsRoute(apiV1Group)
@@ -19,10 +38,12 @@ func updateRoute(apiV1Group *gin.RouterGroup) {
 	serverutil.ApiRoute(apiV1Group, "POST", "/update", func(c *gin.Context) *api.Response {
 		version := c.PostForm("version")
 		if err := update.Update(version); err != nil {
-			return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">` + html.EscapeString(err.Error()) + `</span>`)
+			return api.NewResponse().WithStatusCode(500).WithError(err)
 		}
 		go update.RestartAutobutler()
-		return api.Ok().WithData(`<span class="text-green-500">Update successful, Autobutler will restart.</span>`)
+		return api.Ok().
+			WithData(updateResponse{Version: version, Restarting: true}).
+			WithComponent(templ.Raw(`<span class="text-green-500">Update successful, Autobutler will restart.</span>`))
 	})
 }
 
@@ -30,11 +51,18 @@ func listVersionsRoute(apiV1Group *gin.RouterGroup) {
 	serverutil.ApiRoute(apiV1Group, "GET", "/versions", func(c *gin.Context) *api.Response {
 		releases, err := update.ListPossibleUpdates()
 		if err != nil {
-			return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">` + html.EscapeString(err.Error()) + `</span>`)
+			return api.NewResponse().WithStatusCode(500).WithError(err)
 		}
-		if err := landing_nav.VersionDropdown(releases).Render(c.Request.Context(), c.Writer); err != nil {
-			return api.NewResponse().WithStatusCode(500).WithData(`<span class="text-red-500">` + html.EscapeString(err.Error()) + `</span>`)
+		data := versionsResponse{Current: versionutil.GetVersion().Semver, Releases: []releaseResponse{}}
+		for _, release := range releases {
+			assets := []string{}
+			for _, asset := range release.Assets {
+				assets = append(assets, asset.BrowserDownloadURL)
+			}
+			data.Releases = append(data.Releases, releaseResponse{Version: release.TagName, Assets: assets})
 		}
-		return api.Ok()
+		return api.Ok().
+			WithData(data).
+			WithComponent(landing_nav.VersionDropdown(releases))
 	})
 }
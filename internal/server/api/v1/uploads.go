@@ -0,0 +1,233 @@
+package v1
+
+import (
+	"autobutler/pkg/api"
+	"autobutler/pkg/filechanges"
+	"autobutler/pkg/quotas"
+	"autobutler/pkg/uploads"
+	"autobutler/pkg/util/serverutil"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The uploads routes implement the core tus 1.0 resumable upload protocol
+// (https://tus.io/protocols/resumable-upload), along with its creation,
+// expiration, checksum and termination extensions.
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,expiration,checksum,termination"
+	// statusChecksumMismatch is the status tus defines for chunks that fail
+	// checksum verification.
+	statusChecksumMismatch = 460
+	offsetContentType      = "application/offset+octet-stream"
+)
+
+func SetupUploadRoutes(apiV1Group *gin.RouterGroup) {
+	createUploadRoute(apiV1Group)
+	getUploadOffsetRoute(apiV1Group)
+	patchUploadRoute(apiV1Group)
+	terminateUploadRoute(apiV1Group)
+	uploadOptionsRoute(apiV1Group)
+}
+
+func createUploadRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "POST", "/uploads", func(c *gin.Context) *api.Response {
+		if resp := checkTusResumable(c); resp != nil {
+			return resp
+		}
+		if c.GetHeader("Upload-Defer-Length") != "" {
+			return uploadError(http.StatusBadRequest, "Upload-Defer-Length is not supported")
+		}
+		length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+		if err != nil || length < 0 {
+			return uploadError(http.StatusBadRequest, "Invalid Upload-Length")
+		}
+		rawMetadata := c.GetHeader("Upload-Metadata")
+		metadata, err := parseUploadMetadata(rawMetadata)
+		if err != nil {
+			return uploadError(http.StatusBadRequest, "Invalid Upload-Metadata: "+err.Error())
+		}
+		fileName := metadata["filename"]
+		if fileName == "" {
+			fileName = metadata["name"]
+		}
+		if fileName == "" {
+			return uploadError(http.StatusBadRequest, "Upload-Metadata must include a filename")
+		}
+
+		if err := quotas.Check(metadata["rootDir"], length, 1); err != nil {
+			return api.NewResponse().WithStatusCode(uploadErrorStatus(err)).WithError(err)
+		}
+		upload, err := uploads.Create(metadata["rootDir"], fileName, length, rawMetadata)
+		if err != nil {
+			return uploadError(uploadErrorStatus(err), err.Error())
+		}
+		c.Header("Location", "/api/v1/uploads/"+upload.ID)
+		c.Header("Upload-Expires", upload.ExpiresAt.Format(http.TimeFormat))
+		if length == 0 {
+			// Nothing will ever be patched, so the upload is already complete
+			_, completedPath, err := uploads.Append(upload.ID, 0, http.NoBody, nil)
+			if err != nil {
+				return uploadError(uploadErrorStatus(err), err.Error())
+			}
+			c.Header("X-Upload-Path", "/"+completedPath)
+			filechanges.Added(completedPath)
+		}
+		return api.NewResponse().WithStatusCode(http.StatusCreated)
+	})
+}
+
+func getUploadOffsetRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "HEAD", "/uploads/:id", func(c *gin.Context) *api.Response {
+		if resp := checkTusResumable(c); resp != nil {
+			return resp
+		}
+		c.Header("Cache-Control", "no-store")
+		upload, offset, err := uploads.Get(c.Param("id"))
+		if err != nil {
+			return api.NewResponse().WithStatusCode(uploadErrorStatus(err))
+		}
+		c.Header("Upload-Offset", strconv.FormatInt(offset, 10))
+		c.Header("Upload-Length", strconv.FormatInt(upload.SizeBytes, 10))
+		c.Header("Upload-Expires", upload.ExpiresAt.Format(http.TimeFormat))
+		if upload.Metadata != "" {
+			c.Header("Upload-Metadata", upload.Metadata)
+		}
+		return api.NewResponse().WithStatusCode(http.StatusOK)
+	})
+}
+
+func patchUploadRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "PATCH", "/uploads/:id", func(c *gin.Context) *api.Response {
+		if resp := checkTusResumable(c); resp != nil {
+			return resp
+		}
+		if c.ContentType() != offsetContentType {
+			return uploadError(http.StatusUnsupportedMediaType, "Content-Type must be "+offsetContentType)
+		}
+		offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+		if err != nil || offset < 0 {
+			return uploadError(http.StatusBadRequest, "Invalid Upload-Offset")
+		}
+		var checksum *uploads.Checksum
+		if header := c.GetHeader("Upload-Checksum"); header != "" {
+			if checksum, err = parseUploadChecksum(header); err != nil {
+				return uploadError(http.StatusBadRequest, "Invalid Upload-Checksum: "+err.Error())
+			}
+		}
+
+		id := c.Param("id")
+		newOffset, completedPath, err := uploads.Append(id, offset, c.Request.Body, checksum)
+		if err != nil {
+			return uploadError(uploadErrorStatus(err), err.Error())
+		}
+		c.Header("Upload-Offset", strconv.FormatInt(newOffset, 10))
+		if completedPath != "" {
+			c.Header("X-Upload-Path", "/"+completedPath)
+			filechanges.Added(completedPath)
+		} else if expiresAt, err := uploads.ExpiresAt(id); err == nil {
+			c.Header("Upload-Expires", expiresAt.Format(http.TimeFormat))
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+func terminateUploadRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "DELETE", "/uploads/:id", func(c *gin.Context) *api.Response {
+		if resp := checkTusResumable(c); resp != nil {
+			return resp
+		}
+		if err := uploads.Terminate(c.Param("id")); err != nil {
+			return uploadError(uploadErrorStatus(err), err.Error())
+		}
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+func uploadOptionsRoute(apiV1Group *gin.RouterGroup) {
+	serverutil.ApiRoute(apiV1Group, "OPTIONS", "/uploads", func(c *gin.Context) *api.Response {
+		c.Header("Tus-Resumable", tusVersion)
+		c.Header("Tus-Version", tusVersion)
+		c.Header("Tus-Extension", tusExtensions)
+		c.Header("Tus-Checksum-Algorithm", strings.Join(uploads.ChecksumAlgorithms, ","))
+		return api.NewResponse().WithStatusCode(http.StatusNoContent)
+	})
+}
+
+// checkTusResumable sets the Tus-Resumable header that every response must
+// carry, and rejects requests made with an unsupported protocol version.
+func checkTusResumable(c *gin.Context) *api.Response {
+	c.Header("Tus-Resumable", tusVersion)
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		return api.NewResponse().WithStatusCode(http.StatusPreconditionFailed)
+	}
+	return nil
+}
+
+// parseUploadMetadata decodes an Upload-Metadata header, which is a comma
+// separated list of keys, each followed by a space and a base64 value.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+	for pair := range strings.SplitSeq(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("empty key")
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+// parseUploadChecksum decodes an Upload-Checksum header of the form
+// "<algorithm> <base64 checksum>".
+func parseUploadChecksum(header string) (*uploads.Checksum, error) {
+	algorithm, encoded, found := strings.Cut(header, " ")
+	if !found {
+		return nil, errors.New("expected an algorithm and a checksum")
+	}
+	sum, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &uploads.Checksum{Algorithm: algorithm, Sum: sum}, nil
+}
+
+func uploadError(statusCode int, message string) *api.Response {
+	resp := api.NewResponse().WithStatusCode(statusCode).WithError(errors.New(message))
+	if statusCode == statusChecksumMismatch {
+		resp = resp.WithErrorCode("checksum_mismatch")
+	}
+	return resp
+}
+
+// uploadErrorStatus maps an error from the uploads package onto an HTTP
+// status code.
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, uploads.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, uploads.ErrOffsetMismatch):
+		return http.StatusConflict
+	case errors.Is(err, uploads.ErrChecksumMismatch):
+		return statusChecksumMismatch
+	case errors.Is(err, uploads.ErrExceedsLength):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, uploads.ErrUnsupportedChecksum), errors.Is(err, uploads.ErrInvalidDestination):
+		return http.StatusBadRequest
+	default:
+		return fileErrorStatus(err)
+	}
+}
@@ -12,9 +12,22 @@ import (
 func useMiddleware(router *gin.Engine) {
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
-	config.AllowMethods = []string{"POST", "GET", "PUT", "OPTIONS"}
+	config.AllowMethods = []string{"POST", "GET", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
 	config.AllowHeaders = []string{"*"}
-	config.ExposeHeaders = []string{"Content-Length"}
+	// The tus headers have to be exposed for browser clients to resume uploads
+	config.ExposeHeaders = []string{
+		"Content-Length",
+		"Location",
+		"Tus-Resumable",
+		"Tus-Version",
+		"Tus-Extension",
+		"Tus-Checksum-Algorithm",
+		"Upload-Offset",
+		"Upload-Length",
+		"Upload-Metadata",
+		"Upload-Expires",
+		"X-Upload-Path",
+	}
 	config.AllowCredentials = true
 	config.MaxAge = 12 * time.Hour
 	router.Use(otelgin.Middleware("autobutler-server"))
@@ -295,21 +295,23 @@ function deselectFileNode(node) {
 }
 
 /**
- * Update the download button state based on selection
+ * Update the download and move/copy button states based on selection
  */
 function updateDownloadButton() {
-    const downloadBtn = document.getElementById('file-download-button');
-    if (!downloadBtn) return;
+    ['file-download-button', 'file-transfer-button'].forEach((id) => {
+        const button = document.getElementById(id);
+        if (!button) return;
 
-    if (selectedFiles.length > 0) {
-        downloadBtn.disabled = false;
-        downloadBtn.classList.remove('btn--disabled');
-        downloadBtn.classList.add('btn--secondary');
-    } else {
-        downloadBtn.disabled = true;
-        downloadBtn.classList.remove('btn--secondary');
-        downloadBtn.classList.add('btn--disabled');
-    }
+        if (selectedFiles.length > 0) {
+            button.disabled = false;
+            button.classList.remove('btn--disabled');
+            button.classList.add('btn--secondary');
+        } else {
+            button.disabled = true;
+            button.classList.remove('btn--secondary');
+            button.classList.add('btn--disabled');
+        }
+    });
 }
 
 /**
@@ -538,58 +540,140 @@ function dropOnNode(event, returnDir) {
 function downloadSelectedFiles(event, rootDir) {
     preventDefault(event);
 
-    console.log('Download requested. Root dir:', rootDir, 'Selected files:', selectedFiles);
-
     if (!rootDir) rootDir = '';
 
-    selectedFiles.forEach((fileName) => {
-        const link = document.createElement('a');
+    const filePaths = selectedFiles.map((fileName) => {
         let cleanFileName = fileName;
         while (cleanFileName.endsWith('/')) {
             cleanFileName = cleanFileName.slice(0, -1);
         }
+        return joinFilePath(rootDir, cleanFileName);
+    });
 
-        // Construct the proper path - ensure no double slashes
-        let filePath;
-        if (rootDir && rootDir !== '/') {
-            // Remove leading slash from rootDir if present
-            const cleanRootDir = rootDir.startsWith('/') ? rootDir.slice(1) : rootDir;
-            filePath = `/api/v1/files/${cleanRootDir}/${cleanFileName}`;
-        } else {
-            filePath = `/api/v1/files/${cleanFileName}`;
-        }
-
-        console.log('Downloading:', filePath);
-
-        link.href = filePath;
-        link.download = cleanFileName;
+    // A single file downloads as itself, anything more as one streamed zip
+    if (filePaths.length === 1 && !selectedFiles[0].endsWith('/')) {
+        const link = document.createElement('a');
+        link.href = `/api/v1/files${filePaths[0]}`;
+        link.download = filePaths[0].split('/').pop();
         document.body.appendChild(link);
         link.click();
         document.body.removeChild(link);
-    });
+    } else {
+        downloadAsZip(filePaths);
+    }
     clearSelectedFiles();
 }
 
+/**
+ * Download files and folders as a single zip archive. A form post lets the
+ * browser stream the archive straight to disk.
+ */
+function downloadAsZip(filePaths) {
+    const form = document.createElement('form');
+    form.method = 'POST';
+    form.action = '/api/v1/zip/files';
+    form.hidden = true;
+    filePaths.forEach((filePath) => {
+        const input = document.createElement('input');
+        input.type = 'hidden';
+        input.name = 'paths';
+        input.value = filePath;
+        form.appendChild(input);
+    });
+    document.body.appendChild(form);
+    form.submit();
+    document.body.removeChild(form);
+}
+
 function dropFiles(event, rootDir, returnDir) {
     rootDir = rootDir || '';
     returnDir = returnDir || '';
     preventDefault(event);
-    const files = event.dataTransfer.files;
-    if (files.length > 0) {
-        const formData = new FormData();
-        for (const file of files) {
-            formData.append('files', file);
+    // Entries can only be read while handling the drop, so grab them now
+    const entries = supportsDirectoryUpload()
+        ? Array.from(event.dataTransfer.items || [])
+              .map((item) => item.webkitGetAsEntry && item.webkitGetAsEntry())
+              .filter(Boolean)
+        : [];
+    if (entries.some((entry) => entry.isDirectory)) {
+        collectEntryFiles(entries, '')
+            .then((files) => uploadFiles(files, rootDir, returnDir))
+            .catch((error) => toastr.error(`Failed to read dropped folder: ${error.message}`));
+        return;
+    }
+    const files = Array.from(event.dataTransfer.files).map((file) => ({ file, path: file.name }));
+    uploadFiles(files, rootDir, returnDir);
+}
+
+/**
+ * Read every file below dropped file system entries, along with its path
+ * relative to where it was dropped.
+ */
+async function collectEntryFiles(entries, prefix) {
+    const files = [];
+    for (const entry of entries) {
+        const path = prefix + entry.name;
+        if (entry.isFile) {
+            const file = await new Promise((resolve, reject) => entry.file(resolve, reject));
+            files.push({ file, path });
+        } else if (entry.isDirectory) {
+            const reader = entry.createReader();
+            // readEntries hands out a batch at a time, until an empty one
+            for (;;) {
+                const batch = await new Promise((resolve, reject) =>
+                    reader.readEntries(resolve, reject)
+                );
+                if (batch.length === 0) break;
+                files.push(...(await collectEntryFiles(batch, `${path}/`)));
+            }
         }
-        const uploadForm = document.getElementById('file-upload-form');
-        // NOTE: https://flaviocopes.com/htmx-send-files-using-htmxajax-call/
-        htmx.ajax('POST', uploadForm.getAttribute('hx-post') + rootDir, {
-            values: {
-                files: formData.getAll('files'),
-                returnDir: returnDir,
-            },
-            source: uploadForm,
-        });
     }
+    return files;
+}
+
+/**
+ * Upload files into rootDir, each at its relative path so that folders keep
+ * their structure.
+ */
+function uploadFiles(files, rootDir, returnDir) {
+    if (files.length === 0) {
+        return;
+    }
+    const uploadForm = document.getElementById('file-upload-form');
+    // NOTE: https://flaviocopes.com/htmx-send-files-using-htmxajax-call/
+    htmx.ajax('POST', uploadForm.getAttribute('hx-post') + rootDir, {
+        values: {
+            files: files.map((upload) => upload.file),
+            paths: files.map((upload) => upload.path),
+            returnDir: returnDir,
+        },
+        source: uploadForm,
+    });
+}
+
+/**
+ * Let the user pick a folder, and upload it into rootDir with everything in
+ * it. The picker is made on demand, leaving the upload form with a single
+ * file input.
+ */
+// eslint-disable-next-line no-unused-vars
+function chooseFolderToUpload(event, rootDir) {
+    preventDefault(event);
+    const input = document.createElement('input');
+    input.type = 'file';
+    input.webkitdirectory = true;
+    input.hidden = true;
+    input.addEventListener('change', () => {
+        const files = Array.from(input.files).map((file) => ({
+            file,
+            path: file.webkitRelativePath || file.name,
+        }));
+        uploadFiles(files, '', rootDir);
+        input.remove();
+    });
+    input.addEventListener('cancel', () => input.remove());
+    document.body.appendChild(input);
+    input.click();
 }
 
 // eslint-disable-next-line no-unused-vars
@@ -815,6 +899,665 @@ function moveFile(event, rootDir, fileName) {
     });
 }
 
+/**
+ * Join a folder and an entry name into a path starting with a "/"
+ */
+function joinFilePath(dir, name) {
+    const path = `${dir || ''}/${name}`.replace(/\/+/g, '/').replace(/(.)\/$/, '$1');
+    return path.startsWith('/') ? path : `/${path}`;
+}
+
+// eslint-disable-next-line no-unused-vars
+function duplicateFile(event, rootDir, fileName) {
+    preventDefault(event);
+    runFileOperation('duplicate', { sources: [joinFilePath(rootDir, fileName)] });
+}
+
+// eslint-disable-next-line no-unused-vars
+function transferSelectedFiles(event, rootDir) {
+    preventDefault(event);
+    if (selectedFiles.length === 0) return;
+    const sources = selectedFiles.map((fileName) => joinFilePath(rootDir, fileName));
+
+    const overlay = document.createElement('div');
+    overlay.className = 'ab-rename-overlay';
+    overlay.innerHTML = `
+        <div class="ab-rename-dialog">
+            <div class="ab-rename-header">
+                <h3 class="ab-rename-title">Move or Copy</h3>
+                <p class="ab-rename-subtitle" id="ab-transfer-subtitle"></p>
+            </div>
+            <form class="ab-rename-form" id="ab-transfer-form">
+                <div class="ab-rename-input-group">
+                    <label class="ab-rename-label" for="ab-transfer-destination">
+                        Destination folder:
+                    </label>
+                    <input
+                        type="text"
+                        id="ab-transfer-destination"
+                        class="ab-rename-input"
+                        required
+                    />
+                </div>
+                <div class="ab-rename-input-group">
+                    <label class="ab-rename-label" for="ab-transfer-collision">
+                        If an item already exists there:
+                    </label>
+                    <select id="ab-transfer-collision" class="ab-rename-input">
+                        <option value="keep-both">Keep both</option>
+                        <option value="skip">Skip it</option>
+                        <option value="overwrite">Replace it</option>
+                    </select>
+                </div>
+                <div class="ab-rename-actions">
+                    <button type="button" class="btn btn--secondary" id="ab-transfer-cancel">
+                        Cancel
+                    </button>
+                    <button type="submit" class="btn btn--secondary" value="copy">Copy</button>
+                    <button type="submit" class="btn btn--primary" value="move">Move</button>
+                </div>
+            </form>
+        </div>
+    `;
+    document.body.appendChild(overlay);
+
+    const input = document.getElementById('ab-transfer-destination');
+    input.value = joinFilePath(rootDir, '');
+    document.getElementById('ab-transfer-subtitle').textContent =
+        sources.length === 1 ? sources[0] : `${sources.length} items`;
+    setTimeout(() => input.focus(), 10);
+
+    const close = () => {
+        overlay.remove();
+        document.removeEventListener('keydown', escapeHandler);
+    };
+    const escapeHandler = (e) => {
+        if (e.key === 'Escape') close();
+    };
+    document.addEventListener('keydown', escapeHandler);
+    overlay.addEventListener('click', (e) => {
+        if (e.target === overlay) close();
+    });
+    document.getElementById('ab-transfer-cancel').addEventListener('click', close);
+
+    document.getElementById('ab-transfer-form').addEventListener('submit', (e) => {
+        e.preventDefault();
+        const destination = input.value.trim();
+        if (!destination) {
+            input.focus();
+            return;
+        }
+        const action = e.submitter && e.submitter.value === 'copy' ? 'copy' : 'move';
+        close();
+        runFileOperation(action, {
+            sources: sources,
+            destination: destination,
+            onCollision: document.getElementById('ab-transfer-collision').value,
+        });
+    });
+}
+
+/**
+ * Run a batch move, copy or duplicate. Operations that take a while carry on
+ * as a background job, which reports the results once it finishes.
+ */
+function runFileOperation(action, request) {
+    fetch(`/api/v1/${action}/files`, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify(request),
+    })
+        .then((response) => response.json())
+        .then((body) => {
+            if (body.error) {
+                toastr.error(`Failed to ${action} files: ${body.error.message}`);
+                return;
+            }
+            clearSelectedFiles();
+            if (!body.results) {
+                getReportedJobs().add(body.job.id);
+                toastr.info(`${body.job.description} continues in the background`);
+                renderJob(body.job);
+                return;
+            }
+            reportFileResults(body.results);
+            refreshChangedView();
+        })
+        .catch((error) => {
+            toastr.error(`Failed to ${action} files: ${error.message}`);
+        });
+}
+
+/**
+ * Report the outcome of a file operation, including every item that failed
+ */
+function reportFileResults(results) {
+    const failed = results.filter((result) => result.status === 'failed');
+    const skipped = results.filter((result) => result.status === 'skipped');
+    const done = results.length - failed.length - skipped.length;
+    if (done > 0) {
+        toastr.success(`${done} of ${results.length} item(s) done`);
+    }
+    if (skipped.length > 0) {
+        toastr.info(`Skipped ${skipped.map((result) => result.source).join(', ')}`);
+    }
+    failed.forEach((result) => {
+        toastr.error(`${result.source}: ${result.error}`);
+    });
+}
+
+// SHARES
+
+/**
+ * Create a public link to a file or folder, and show it for copying
+ */
+// eslint-disable-next-line no-unused-vars
+function shareFile(event, rootDir, fileName) {
+    preventDefault(event);
+    const filePath = joinFilePath(rootDir, fileName);
+
+    const overlay = document.createElement('div');
+    overlay.className = 'ab-rename-overlay';
+    overlay.innerHTML = `
+        <div class="ab-rename-dialog ab-share-dialog">
+            <div class="ab-rename-header">
+                <h3 class="ab-rename-title">Share</h3>
+                <p class="ab-rename-subtitle" id="ab-share-subtitle"></p>
+            </div>
+            <form class="ab-rename-form" id="ab-share-form">
+                <div class="ab-rename-input-group">
+                    <label class="ab-rename-label" for="ab-share-expiry">Link expires:</label>
+                    <select id="ab-share-expiry" class="ab-rename-input">
+                        <option value="1">After 1 day</option>
+                        <option value="7" selected>After 7 days</option>
+                        <option value="30">After 30 days</option>
+                        <option value="">Never</option>
+                    </select>
+                </div>
+                <div class="ab-rename-input-group">
+                    <label class="ab-rename-label" for="ab-share-password">
+                        Password (optional):
+                    </label>
+                    <input
+                        type="password"
+                        id="ab-share-password"
+                        class="ab-rename-input"
+                        autocomplete="new-password"
+                    />
+                </div>
+                <div class="ab-rename-input-group">
+                    <label class="ab-rename-label" for="ab-share-max-downloads">
+                        Maximum downloads (0 for no limit):
+                    </label>
+                    <input
+                        type="number"
+                        id="ab-share-max-downloads"
+                        class="ab-rename-input"
+                        min="0"
+                        value="0"
+                    />
+                </div>
+                <div class="ab-rename-input-group ab-rename-hidden" id="ab-share-result">
+                    <label class="ab-rename-label" for="ab-share-url">
+                        Anyone with this link can open it:
+                    </label>
+                    <input type="text" id="ab-share-url" class="ab-rename-input" readonly />
+                </div>
+                <div class="ab-rename-actions">
+                    <button type="button" class="btn btn--secondary" id="ab-share-cancel">
+                        Close
+                    </button>
+                    <button type="submit" class="btn btn--primary" id="ab-share-submit">
+                        Create link
+                    </button>
+                </div>
+            </form>
+        </div>
+    `;
+    document.body.appendChild(overlay);
+    document.getElementById('ab-share-subtitle').textContent = filePath;
+
+    const close = () => {
+        overlay.remove();
+        document.removeEventListener('keydown', escapeHandler);
+    };
+    const escapeHandler = (e) => {
+        if (e.key === 'Escape') close();
+    };
+    document.addEventListener('keydown', escapeHandler);
+    overlay.addEventListener('click', (e) => {
+        if (e.target === overlay) close();
+    });
+    document.getElementById('ab-share-cancel').addEventListener('click', close);
+
+    const form = document.getElementById('ab-share-form');
+    const submitBtn = document.getElementById('ab-share-submit');
+    const urlInput = document.getElementById('ab-share-url');
+    form.addEventListener('submit', (e) => {
+        e.preventDefault();
+        // Once created, the button copies the link instead
+        if (urlInput.value) {
+            copyShareUrl(urlInput);
+            return;
+        }
+        const request = {
+            path: filePath,
+            password: document.getElementById('ab-share-password').value,
+            maxDownloads: Number(document.getElementById('ab-share-max-downloads').value) || 0,
+        };
+        const days = document.getElementById('ab-share-expiry').value;
+        if (days) {
+            request.expiresAt = new Date(Date.now() + days * 24 * 60 * 60 * 1000).toISOString();
+        }
+        submitBtn.disabled = true;
+        fetch('/api/v1/shares', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify(request),
+        })
+            .then((response) => response.json())
+            .then((body) => {
+                if (body.error) {
+                    toastr.error(`Failed to share ${filePath}: ${body.error.message}`);
+                    return;
+                }
+                form.querySelectorAll('input, select').forEach((input) => {
+                    input.disabled = input !== urlInput;
+                });
+                urlInput.value = new URL(body.url, window.location.origin).href;
+                document.getElementById('ab-share-result').classList.remove('ab-rename-hidden');
+                submitBtn.textContent = 'Copy link';
+                copyShareUrl(urlInput);
+            })
+            .catch((error) => {
+                toastr.error(`Failed to share ${filePath}: ${error.message}`);
+            })
+            .finally(() => {
+                submitBtn.disabled = false;
+            });
+    });
+}
+
+function copyShareUrl(input) {
+    input.select();
+    if (!navigator.clipboard) return;
+    navigator.clipboard.writeText(input.value).then(
+        () => toastr.success('Link copied'),
+        () => toastr.info('Copy the selected link to share it')
+    );
+}
+
+// TAGS
+
+function encodeFilePath(filePath) {
+    return filePath.split('/').map(encodeURIComponent).join('/');
+}
+
+/**
+ * Send a JSON request to the API, failing with the message of its error
+ * envelope, if any.
+ */
+function apiRequest(method, url, data) {
+    const options = { method, headers: { Accept: 'application/json' } };
+    if (data !== undefined) {
+        options.headers['Content-Type'] = 'application/json';
+        options.body = JSON.stringify(data);
+    }
+    return fetch(url, options).then((response) => {
+        if (response.status === 204) return null;
+        return response.json().then((body) => {
+            if (body.error) throw new Error(body.error.message);
+            return body;
+        });
+    });
+}
+
+/**
+ * Show the tags of a file or folder, to add or remove them, or to tag it with
+ * a new one
+ */
+// eslint-disable-next-line no-unused-vars
+function tagFile(event, rootDir, fileName) {
+    preventDefault(event);
+    const filePath = joinFilePath(rootDir, fileName).replace(/\/+$/, '');
+
+    const overlay = document.createElement('div');
+    overlay.className = 'ab-rename-overlay';
+    overlay.innerHTML = `
+        <div class="ab-rename-dialog ab-tag-dialog">
+            <div class="ab-rename-header">
+                <h3 class="ab-rename-title">Tags</h3>
+                <p class="ab-rename-subtitle" id="ab-tag-subtitle"></p>
+            </div>
+            <form class="ab-rename-form" id="ab-tag-form">
+                <ul class="ab-tag-options" id="ab-tag-options"></ul>
+                <div class="ab-rename-input-group">
+                    <label class="ab-rename-label" for="ab-tag-name">New tag:</label>
+                    <div class="ab-tag-new">
+                        <input
+                            type="text"
+                            id="ab-tag-name"
+                            class="ab-rename-input"
+                            maxlength="64"
+                            autocomplete="off"
+                        />
+                        <input type="color" id="ab-tag-color" aria-label="New tag colour" />
+                    </div>
+                </div>
+                <div class="ab-rename-actions">
+                    <button type="button" class="btn btn--secondary" id="ab-tag-cancel">
+                        Close
+                    </button>
+                    <button type="submit" class="btn btn--primary" id="ab-tag-submit">
+                        Add tag
+                    </button>
+                </div>
+            </form>
+        </div>
+    `;
+    document.body.appendChild(overlay);
+    document.getElementById('ab-tag-subtitle').textContent = filePath;
+
+    const close = () => {
+        overlay.remove();
+        document.removeEventListener('keydown', escapeHandler);
+    };
+    const escapeHandler = (e) => {
+        if (e.key === 'Escape') close();
+    };
+    document.addEventListener('keydown', escapeHandler);
+    overlay.addEventListener('click', (e) => {
+        if (e.target === overlay) close();
+    });
+    document.getElementById('ab-tag-cancel').addEventListener('click', close);
+
+    const options = document.getElementById('ab-tag-options');
+    const tagsUrl = (tag) => `/api/v1/tags/${tag.id}/files`;
+    const addOption = (tag, checked) => {
+        const item = document.createElement('li');
+        const label = document.createElement('label');
+        label.className = 'ab-tag-option';
+        const checkbox = document.createElement('input');
+        checkbox.type = 'checkbox';
+        checkbox.checked = checked;
+        const chip = document.createElement('span');
+        chip.className = 'tag-chip';
+        chip.style.setProperty('--tag-color', tag.color);
+        chip.textContent = tag.name;
+        checkbox.addEventListener('change', () => {
+            const request = checkbox.checked
+                ? apiRequest('POST', tagsUrl(tag), { paths: [filePath] })
+                : apiRequest(
+                      'DELETE',
+                      `${tagsUrl(tag)}?filePaths=${encodeURIComponent(filePath)}`
+                  );
+            request.catch((error) => {
+                checkbox.checked = !checkbox.checked;
+                toastr.error(`Failed to tag ${filePath}: ${error.message}`);
+            });
+        });
+        label.append(checkbox, chip);
+        item.appendChild(label);
+        options.appendChild(item);
+    };
+
+    Promise.all([
+        apiRequest('GET', '/api/v1/tags'),
+        apiRequest('GET', `/api/v1/files${encodeFilePath(filePath)}`),
+    ])
+        .then(([list, file]) => {
+            const current = new Set((file.tags || []).map((tag) => tag.id));
+            list.tags.forEach((tag) => addOption(tag, current.has(tag.id)));
+        })
+        .catch((error) => {
+            toastr.error(`Failed to load tags: ${error.message}`);
+        });
+
+    const nameInput = document.getElementById('ab-tag-name');
+    const colorInput = document.getElementById('ab-tag-color');
+    const submitBtn = document.getElementById('ab-tag-submit');
+    let colorPicked = false;
+    colorInput.addEventListener('input', () => {
+        colorPicked = true;
+    });
+    document.getElementById('ab-tag-form').addEventListener('submit', (e) => {
+        e.preventDefault();
+        const name = nameInput.value.trim();
+        if (!name) return;
+        submitBtn.disabled = true;
+        // Without a colour, the server picks the next one from its palette
+        apiRequest('POST', '/api/v1/tags', { name, color: colorPicked ? colorInput.value : '' })
+            .then((tag) => apiRequest('POST', tagsUrl(tag), { paths: [filePath] }).then(() => tag))
+            .then((tag) => {
+                addOption(tag, true);
+                nameInput.value = '';
+                colorPicked = false;
+            })
+            .catch((error) => {
+                toastr.error(`Failed to tag ${filePath}: ${error.message}`);
+            })
+            .finally(() => {
+                submitBtn.disabled = false;
+            });
+    });
+    nameInput.focus();
+}
+
+function refreshTagList() {
+    htmx.ajax('GET', window.location.pathname, {
+        target: '#file-explorer-view-content',
+        swap: 'innerHTML',
+    });
+}
+
+// eslint-disable-next-line no-unused-vars
+function createTag(form) {
+    const name = form.elements.name.value.trim();
+    if (!name) return;
+    apiRequest('POST', '/api/v1/tags', { name, color: form.elements.color.value })
+        .then(refreshTagList)
+        .catch((error) => {
+            toastr.error(`Failed to create tag ${name}: ${error.message}`);
+        });
+}
+
+// eslint-disable-next-line no-unused-vars
+function updateTag(id, changes) {
+    apiRequest('PATCH', `/api/v1/tags/${id}`, changes)
+        .then(refreshTagList)
+        .catch((error) => {
+            toastr.error(`Failed to update tag: ${error.message}`);
+        });
+}
+
+// eslint-disable-next-line no-unused-vars
+function renameTag(id, name) {
+    const newName = prompt('Rename tag', name);
+    if (newName === null || newName.trim() === '' || newName === name) return;
+    updateTag(id, { name: newName.trim() });
+}
+
+// eslint-disable-next-line no-unused-vars
+function deleteTag(id, name) {
+    if (!confirm(`Delete the tag ${name}? The files themselves are kept.`)) return;
+    apiRequest('DELETE', `/api/v1/tags/${id}`)
+        .then(refreshTagList)
+        .catch((error) => {
+            toastr.error(`Failed to delete tag ${name}: ${error.message}`);
+        });
+}
+
+// ALBUMS
+
+// eslint-disable-next-line no-unused-vars
+function createAlbum(form) {
+    const name = form.elements.name.value.trim();
+    if (!name) return;
+    apiRequest('POST', '/api/v1/albums', { name })
+        .then((album) => {
+            window.location.href = `/albums/${album.id}`;
+        })
+        .catch((error) => {
+            toastr.error(`Failed to create album ${name}: ${error.message}`);
+        });
+}
+
+// eslint-disable-next-line no-unused-vars
+function renameAlbum(id, name) {
+    const newName = prompt('Rename album', name);
+    if (newName === null || newName.trim() === '' || newName === name) return;
+    apiRequest('PATCH', `/api/v1/albums/${id}`, { name: newName.trim() })
+        .then(() => window.location.reload())
+        .catch((error) => {
+            toastr.error(`Failed to rename album: ${error.message}`);
+        });
+}
+
+// eslint-disable-next-line no-unused-vars
+function deleteAlbum(id, name) {
+    if (!confirm(`Delete the album ${name}? The photos themselves are kept.`)) return;
+    apiRequest('DELETE', `/api/v1/albums/${id}`)
+        .then(() => {
+            window.location.href = '/albums';
+        })
+        .catch((error) => {
+            toastr.error(`Failed to delete album ${name}: ${error.message}`);
+        });
+}
+
+// DUPLICATES
+
+/**
+ * Keep one photo of a set of duplicates and move the rest to the trash. The
+ * photos shown are sent along, so that nothing is trashed if a scan has
+ * changed the set since. Large sets carry on in the background, like other
+ * file operations.
+ */
+// eslint-disable-next-line no-unused-vars
+function resolveDuplicates(clusterId, keep) {
+    const cluster = document.querySelector(`.duplicates-cluster[data-cluster-id="${clusterId}"]`);
+    const paths = Array.from(cluster.querySelectorAll('.duplicates-copy')).map(
+        (copy) => copy.dataset.path
+    );
+    const others = paths.length - 1;
+    const copies = others === 1 ? '1 other copy' : `${others} other copies`;
+    if (!confirm(`Keep ${keep} and move ${copies} to the trash?`)) return;
+    apiRequest('POST', `/api/v1/duplicates/${clusterId}/resolve`, { keep, paths })
+        .then((body) => {
+            if (!body.results) {
+                getReportedJobs().add(body.job.id);
+                toastr.info(`${body.job.description} continues in the background`);
+                renderJob(body.job);
+            } else {
+                reportFileResults(body.results);
+            }
+            cluster.remove();
+        })
+        .catch((error) => {
+            toastr.error(
+                `Failed to trash duplicates: ${error.message}. Reload to review them again.`
+            );
+        });
+}
+
+// eslint-disable-next-line no-unused-vars
+function scanDuplicates() {
+    apiRequest('POST', '/api/v1/duplicates/scan')
+        .then(() => {
+            toastr.info('Scanning for duplicates. Reload the page in a moment to see them.');
+        })
+        .catch((error) => {
+            toastr.error(`Failed to scan for duplicates: ${error.message}`);
+        });
+}
+
+// STARS
+
+/**
+ * Star or unstar a file or folder. Open explorers update through the live
+ * updates.
+ */
+// eslint-disable-next-line no-unused-vars
+function toggleStar(event, rootDir, fileName, starred) {
+    preventDefault(event);
+    const filePath = joinFilePath(rootDir, fileName).replace(/\/+$/, '');
+    const request = starred
+        ? apiRequest('DELETE', `/api/v1/stars?filePaths=${encodeURIComponent(filePath)}`)
+        : apiRequest('POST', '/api/v1/stars', { paths: [filePath] });
+    request.catch((error) => {
+        toastr.error(`Failed to ${starred ? 'unstar' : 'star'} ${filePath}: ${error.message}`);
+    });
+}
+
+// ARCHIVES
+
+/**
+ * Check or uncheck every entry in the archive viewer
+ */
+// eslint-disable-next-line no-unused-vars
+function toggleArchiveEntries(checkbox) {
+    const viewer = checkbox.closest('.file-viewer-archive');
+    viewer.querySelectorAll('input[name="entry"]').forEach((entry) => {
+        entry.checked = checkbox.checked;
+    });
+}
+
+/**
+ * Extract the checked entries of an archive, or all of them, into the
+ * destination folder. Large archives carry on extracting in the background.
+ */
+// eslint-disable-next-line no-unused-vars
+function extractArchive(event, filePath) {
+    preventDefault(event);
+    const form = event.target;
+    const viewer = form.closest('.file-viewer-archive');
+    const destination = form.elements.destination.value.trim();
+    let entries = [];
+    if (event.submitter?.value !== 'all') {
+        entries = Array.from(viewer.querySelectorAll('input[name="entry"]:checked')).map(
+            (entry) => entry.value
+        );
+        if (entries.length === 0) {
+            toastr.warning('Select the entries to extract first');
+            return;
+        }
+    }
+    const encodedPath = filePath.split('/').map(encodeURIComponent).join('/');
+    fetch(`/api/v1/extract/files${encodedPath}`, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ entries, destination }),
+    })
+        .then((response) => response.json())
+        .then((body) => {
+            if (body.error) {
+                toastr.error(`Failed to extract: ${body.error.message}`);
+                return;
+            }
+            if (!body.results) {
+                getReportedJobs().add(body.job.id);
+                toastr.info(`${body.job.description} continues in the background`);
+                renderJob(body.job);
+                return;
+            }
+            if (body.job.status !== 'succeeded') {
+                toastr.error(`Failed to extract: ${body.job.error || body.job.status}`);
+                return;
+            }
+            toastr.success(`Extracted to ${destination}`);
+            refreshChangedView();
+        })
+        .catch((error) => {
+            toastr.error(`Failed to extract: ${error.message}`);
+        });
+}
+
 // eslint-disable-next-line no-unused-vars
 function newFile(event, rootDir) {
     preventDefault(event);
@@ -1264,3 +2007,230 @@ document.addEventListener('click', function (event) {
         clearSelectedFiles();
     }
 });
+
+// LIVE UPDATES
+// Re-render the folder being shown when it changes on disk, whether through
+// this tab, another one, or outside of autobutler entirely.
+
+var PHOTO_EXTENSIONS = /\.(png|jpe?g|gif|svg|heic|heif|webp|bmp|tiff?|avif)$/i;
+
+function watchFileChanges() {
+    // The script is loaded again whenever the explorer is swapped in
+    if (window.fileEventSource || typeof EventSource === 'undefined') return;
+    window.fileEventSource = new EventSource('/api/v1/events/files');
+    const refresh = debounce(refreshChangedView, 500);
+    window.fileEventSource.addEventListener('fs-change', function (event) {
+        const change = JSON.parse(event.data);
+        if (isAffectedByChange(change)) {
+            refresh();
+        }
+    });
+}
+
+/**
+ * Get the folder shown by the file explorer, like "/photos", or null when the
+ * page isn't showing one.
+ */
+function getExplorerDir() {
+    const currentPath = window.location.pathname;
+    const viewContent = document.getElementById('file-explorer-view-content');
+    if (!viewContent || !currentPath.startsWith('/files')) {
+        return null;
+    }
+    const dir = decodeURIComponent(currentPath.substring('/files'.length)).replace(/\/+$/, '');
+    return dir || '/';
+}
+
+/**
+ * Whether the page is showing a virtual folder, like files by tag or the
+ * starred ones. They can be anywhere, so any change may affect them.
+ */
+function isVirtualFolderView() {
+    return (
+        document.getElementById('file-explorer-view-content') !== null &&
+        /^\/(tags|starred|recent|activity)(\/|$)/.test(window.location.pathname)
+    );
+}
+
+function isAffectedByChange(change) {
+    if (isVirtualFolderView()) {
+        return true;
+    }
+    const dir = getExplorerDir();
+    if (dir !== null) {
+        // Changes below the folder change the sizes of its subfolders, and the
+        // column view also shows the folders above it
+        const within = (child, parent) => parent === '/' || child.startsWith(parent + '/');
+        return change.dir === dir || within(change.dir, dir) || within(dir, change.dir);
+    }
+    if (document.getElementById('photos-main')) {
+        // New folders may be full of photos
+        return change.paths.some((path) => PHOTO_EXTENSIONS.test(path) || !/\.[^/]+$/.test(path));
+    }
+    return false;
+}
+
+function refreshChangedView() {
+    if (getExplorerDir() !== null || isVirtualFolderView()) {
+        // Don't pull the rug out from under a selection or an open menu
+        if (selectedFiles.length > 0 || document.querySelector('.context-menu:not(.hidden)')) {
+            setTimeout(refreshChangedView, 2000);
+            return;
+        }
+        htmx.ajax('GET', window.location.pathname, {
+            target: '#file-explorer-view-content',
+            swap: 'innerHTML',
+        });
+    } else if (document.getElementById('photos-main')) {
+        htmx.ajax('GET', window.location.pathname, {
+            target: '#photos-main',
+            select: '#photos-main',
+            swap: 'outerHTML',
+        });
+    }
+}
+
+if (document.readyState === 'loading') {
+    document.addEventListener('DOMContentLoaded', watchFileChanges);
+} else {
+    watchFileChanges();
+}
+
+// BACKGROUND JOBS
+// Long file operations run as jobs on the server. Their progress is shown in
+// a panel that follows them over Server-Sent Events, and picks up the ones
+// that are still running after a reload.
+
+// Jobs finishing this quickly are left out of the panel
+var JOB_PANEL_DELAY = 1000;
+var JOB_FINISHED_LINGER = 5000;
+
+function watchJobs() {
+    if (window.jobEventSource || typeof EventSource === 'undefined') return;
+    window.jobEventSource = new EventSource('/api/v1/events/jobs');
+    window.jobEventSource.addEventListener('job', function (event) {
+        renderJob(JSON.parse(event.data));
+    });
+    fetch('/api/v1/jobs?limit=20')
+        .then((response) => response.json())
+        .then((body) => {
+            body.jobs
+                .filter((job) => !job.finishedAt)
+                .reverse()
+                .forEach(renderJob);
+        })
+        .catch((error) => console.error('Error loading jobs:', error));
+}
+
+/**
+ * Get the IDs of jobs started from this page, whose results are reported
+ * once they finish
+ */
+function getReportedJobs() {
+    if (!window.reportedJobs) {
+        window.reportedJobs = new Set();
+    }
+    return window.reportedJobs;
+}
+
+function getJobsPanel() {
+    let panel = document.getElementById('file-jobs');
+    if (!panel) {
+        // Lives outside of the explorer, which gets swapped out
+        panel = document.createElement('div');
+        panel.id = 'file-jobs';
+        panel.className = 'file-jobs';
+        panel.setAttribute('aria-live', 'polite');
+        document.body.appendChild(panel);
+    }
+    return panel;
+}
+
+function renderJob(job) {
+    const finished = Boolean(job.finishedAt);
+    if (finished && getReportedJobs().delete(job.id)) {
+        reportFileResults(job.result || []);
+        if (job.status === 'canceled') {
+            toastr.info(`${job.description} was canceled`);
+        }
+    }
+
+    const panel = getJobsPanel();
+    let row = panel.querySelector(`[data-job-id="${job.id}"]`);
+    if (!row) {
+        if (finished || Date.now() - Date.parse(job.createdAt) < JOB_PANEL_DELAY) return;
+        row = document.createElement('div');
+        row.className = 'file-job';
+        row.dataset.jobId = job.id;
+        row.innerHTML = `
+            <div class="file-job-header">
+                <span class="file-job-description"></span>
+                <button type="button" class="file-job-cancel">Cancel</button>
+            </div>
+            <div class="file-job-bar"><div class="file-job-bar-fill"></div></div>
+            <div class="file-job-status"></div>
+        `;
+        row.querySelector('.file-job-cancel').addEventListener('click', () => cancelJob(job.id));
+        panel.appendChild(row);
+    }
+    row.querySelector('.file-job-description').textContent = job.description;
+    row.querySelector('.file-job-bar-fill').style.width = `${getJobPercent(job)}%`;
+    row.querySelector('.file-job-status').textContent = describeJob(job);
+    row.querySelector('.file-job-cancel').hidden = finished;
+    row.classList.toggle('file-job--failed', job.status === 'failed');
+    if (finished) {
+        setTimeout(() => row.remove(), JOB_FINISHED_LINGER);
+    }
+}
+
+function getJobPercent(job) {
+    if (job.status === 'succeeded') return 100;
+    if (job.bytesTotal > 0) return Math.min(100, (job.bytesDone / job.bytesTotal) * 100);
+    if (job.itemsTotal > 0) return Math.min(100, (job.itemsDone / job.itemsTotal) * 100);
+    return 0;
+}
+
+function describeJob(job) {
+    switch (job.status) {
+        case 'queued':
+            return 'Waiting for other jobs…';
+        case 'succeeded':
+            return 'Done';
+        case 'canceled':
+            return 'Canceled';
+        case 'failed':
+            return job.error || 'Failed';
+        default:
+            if (job.bytesTotal > 0) {
+                return `${formatJobBytes(job.bytesDone)} of ${formatJobBytes(job.bytesTotal)}`;
+            }
+            return `${job.itemsDone} of ${job.itemsTotal} item(s)`;
+    }
+}
+
+function formatJobBytes(bytes) {
+    const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+    let unit = 0;
+    while (bytes >= 1024 && unit < units.length - 1) {
+        bytes /= 1024;
+        unit++;
+    }
+    return `${bytes.toFixed(unit === 0 ? 0 : 1)} ${units[unit]}`;
+}
+
+function cancelJob(id) {
+    fetch(`/api/v1/jobs/${id}/cancel`, { method: 'POST' })
+        .then((response) => response.json())
+        .then((body) => {
+            if (body.error) {
+                toastr.error(`Failed to cancel: ${body.error.message}`);
+            }
+        })
+        .catch((error) => toastr.error(`Failed to cancel: ${error.message}`));
+}
+
+if (document.readyState === 'loading') {
+    document.addEventListener('DOMContentLoaded', watchJobs);
+} else {
+    watchJobs();
+}
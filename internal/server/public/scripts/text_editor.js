@@ -2,7 +2,7 @@
 var currentEditorInstance = null;
 
 // eslint-disable-next-line no-unused-vars
-function initializeTextEditor(filePath) {
+function initializeTextEditor(filePath, etag) {
     if (typeof ace === 'undefined') {
         console.error('ACE editor not loaded');
         return;
@@ -93,33 +93,46 @@ function initializeTextEditor(filePath) {
     editor.resize();
     editor.renderer.updateFull();
 
-    // Debounced save function
+    // Debounced save function. Saves replace the file in place, and only if
+    // it is still the version that was loaded or last saved, so that edits
+    // from elsewhere are never silently overwritten.
     let saveTimeout;
+    let conflicted = false;
     editor.getSession().on('change', function () {
         clearTimeout(saveTimeout);
+        if (conflicted) {
+            return;
+        }
         saveTimeout = setTimeout(function () {
-            var content = editor.getValue();
-            var blob = new Blob([content], { type: 'text/plain' });
-            var formData = new FormData();
-
-            // Extract filename from path for the blob
-            var fileName = filePath.split('/').pop();
-            formData.append('files', blob, fileName);
-
-            // Extract directory from full path
-            var dirPath = filePath.substring(0, filePath.lastIndexOf('/'));
-
-            // POST to update the file
-            fetch('/api/v1/files' + dirPath, {
-                method: 'POST',
-                body: formData,
+            fetch('/api/v1/content/files' + filePath, {
+                method: 'PUT',
+                headers: {
+                    Accept: 'application/json',
+                    'Content-Type': 'text/plain; charset=utf-8',
+                    'If-Match': etag,
+                },
+                body: editor.getValue(),
             })
                 .then(function (response) {
-                    if (!response.ok) {
-                        return response.text().then(function (text) {
-                            toastr.error('Error saving file: ' + (text || response.statusText));
-                        });
+                    if (response.ok) {
+                        etag = response.headers.get('ETag') || etag;
+                        return;
+                    }
+                    if (response.status === 412) {
+                        conflicted = true;
+                        toastr.error(
+                            'This file was changed somewhere else. Reopen it to keep editing.'
+                        );
+                        return;
                     }
+                    return response.json().then(
+                        function (body) {
+                            toastr.error('Error saving file: ' + body.error.message);
+                        },
+                        function () {
+                            toastr.error('Error saving file: ' + response.statusText);
+                        }
+                    );
                 })
                 .catch(function (error) {
                     console.error('Error saving file:', error);
@@ -76,17 +76,22 @@ document.addEventListener('DOMContentLoaded', function () {
 
         let errorMessage = 'Request failed';
 
-        // Try to extract error message from response
+        // Try to extract error message from response, which is the error
+        // envelope for JSON and an error span for HTML
         try {
             const response = JSON.parse(xhr.responseText);
             if (response.error) {
-                errorMessage = response.error;
+                errorMessage = response.error.message || response.error;
             } else if (response.message) {
                 errorMessage = response.message;
             }
         } catch {
-            // Use status text if JSON parsing fails
-            if (xhr.statusText) {
+            const html = new DOMParser().parseFromString(xhr.responseText, 'text/html');
+            const text = html.body.textContent.trim();
+            if (text) {
+                errorMessage = text;
+            } else if (xhr.statusText) {
+                // Use status text if there's no message at all
                 errorMessage = `${errorMessage}: ${xhr.statusText}`;
             }
         }
@@ -2,11 +2,16 @@ package server
 
 import (
 	"embed"
+	"errors"
+	"net/http"
+	"strings"
 
 	v1 "autobutler/internal/server/api/v1"
 	"autobutler/internal/server/ui"
 	"autobutler/internal/server/ui/types"
 	"autobutler/internal/server/ui/views"
+	"autobutler/pkg/api"
+	"autobutler/pkg/util/serverutil"
 
 	"github.com/gin-contrib/static"
 	"github.com/gin-gonic/gin"
@@ -17,6 +22,7 @@ var public embed.FS
 
 func setupRoutes(router *gin.Engine) {
 	setupApiRoutes(router)
+	setupDavRoutes(router)
 	setupStaticRoutes(router)
 	setupUiRoutes(router)
 }
@@ -26,11 +32,29 @@ func setupApiRoutes(router *gin.Engine) {
 	v1.SetupMetricsRoutes(apiV1Group, metricsExporter)
 	v1.SetupDocRoutes(apiV1Group)
 	v1.SetupFilesRoutes(apiV1Group)
+	v1.SetupFileOperationRoutes(apiV1Group)
+	v1.SetupArchiveRoutes(apiV1Group)
 	v1.SetupCalendarRoutes(apiV1Group)
 	v1.SetupStorageRoutes(apiV1Group)
 	v1.SetupUpdateRoutes(apiV1Group)
 	v1.SetupHealthRoutes(apiV1Group)
 	v1.SetupThumbnailRoutes(apiV1Group)
+	v1.SetupTrashRoutes(apiV1Group)
+	v1.SetupUploadRoutes(apiV1Group)
+	v1.SetupSearchRoutes(apiV1Group)
+	v1.SetupEventRoutes(apiV1Group)
+	v1.SetupJobRoutes(apiV1Group)
+	v1.SetupShareRoutes(apiV1Group)
+	v1.SetupTagRoutes(apiV1Group)
+	v1.SetupActivityRoutes(apiV1Group)
+	v1.SetupQuotaRoutes(apiV1Group)
+	v1.SetupPhotoRoutes(apiV1Group)
+	v1.SetupAlbumRoutes(apiV1Group)
+	v1.SetupDuplicateRoutes(apiV1Group)
+}
+
+func setupDavRoutes(router *gin.Engine) {
+	v1.SetupDavRoutes(router.Group("/dav"))
 }
 
 func setupStaticRoutes(router *gin.Engine) error {
@@ -42,6 +66,11 @@ func setupStaticRoutes(router *gin.Engine) error {
 		static.Serve("/public", staticFS),
 		// TODO: have a proper 404 page
 		func(c *gin.Context) {
+			if strings.HasPrefix(c.Request.URL.Path, "/api/") && serverutil.WantsJSON(c) {
+				resp := api.NewResponse().WithStatusCode(http.StatusNotFound).WithError(errors.New("no such endpoint"))
+				c.JSON(resp.StatusCode, resp.Envelope())
+				return
+			}
 			if err := views.NotFound(types.NewPageState()).Render(c.Request.Context(), c.Writer); err != nil {
 				c.Status(400)
 				return
@@ -60,4 +89,5 @@ func setupUiRoutes(router *gin.Engine) {
 	ui.SetupFileRoutes(router)
 	ui.SetupPhotoRoutes(router)
 	ui.SetupBookRoutes(router)
+	ui.SetupShareRoutes(router, v1.DownloadFile)
 }
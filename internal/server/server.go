@@ -3,6 +3,15 @@ package server
 import (
 	"autobutler/pkg/botel/exporters/botelsqlite"
 	"autobutler/pkg/db"
+	"autobutler/pkg/dedup"
+	"autobutler/pkg/filechanges"
+	"autobutler/pkg/jobs"
+	"autobutler/pkg/photos"
+	"autobutler/pkg/search"
+	"autobutler/pkg/shares"
+	"autobutler/pkg/thumbnails"
+	"autobutler/pkg/trash"
+	"autobutler/pkg/uploads"
 	"context"
 	"fmt"
 	"log"
@@ -84,6 +93,17 @@ func StartServer() error {
 	// IMPORTANT: UseMiddleware MUST be called before setupRoutes
 	useMiddleware(router)
 	setupRoutes(router)
+	trash.StartAutoPurge()
+	uploads.StartExpiryCleanup()
+	shares.StartExpiryCleanup()
+	search.StartIndexer()
+	thumbnails.StartEviction()
+	// Before the catalogue, so that its first scan is followed by one for
+	// duplicates
+	dedup.StartScanner()
+	photos.StartScanner()
+	filechanges.StartWatcher()
+	jobs.StartJobs()
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -3,8 +3,8 @@ package ui
 import (
 	"autobutler/internal/server/ui/types"
 	"autobutler/internal/server/ui/views"
+	"autobutler/pkg/util/fileutil"
 	"autobutler/pkg/util/serverutil"
-	"path/filepath"
 
 	"github.com/a-h/templ"
 	"github.com/gin-gonic/gin"
@@ -30,7 +30,10 @@ func setupBookReaderView(router *gin.Engine) {
 		}
 
 		// Clean the path to prevent directory traversal
-		bookPath = filepath.Clean(bookPath)
+		bookPath, err := fileutil.GetFilesRoot().Clean(bookPath)
+		if err != nil {
+			return nil
+		}
 
 		return views.BookReader(bookPath)
 	})
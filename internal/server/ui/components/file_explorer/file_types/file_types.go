@@ -0,0 +1,106 @@
+package file_types
+
+import (
+	"autobutler/internal/server/ui/components/file_explorer/file_viewer/archive_viewer"
+	"autobutler/internal/server/ui/components/file_explorer/file_viewer/audio_viewer"
+	"autobutler/internal/server/ui/components/file_explorer/file_viewer/docx_viewer"
+	"autobutler/internal/server/ui/components/file_explorer/file_viewer/epub_viewer"
+	"autobutler/internal/server/ui/components/file_explorer/file_viewer/image_viewer"
+	"autobutler/internal/server/ui/components/file_explorer/file_viewer/pdf_viewer"
+	"autobutler/internal/server/ui/components/file_explorer/file_viewer/spreadsheet_viewer"
+	"autobutler/internal/server/ui/components/file_explorer/file_viewer/text_viewer"
+	"autobutler/internal/server/ui/components/file_explorer/file_viewer/unsupported_viewer"
+	"autobutler/internal/server/ui/components/file_explorer/file_viewer/video_viewer"
+	archiveicon "autobutler/internal/server/ui/components/icons/archive"
+	"autobutler/internal/server/ui/components/icons/audio"
+	"autobutler/internal/server/ui/components/icons/code"
+	"autobutler/internal/server/ui/components/icons/folder"
+	"autobutler/internal/server/ui/components/icons/generic"
+	"autobutler/internal/server/ui/components/icons/image"
+	"autobutler/internal/server/ui/components/icons/pdf"
+	"autobutler/internal/server/ui/components/icons/slideshow"
+	"autobutler/internal/server/ui/components/icons/spreadsheet"
+	"autobutler/internal/server/ui/components/icons/video"
+	"autobutler/pkg/archive"
+	"autobutler/pkg/util/fileutil"
+	"sync"
+
+	"github.com/a-h/templ"
+)
+
+// Kind is how the file explorer shows a type of file. Either half can be
+// left nil to use the default.
+type Kind struct {
+	Icon   func() templ.Component
+	Viewer func(filePath string) templ.Component
+}
+
+var (
+	kindsMutex sync.RWMutex
+	kinds      = map[fileutil.FileType]Kind{}
+)
+
+func init() {
+	Register(fileutil.FileTypeFolder, Kind{Icon: folder.Component})
+	Register(fileutil.FileTypeImage, Kind{Icon: image.Component, Viewer: image_viewer.Component})
+	Register(fileutil.FileTypeVideo, Kind{Icon: video.Component, Viewer: video_viewer.Component})
+	Register(fileutil.FileTypeAudio, Kind{Icon: audio.Component, Viewer: audio_viewer.Component})
+	Register(fileutil.FileTypePDF, Kind{Icon: pdf.Component, Viewer: pdf_viewer.Component})
+	Register(fileutil.FileTypeSlideshow, Kind{Icon: slideshow.Component})
+	Register(fileutil.FileTypeEpub, Kind{Viewer: epub_viewer.Component})
+	Register(fileutil.FileTypeDocx, Kind{Viewer: docx_viewer.Component})
+	Register(fileutil.FileTypeCode, Kind{Icon: code.Component})
+	Register(fileutil.FileTypeSpreadsheet, Kind{Icon: spreadsheet.Component, Viewer: spreadsheetViewer})
+	Register(fileutil.FileTypeArchive, Kind{Icon: archiveicon.Component, Viewer: archiveViewer})
+}
+
+func spreadsheetViewer(filePath string) templ.Component {
+	// Only delimited text can be read without a spreadsheet library
+	if !fileutil.IsTextFile(filePath) {
+		return unsupported_viewer.Component(filePath)
+	}
+	return spreadsheet_viewer.Component(filePath)
+}
+
+func archiveViewer(filePath string) templ.Component {
+	// Formats like 7z and rar can only be downloaded
+	if !archive.Supported(filePath) {
+		return unsupported_viewer.Component(filePath)
+	}
+	entries, err := archive.List(filePath)
+	return archive_viewer.Component(filePath, entries, err)
+}
+
+// Register sets how files of fileType are shown, replacing any earlier
+// registration.
+func Register(fileType fileutil.FileType, kind Kind) {
+	kindsMutex.Lock()
+	defer kindsMutex.Unlock()
+	kinds[fileType] = kind
+}
+
+func lookup(fileType fileutil.FileType) Kind {
+	kindsMutex.RLock()
+	defer kindsMutex.RUnlock()
+	return kinds[fileType]
+}
+
+// Icon returns the icon for files of fileType.
+func Icon(fileType fileutil.FileType) templ.Component {
+	if kind := lookup(fileType); kind.Icon != nil {
+		return kind.Icon()
+	}
+	return generic.Component()
+}
+
+// Viewer returns the viewer for the file at filePath, of fileType. Text
+// without a viewer of its own opens in the text editor.
+func Viewer(fileType fileutil.FileType, filePath string) templ.Component {
+	if kind := lookup(fileType); kind.Viewer != nil {
+		return kind.Viewer(filePath)
+	}
+	if fileType == fileutil.FileTypeGeneric || fileutil.IsTextFile(filePath) {
+		return text_viewer.Component(filePath)
+	}
+	return unsupported_viewer.Component(filePath)
+}
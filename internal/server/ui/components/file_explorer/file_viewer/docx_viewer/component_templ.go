@@ -11,12 +11,15 @@ import templruntime "github.com/a-h/templ/runtime"
 import "autobutler/pkg/quill"
 import "fmt"
 import "autobutler/pkg/util/fileutil"
-import "path/filepath"
 
 func getQuillData(filePath string) quill.Delta {
 	// Load the DOCX file and convert it to a Quill Delta
-	fullPath := filepath.Join(fileutil.GetFilesDir(), filePath)
-	delta, err := quill.FromDocx(fullPath)
+	content, err := fileutil.GetFilesRoot().ReadFile(filePath)
+	if err != nil {
+		fmt.Printf("Error loading DOCX file: %v", err)
+		return quill.Delta{}
+	}
+	delta, err := quill.FromDocxBytes(content)
 	if err != nil {
 		fmt.Printf("Error loading DOCX file: %v", err)
 		return quill.Delta{}
@@ -51,7 +54,7 @@ func Component(filePath string) templ.Component {
 		}
 		templ_7745c5c3_Var2, templ_7745c5c3_Err := templruntime.ScriptContentOutsideStringLiteral(getQuillData(filePath))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/components/file_explorer/file_viewer/docx_viewer/component.templ`, Line: 27, Col: 45}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/components/file_explorer/file_viewer/docx_viewer/component.templ`, Line: 30, Col: 45}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var2)
 		if templ_7745c5c3_Err != nil {
@@ -63,7 +66,7 @@ func Component(filePath string) templ.Component {
 		}
 		templ_7745c5c3_Var3, templ_7745c5c3_Err := templruntime.ScriptContentOutsideStringLiteral(filePath)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/components/file_explorer/file_viewer/docx_viewer/component.templ`, Line: 30, Col: 24}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/components/file_explorer/file_viewer/docx_viewer/component.templ`, Line: 33, Col: 24}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var3)
 		if templ_7745c5c3_Err != nil {
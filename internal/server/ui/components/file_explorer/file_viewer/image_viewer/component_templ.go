@@ -11,14 +11,11 @@ import templruntime "github.com/a-h/templ/runtime"
 import (
 	"autobutler/pkg/util/fileutil"
 	"encoding/base64"
-	"os"
 	"path/filepath"
 )
 
 func readImageAsBase64(filePath string) (string, error) {
-	rootDir := fileutil.GetFilesDir()
-	fullPath := filepath.Join(rootDir, filePath)
-	data, err := os.ReadFile(fullPath)
+	data, err := fileutil.GetFilesRoot().ReadFile(filePath)
 	if err != nil {
 		return "", err
 	}
@@ -43,8 +40,8 @@ func readImageAsBase64(filePath string) (string, error) {
 		return "data:image/avif;base64," + encoded, nil
 	case ".ico":
 		return "data:image/x-icon;base64," + encoded, nil
-	default: // Default to JPEG for everything else
-		return "data:image/jpeg;base64," + encoded, nil
+	default: // Go by the content for everything else
+		return "data:" + fileutil.SniffMimeType(filePath, data) + ";base64," + encoded, nil
 	}
 }
 
@@ -78,7 +75,7 @@ func Component(filePath string) templ.Component {
 			var templ_7745c5c3_Var2 string
 			templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(err.Error())
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/components/file_explorer/file_viewer/image_viewer/component.templ`, Line: 46, Col: 39}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/components/file_explorer/file_viewer/image_viewer/component.templ`, Line: 43, Col: 39}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
 			if templ_7745c5c3_Err != nil {
@@ -96,7 +93,7 @@ func Component(filePath string) templ.Component {
 			var templ_7745c5c3_Var3 string
 			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(imageData)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/components/file_explorer/file_viewer/image_viewer/component.templ`, Line: 50, Col: 18}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/components/file_explorer/file_viewer/image_viewer/component.templ`, Line: 47, Col: 18}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
 			if templ_7745c5c3_Err != nil {
@@ -109,7 +106,7 @@ func Component(filePath string) templ.Component {
 			var templ_7745c5c3_Var4 string
 			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(filePath)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/components/file_explorer/file_viewer/image_viewer/component.templ`, Line: 51, Col: 17}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/components/file_explorer/file_viewer/image_viewer/component.templ`, Line: 48, Col: 17}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 			if templ_7745c5c3_Err != nil {
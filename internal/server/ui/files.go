@@ -2,19 +2,27 @@ package ui
 
 import (
 	"autobutler/internal/server/ui/components/file_explorer"
-	"autobutler/internal/server/ui/components/file_explorer/file_viewer/docx_viewer"
-	"autobutler/internal/server/ui/components/file_explorer/file_viewer/epub_viewer"
-	"autobutler/internal/server/ui/components/file_explorer/file_viewer/image_viewer"
-	"autobutler/internal/server/ui/components/file_explorer/file_viewer/pdf_viewer"
-	"autobutler/internal/server/ui/components/file_explorer/file_viewer/text_viewer"
-	"autobutler/internal/server/ui/components/file_explorer/file_viewer/unsupported_viewer"
-	"autobutler/internal/server/ui/components/file_explorer/file_viewer/video_viewer"
+	"autobutler/internal/server/ui/components/file_explorer/activity_feed"
+	"autobutler/internal/server/ui/components/file_explorer/file_search"
+	"autobutler/internal/server/ui/components/file_explorer/file_types"
+	"autobutler/internal/server/ui/components/file_explorer/folder_size"
 	"autobutler/internal/server/ui/types"
 	"autobutler/internal/server/ui/views"
+	"autobutler/pkg/activity"
+	"autobutler/pkg/dirsize"
+	"autobutler/pkg/search"
+	"autobutler/pkg/stars"
+	"autobutler/pkg/tags"
+	"autobutler/pkg/trash"
 	"autobutler/pkg/util/fileutil"
 	"autobutler/pkg/util/serverutil"
+	"errors"
+	"fmt"
 	"html"
-	"path/filepath"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/a-h/templ"
 	"github.com/gin-gonic/gin"
@@ -22,6 +30,9 @@ import (
 
 func SetupFileRoutes(router *gin.Engine) {
 	setupFileView(router)
+	setupTrashView(router)
+	setupTagView(router)
+	setupActivityViews(router)
 	setupComponentRoutes(router)
 }
 
@@ -66,9 +77,47 @@ func setupFileView(router *gin.Engine) {
 	})
 }
 
+func setupTrashView(router *gin.Engine) {
+	serverutil.UiRoute(router, "/trash", func(c *gin.Context) templ.Component {
+		view := getViewFromRequest(c)
+
+		// If this is an htmx request, return just the view content with OOB breadcrumb
+		if c.GetHeader("HX-Request") == "true" {
+			return getTrashExplorerComponent(c, true, view)
+		}
+
+		return views.Trash(types.NewPageState().WithLocation(types.LocationTrash).WithView(view))
+	})
+}
+
+func setupTagView(router *gin.Engine) {
+	serverutil.UiRoute(router, "/tags", func(c *gin.Context) templ.Component {
+		return getTagExplorerComponent(c, tags.Tag{}, getViewFromRequest(c))
+	})
+	serverutil.UiRoute(router, "/tags/:id", func(c *gin.Context) templ.Component {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return views.NotFound(types.NewPageState())
+		}
+		tag, err := tags.Get(id)
+		if errors.Is(err, tags.ErrNotFound) {
+			c.Status(http.StatusNotFound)
+			return views.NotFound(types.NewPageState())
+		} else if err != nil {
+			c.Writer.WriteString(`<span class="text-red-500">Failed to load tag: ` + html.EscapeString(err.Error()) + `</span>`)
+			return nil
+		}
+		return getTagExplorerComponent(c, tag, getViewFromRequest(c))
+	})
+}
+
 func setupComponentRoutes(router *gin.Engine) {
 	setupComponentFileExplorer(router)
 	setupComponentFileViewers(router)
+	setupComponentFileSearch(router)
+	setupComponentFolderSize(router)
+	setupComponentActivity(router)
 }
 
 func GetFileExplorer(c *gin.Context, rootDir string) templ.Component {
@@ -84,13 +133,7 @@ func GetFileExplorerViewContentWithBreadcrumb(c *gin.Context, rootDir string, vi
 }
 
 func getFileExplorerComponent(c *gin.Context, rootDir string, viewContentOnly bool, view ...any) templ.Component {
-	fullPathDir := ""
-	if rootDir == "" {
-		fullPathDir = fileutil.GetFilesDir()
-	} else {
-		fullPathDir = filepath.Join(fileutil.GetFilesDir(), rootDir)
-	}
-	files, err := fileutil.StatFilesInDir(fullPathDir)
+	files, err := fileutil.GetFilesRoot().StatFilesInDir(rootDir, dirsize.Get)
 	if err != nil {
 		c.Writer.WriteString(`<span class="text-red-500">Failed to load files: ` + html.EscapeString(err.Error()) + `</span>`)
 		return nil
@@ -111,8 +154,17 @@ func getFileExplorerComponent(c *gin.Context, rootDir string, viewContentOnly bo
 		}
 	}
 
+	fileTags, err := tags.InDir(rootDir)
+	if err != nil {
+		fmt.Printf("Error loading tags in %s: %v\n", rootDir, err)
+	}
+	starred, err := stars.InDir(rootDir)
+	if err != nil {
+		fmt.Printf("Error loading stars in %s: %v\n", rootDir, err)
+	}
+
 	var component templ.Component
-	pageState := types.NewPageState().WithRootDir(rootDir).WithView(viewStr)
+	pageState := types.NewPageState().WithRootDir(rootDir).WithView(viewStr).WithFileTags(fileTags).WithStarred(starred)
 	if viewContentOnly {
 		if withBreadcrumb {
 			component = file_explorer.ViewContentWithBreadcrumb(pageState, files, viewStr)
@@ -125,33 +177,131 @@ func getFileExplorerComponent(c *gin.Context, rootDir string, viewContentOnly bo
 	return component
 }
 
+// GetTrashExplorer renders the file explorer over the items in the trash.
+func GetTrashExplorer(c *gin.Context) templ.Component {
+	return getTrashExplorerComponent(c, false, getViewFromRequest(c))
+}
+
+func getTrashExplorerComponent(c *gin.Context, viewContentOnly bool, view string) templ.Component {
+	files, err := trash.ListFileInfos()
+	if err != nil {
+		c.Writer.WriteString(`<span class="text-red-500">Failed to load trash: ` + html.EscapeString(err.Error()) + `</span>`)
+		return nil
+	}
+	pageState := types.NewPageState().WithLocation(types.LocationTrash).WithView(view)
+	if viewContentOnly {
+		return file_explorer.ViewContentWithBreadcrumb(pageState, files, pageState.View)
+	}
+	return file_explorer.Component(pageState, files, pageState.View)
+}
+
+// getTagExplorerComponent renders the file explorer over the files with a
+// tag, wherever they are. Without a tag, it lists every tag instead.
+func getTagExplorerComponent(c *gin.Context, tag tags.Tag, view string) templ.Component {
+	var files []fs.FileInfo
+	if tag.ID != 0 {
+		var err error
+		files, err = tags.ListFileInfos(tag.ID, dirsize.Get)
+		if err != nil {
+			c.Writer.WriteString(`<span class="text-red-500">Failed to load tagged files: ` + html.EscapeString(err.Error()) + `</span>`)
+			return nil
+		}
+	}
+	return getVirtualFolderComponent(c, types.NewPageState().WithTag(tag).WithView(view), files)
+}
+
+// getVirtualFolderComponent renders the file explorer over files from all over
+// the files area. Their names are their paths, so that the explorer links to
+// them from the root.
+func getVirtualFolderComponent(c *gin.Context, pageState types.PageState, files []fs.FileInfo) templ.Component {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = strings.TrimSuffix(file.Name(), "/")
+	}
+	fileTags, err := tags.ForPaths(paths)
+	if err != nil {
+		fmt.Printf("Error loading tags: %v\n", err)
+	}
+	starred, err := stars.Among(paths)
+	if err != nil {
+		fmt.Printf("Error loading stars: %v\n", err)
+	}
+	pageState = pageState.WithRootDir("/").WithFileTags(fileTags).WithStarred(starred)
+	if c.GetHeader("HX-Request") == "true" {
+		return file_explorer.ViewContentWithBreadcrumb(pageState, files, pageState.View)
+	}
+	return views.VirtualFolder(pageState, files)
+}
+
+func setupActivityViews(router *gin.Engine) {
+	serverutil.UiRoute(router, "/starred", func(c *gin.Context) templ.Component {
+		files, err := stars.ListFileInfos(dirsize.Get)
+		if err != nil {
+			c.Writer.WriteString(`<span class="text-red-500">Failed to load starred files: ` + html.EscapeString(err.Error()) + `</span>`)
+			return nil
+		}
+		pageState := types.NewPageState().WithLocation(types.LocationStarred).WithView(getViewFromRequest(c))
+		return getVirtualFolderComponent(c, pageState, files)
+	})
+	serverutil.UiRoute(router, "/recent", func(c *gin.Context) templ.Component {
+		paths, err := activity.RecentPaths(activity.DefaultLimit)
+		if err != nil {
+			c.Writer.WriteString(`<span class="text-red-500">Failed to load recent files: ` + html.EscapeString(err.Error()) + `</span>`)
+			return nil
+		}
+		files := fileutil.GetFilesRoot().StatPaths(paths, dirsize.Get)
+		pageState := types.NewPageState().WithLocation(types.LocationRecent).WithView(getViewFromRequest(c))
+		return getVirtualFolderComponent(c, pageState, files)
+	})
+	serverutil.UiRoute(router, "/activity", func(c *gin.Context) templ.Component {
+		pageState := types.NewPageState().WithLocation(types.LocationActivity).WithView(getViewFromRequest(c))
+		return getVirtualFolderComponent(c, pageState, nil)
+	})
+}
+
 func setupComponentFileExplorer(router *gin.Engine) {
 	serverutil.UiRoute(router, "/components/files/explorer/*fileDir", func(c *gin.Context) templ.Component {
 		return GetFileExplorer(c, c.Param("fileDir"))
 	})
 }
 
+func setupComponentFileSearch(router *gin.Engine) {
+	serverutil.UiRoute(router, "/components/files/search", func(c *gin.Context) templ.Component {
+		query := strings.TrimSpace(c.Query("q"))
+		results, err := search.Search(query, nil, search.DefaultLimit, 0)
+		if err != nil && !errors.Is(err, search.ErrEmptyQuery) {
+			c.Writer.WriteString(`<span class="text-red-500">Failed to search files: ` + html.EscapeString(err.Error()) + `</span>`)
+			return nil
+		}
+		return file_search.Results(query, results)
+	})
+}
+
+func setupComponentFolderSize(router *gin.Engine) {
+	serverutil.UiRoute(router, "/components/files/size/*dirPath", func(c *gin.Context) templ.Component {
+		dirPath := c.Param("dirPath")
+		size, ok := dirsize.Get(dirPath)
+		return folder_size.Component(dirPath, size, ok)
+	})
+}
+
+func setupComponentActivity(router *gin.Engine) {
+	serverutil.UiRoute(router, "/components/files/activity", func(c *gin.Context) templ.Component {
+		before, _ := strconv.ParseInt(c.Query("before"), 10, 64)
+		events, err := activity.List(before, activity.DefaultLimit)
+		if err != nil {
+			c.Writer.WriteString(`<span class="text-red-500">Failed to load activity: ` + html.EscapeString(err.Error()) + `</span>`)
+			return nil
+		}
+		return activity_feed.Events(events)
+	})
+}
+
 func setupComponentFileViewers(router *gin.Engine) {
 	serverutil.UiRoute(router, "/components/files/viewer/files/*filePath", func(c *gin.Context) templ.Component {
 		filePath := c.Param("filePath")
 		fileType := fileutil.DetermineFileTypeFromPath(filePath)
-		var viewer templ.Component
-		switch fileType {
-		case fileutil.FileTypeImage:
-			viewer = image_viewer.Component(filePath)
-		case fileutil.FileTypeVideo:
-			viewer = video_viewer.Component(filePath)
-		case fileutil.FileTypePDF:
-			viewer = pdf_viewer.Component(filePath)
-		case fileutil.FileTypeEpub:
-			viewer = epub_viewer.Component(filePath)
-		case fileutil.FileTypeDocx:
-			viewer = docx_viewer.Component(filePath)
-		case fileutil.FileTypeGeneric:
-			viewer = text_viewer.Component(filePath)
-		default:
-			viewer = unsupported_viewer.Component(filePath)
-		}
-		return viewer
+		activity.Record(activity.ActionOpened, filePath)
+		return file_types.Viewer(fileType, filePath)
 	})
 }
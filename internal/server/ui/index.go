@@ -1,10 +1,13 @@
 package ui
 
 import (
+	"autobutler/internal/server/ui/components/recent_files"
 	"autobutler/internal/server/ui/types"
 	"autobutler/internal/server/ui/views"
+	"autobutler/pkg/activity"
 	"autobutler/pkg/storage"
 	"autobutler/pkg/util/serverutil"
+	"fmt"
 
 	"github.com/a-h/templ"
 	"github.com/gin-gonic/gin"
@@ -23,6 +26,12 @@ func SetupIndexRoutes(router *gin.Engine) {
 			summary = storage.Summary{}
 		}
 
-		return views.Home(types.NewPageState(), summary)
+		// The home page is fine without recent files
+		recent, err := activity.RecentPaths(recent_files.Limit)
+		if err != nil {
+			fmt.Printf("Error loading recent files: %v\n", err)
+		}
+
+		return views.Home(types.NewPageState(), summary, recent)
 	})
 }
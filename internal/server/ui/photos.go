@@ -4,10 +4,13 @@ import (
 	"autobutler/internal/server/ui/components/photos"
 	"autobutler/internal/server/ui/types"
 	"autobutler/internal/server/ui/views"
+	"autobutler/pkg/albums"
+	catalogue "autobutler/pkg/photos"
 	"autobutler/pkg/storage"
-	"autobutler/pkg/util/fileutil"
-	"autobutler/pkg/util/imageutil"
 	"autobutler/pkg/util/serverutil"
+	"errors"
+	"html"
+	"net/http"
 	"strconv"
 
 	"github.com/a-h/templ"
@@ -16,81 +19,82 @@ import (
 
 func SetupPhotoRoutes(router *gin.Engine) {
 	setupPhotoView(router)
+	setupAlbumViews(router)
+	setupDuplicatesView(router)
 	setupPhotoComponentRoutes(router)
 }
 
 func setupPhotoView(router *gin.Engine) {
 	serverutil.UiRoute(router, "/photos", func(c *gin.Context) templ.Component {
-		// Get storage summary for the storage bar component
-		detector := storage.NewDetector()
-		devices, err := detector.DetectDevices()
-		var summary storage.Summary
-		if err == nil && len(devices) > 0 {
-			summary = detector.CalculateSummary(devices)
-		} else {
-			// Provide empty summary if detection fails
-			summary = storage.Summary{}
-		}
-
-		return views.Photos(types.NewPageState(), summary)
+		return views.Photos(types.NewPageState(), storageSummary())
 	})
 	serverutil.UiRoute(router, "/photos/*rootDir", func(c *gin.Context) templ.Component {
 		rootDir := c.Param("rootDir")
-		// Get storage summary for the storage bar component
-		detector := storage.NewDetector()
-		devices, err := detector.DetectDevices()
-		var summary storage.Summary
-		if err == nil && len(devices) > 0 {
-			summary = detector.CalculateSummary(devices)
-		} else {
-			// Provide empty summary if detection fails
-			summary = storage.Summary{}
+		return views.Photos(types.NewPageState().WithRootDir(rootDir), storageSummary())
+	})
+}
+
+// setupAlbumViews serves albums under /albums, as /photos/*rootDir takes
+// every path below /photos.
+func setupAlbumViews(router *gin.Engine) {
+	serverutil.UiRoute(router, "/albums", func(c *gin.Context) templ.Component {
+		return views.Albums(types.NewPageState(), storageSummary())
+	})
+	serverutil.UiRoute(router, "/albums/:id", func(c *gin.Context) templ.Component {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return views.NotFound(types.NewPageState())
 		}
+		album, err := albums.Get(id)
+		if errors.Is(err, albums.ErrNotFound) {
+			c.Status(http.StatusNotFound)
+			return views.NotFound(types.NewPageState())
+		} else if err != nil {
+			c.Writer.WriteString(`<span class="text-red-500">Failed to load album: ` + html.EscapeString(err.Error()) + `</span>`)
+			return nil
+		}
+		return views.Album(types.NewPageState(), album, storageSummary())
+	})
+}
 
-		return views.Photos(types.NewPageState().WithRootDir(rootDir), summary)
+func setupDuplicatesView(router *gin.Engine) {
+	serverutil.UiRoute(router, "/duplicates", func(c *gin.Context) templ.Component {
+		return views.Duplicates(types.NewPageState(), storageSummary())
 	})
 }
 
 func setupPhotoComponentRoutes(router *gin.Engine) {
 	// Endpoint for infinite scroll pagination
 	serverutil.UiRoute(router, "/components/photos/grid", func(c *gin.Context) templ.Component {
-		pageStr := c.Query("page")
-		page, err := strconv.Atoi(pageStr)
-		if err != nil || page < 1 {
-			page = 1
-		}
-
-		// DEBUG LOG
-		println("🔍 SERVER: Photo grid request - Page:", page)
-
-		// Get all photos
-		photoFiles, err := imageutil.FindAllPhotosRecursively(fileutil.GetFilesDir())
+		pageState := types.NewPageState().WithRootDir(c.Query("rootDir"))
+		page, err := catalogue.List(catalogue.Filter{RootDir: pageState.RootDir}, c.Query("cursor"), photos.PageSize)
 		if err != nil {
 			return nil
 		}
-
-		totalPhotos := len(photoFiles)
-		pageSize := 50
-		startIdx := (page - 1) * pageSize
-		endIdx := startIdx + pageSize
-
-		println("📊 SERVER: Total photos:", totalPhotos, "StartIdx:", startIdx, "EndIdx:", endIdx)
-
-		if startIdx >= totalPhotos {
+		return photos.PhotoGridPage(pageState, page, c.Query("day"))
+	})
+	serverutil.UiRoute(router, "/components/albums/:id/grid", func(c *gin.Context) templ.Component {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
 			return nil
 		}
-
-		if endIdx > totalPhotos {
-			endIdx = totalPhotos
+		page, err := albums.Photos(id, c.Query("cursor"), photos.PageSize)
+		if err != nil {
+			return nil
 		}
-
-		pagePhotos := photoFiles[startIdx:endIdx]
-		println("✅ SERVER: Rendering", len(pagePhotos), "photos for page", page)
-
-		// Set status before rendering
-		c.Status(200)
-
-		// Render the page component
-		return photos.PhotoGridPage(types.NewPageState(), pagePhotos, page, totalPhotos)
+		return photos.AlbumGridPage(types.NewPageState(), id, page)
 	})
 }
+
+// storageSummary sums up the storage devices for the storage bar of the
+// photos sidebar.
+func storageSummary() storage.Summary {
+	detector := storage.NewDetector()
+	devices, err := detector.DetectDevices()
+	if err != nil || len(devices) == 0 {
+		// Show an empty bar if detection fails
+		return storage.Summary{}
+	}
+	return detector.CalculateSummary(devices)
+}
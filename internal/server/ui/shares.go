@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"autobutler/internal/server/ui/views"
+	"autobutler/pkg/shares"
+	"autobutler/pkg/util/fileutil"
+	"autobutler/pkg/util/serverutil"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// shareAccessCookie holds the key proving the password of a share was
+	// given. It's scoped to the path of the share, so every share gets its
+	// own.
+	shareAccessCookie = "share_access"
+	// shareDownloadCookie holds the grant to fetch a file that was already
+	// counted as downloaded. It's scoped to the path of the file.
+	shareDownloadCookie = "share_download"
+)
+
+// SetupShareRoutes serves public share links. They live outside the rest of
+// the UI, so that people following a link see nothing but what was shared.
+// download serves the content of a file, or a folder as a zip archive.
+func SetupShareRoutes(router *gin.Engine, download func(c *gin.Context, filePath string)) {
+	serverutil.UiRoute(router, "/s/:token", func(c *gin.Context) templ.Component {
+		return shareView(c, "", download)
+	})
+	serverutil.UiRoute(router, "/s/:token/*subPath", func(c *gin.Context) templ.Component {
+		return shareView(c, c.Param("subPath"), download)
+	})
+	router.POST("/s/:token", unlockShare)
+}
+
+func shareView(c *gin.Context, subPath string, download func(c *gin.Context, filePath string)) templ.Component {
+	// Keep the token out of Referer headers and search engines
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	share, err := shares.Find(c.Param("token"))
+	if err != nil {
+		return shareUnavailable(c, err)
+	}
+	filePath, resolveErr := share.Resolve(subPath)
+	downloading := c.Query("download") != ""
+	// Players fetch videos in many ranges, which count as one download as
+	// long as they hold on to the grant from the first, even once the share
+	// is out of downloads
+	grant, _ := c.Cookie(shareDownloadCookie)
+	granted := downloading && resolveErr == nil && share.CheckDownloadGrant(filePath, grant)
+	if share.Expired() && !granted {
+		return shareUnavailable(c, shares.ErrExpired)
+	}
+	if accessKey, _ := c.Cookie(shareAccessCookie); !share.CheckAccessKey(accessKey) {
+		c.Status(http.StatusUnauthorized)
+		return views.SharePassword(share, false)
+	}
+	if resolveErr != nil {
+		return shareUnavailable(c, resolveErr)
+	}
+	info, err := fileutil.GetFilesRoot().Stat(filePath)
+	if err != nil {
+		return shareUnavailable(c, err)
+	}
+
+	if downloading {
+		if !granted {
+			if err := share.CountDownload(); err != nil {
+				return shareUnavailable(c, err)
+			}
+			if grant, err := share.DownloadGrant(filePath); err == nil {
+				c.SetSameSite(http.SameSiteLaxMode)
+				c.SetCookie(shareDownloadCookie, grant, int(shares.DownloadGrantLifetime.Seconds()), c.Request.URL.Path, "", c.Request.TLS != nil, true)
+			}
+		}
+		download(c, filePath)
+		return templ.NopComponent
+	}
+
+	var entries []fs.FileInfo
+	if info.IsDir() {
+		dirEntries, err := fileutil.GetFilesRoot().ReadDir(filePath)
+		if err != nil {
+			return shareUnavailable(c, err)
+		}
+		for _, entry := range dirEntries {
+			if entryInfo, err := entry.Info(); err == nil {
+				entries = append(entries, entryInfo)
+			}
+		}
+	}
+	return views.Share(share, subPath, info, entries)
+}
+
+// unlockShare checks the password of a share, and hands out a cookie letting
+// the browser in if it's right.
+func unlockShare(c *gin.Context) {
+	c.Header("Referrer-Policy", "no-referrer")
+	share, err := shares.Get(c.Param("token"))
+	if err != nil {
+		renderShareComponent(c, shareUnavailable(c, err))
+		return
+	}
+	if !share.CheckPassword(c.PostForm("password")) {
+		c.Status(http.StatusUnauthorized)
+		renderShareComponent(c, views.SharePassword(share, true))
+		return
+	}
+	accessKey, err := share.AccessKey()
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		renderShareComponent(c, views.ShareUnavailable("Something went wrong, please try again later."))
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(shareAccessCookie, accessKey, 0, share.URL, "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusSeeOther, share.URL)
+}
+
+func renderShareComponent(c *gin.Context, component templ.Component) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
+		fmt.Printf("Error rendering share page: %v\n", err)
+	}
+}
+
+// shareUnavailable sets the status for an error from the shares package, and
+// returns the page explaining it. Unknown, revoked and missing all look the
+// same, so that links can't be probed.
+func shareUnavailable(c *gin.Context, err error) templ.Component {
+	switch {
+	case errors.Is(err, shares.ErrExpired):
+		c.Status(http.StatusGone)
+		return views.ShareUnavailable("This link has expired.")
+	case errors.Is(err, shares.ErrNotFound), errors.Is(err, shares.ErrOutsideShare), errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrPermission):
+		c.Status(http.StatusNotFound)
+		return views.ShareUnavailable("This link doesn't exist, or has been revoked.")
+	default:
+		c.Status(http.StatusInternalServerError)
+		return views.ShareUnavailable("Something went wrong, please try again later.")
+	}
+}
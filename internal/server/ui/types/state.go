@@ -1,12 +1,40 @@
 package types
 
-import "strings"
+import (
+	"autobutler/pkg/tags"
+	"io/fs"
+	"strings"
+)
+
+// Location is the area of storage that the file explorer is browsing.
+type Location string
+
+const (
+	LocationFiles Location = ""
+	LocationTrash Location = "trash"
+	// LocationTag is the virtual folder of the files and folders with a tag.
+	LocationTag Location = "tag"
+	// LocationStarred is the virtual folder of the starred files and folders.
+	LocationStarred Location = "starred"
+	// LocationRecent is the virtual folder of the files and folders opened or
+	// changed most recently.
+	LocationRecent Location = "recent"
+	// LocationActivity is the feed of what happened to files and folders.
+	LocationActivity Location = "activity"
+)
 
 type PageState struct {
 	CurrentPageName PageName
 	RootDir         string
 	NavLinks        []Page
 	View            string
+	Location        Location
+	// Tag is the tag being browsed, when the location is LocationTag.
+	Tag tags.Tag
+	// FileTags are the tags of the files being shown, by name.
+	FileTags map[string][]tags.Tag
+	// Starred are the files being shown that are starred, by name.
+	Starred map[string]bool
 }
 
 func NewPageState() PageState {
@@ -44,3 +72,96 @@ func (p PageState) WithView(view string) PageState {
 	p.View = view
 	return p
 }
+
+func (p PageState) WithLocation(location Location) PageState {
+	p.Location = location
+	return p
+}
+
+// InTrash reports whether the file explorer is showing the trash rather than
+// the files area.
+func (p PageState) InTrash() bool {
+	return p.Location == LocationTrash
+}
+
+// InFolder reports whether the file explorer is showing a folder of the files
+// area, rather than the trash or a virtual folder made up of files from all
+// over it.
+func (p PageState) InFolder() bool {
+	return p.Location == LocationFiles
+}
+
+// LocationTitle names the location being shown, for the file explorer's
+// headings.
+func (p PageState) LocationTitle() string {
+	switch p.Location {
+	case LocationTrash:
+		return "Trash"
+	case LocationTag:
+		return "Tags"
+	case LocationStarred:
+		return "Starred"
+	case LocationRecent:
+		return "Recent"
+	case LocationActivity:
+		return "Activity"
+	default:
+		return "File Explorer"
+	}
+}
+
+// EmptyMessage is shown in place of the files when there are none.
+func (p PageState) EmptyMessage() string {
+	switch p.Location {
+	case LocationTrash:
+		return "Trash is empty"
+	case LocationTag:
+		return "Nothing is tagged " + p.Tag.Name
+	case LocationStarred:
+		return "Nothing is starred yet. Star files from their menu to find them here."
+	case LocationRecent:
+		return "Nothing has been opened or changed yet"
+	case LocationActivity:
+		return "Nothing has happened yet"
+	default:
+		return "Empty folder"
+	}
+}
+
+func (p PageState) WithTag(tag tags.Tag) PageState {
+	p.Location = LocationTag
+	p.Tag = tag
+	return p
+}
+
+func (p PageState) WithFileTags(fileTags map[string][]tags.Tag) PageState {
+	p.FileTags = fileTags
+	return p
+}
+
+// InTag reports whether the file explorer is showing the files with a tag,
+// which can be anywhere in the files area.
+func (p PageState) InTag() bool {
+	return p.Location == LocationTag
+}
+
+// TagsOf returns the tags of one of the files being shown.
+func (p PageState) TagsOf(file fs.FileInfo) []tags.Tag {
+	if file == nil {
+		return nil
+	}
+	return p.FileTags[strings.TrimSuffix(file.Name(), "/")]
+}
+
+func (p PageState) WithStarred(starred map[string]bool) PageState {
+	p.Starred = starred
+	return p
+}
+
+// IsStarred reports whether one of the files being shown is starred.
+func (p PageState) IsStarred(file fs.FileInfo) bool {
+	if file == nil {
+		return false
+	}
+	return p.Starred[strings.TrimSuffix(file.Name(), "/")]
+}
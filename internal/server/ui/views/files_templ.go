@@ -13,8 +13,8 @@ import (
 	"autobutler/internal/server/ui/components/file_explorer"
 	"autobutler/internal/server/ui/components/header"
 	"autobutler/internal/server/ui/types"
+	"autobutler/pkg/dirsize"
 	"autobutler/pkg/util/fileutil"
-	"path/filepath"
 )
 
 func Files(pageState types.PageState) templ.Component {
@@ -59,7 +59,7 @@ func Files(pageState types.PageState) templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			files, err := fileutil.StatFilesInDir(filepath.Join(fileutil.GetFilesDir(), pageState.RootDir))
+			files, err := fileutil.GetFilesRoot().StatFilesInDir(pageState.RootDir, dirsize.Get)
 			if err != nil {
 				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<div class=\"error-text\">Error loading files: ")
 				if templ_7745c5c3_Err != nil {
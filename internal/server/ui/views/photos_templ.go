@@ -13,10 +13,8 @@ import (
 	"autobutler/internal/server/ui/components/header"
 	"autobutler/internal/server/ui/components/photos"
 	"autobutler/internal/server/ui/types"
+	catalogue "autobutler/pkg/photos"
 	"autobutler/pkg/storage"
-	"autobutler/pkg/util/fileutil"
-	"autobutler/pkg/util/imageutil"
-	"fmt"
 )
 
 func Photos(pageState types.PageState, summary storage.Summary) templ.Component {
@@ -61,7 +59,8 @@ func Photos(pageState types.PageState, summary storage.Summary) templ.Component
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			photoFiles, err := imageutil.FindAllPhotosRecursively(fileutil.GetFilesDir())
+			page, err := catalogue.List(catalogue.Filter{RootDir: pageState.RootDir}, "", photos.PageSize)
+			totalPhotos, countErr := catalogue.Count(pageState.RootDir)
 			if err != nil {
 				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<div class=\"error-text\">Error loading photos: ")
 				if templ_7745c5c3_Err != nil {
@@ -70,7 +69,7 @@ func Photos(pageState types.PageState, summary storage.Summary) templ.Component
 				var templ_7745c5c3_Var3 string
 				templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(err.Error())
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/views/photos.templ`, Line: 22, Col: 63}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/views/photos.templ`, Line: 21, Col: 63}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
 				if templ_7745c5c3_Err != nil {
@@ -80,58 +79,26 @@ func Photos(pageState types.PageState, summary storage.Summary) templ.Component
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-			} else {
-				// For initial load, only show first 50 photos
-				pageSize := 50
-				totalPhotos := len(photoFiles)
-				firstPage := photoFiles
-				if totalPhotos > pageSize {
-					firstPage = photoFiles[:pageSize]
-				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<div id=\"photo-debug-initial\" data-total=\"")
+			} else if countErr != nil {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<div class=\"error-text\">Error loading photos: ")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 				var templ_7745c5c3_Var4 string
-				templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", totalPhotos))
+				templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(countErr.Error())
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/views/photos.templ`, Line: 35, Col: 48}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/views/photos.templ`, Line: 23, Col: 68}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "\" data-first-page=\"")
-				if templ_7745c5c3_Err != nil {
-					return templ_7745c5c3_Err
-				}
-				var templ_7745c5c3_Var5 string
-				templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", len(firstPage)))
-				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/views/photos.templ`, Line: 36, Col: 56}
-				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
-				if templ_7745c5c3_Err != nil {
-					return templ_7745c5c3_Err
-				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "\" data-page-size=\"")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</div>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var6 string
-				templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", pageSize))
-				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/server/ui/views/photos.templ`, Line: 37, Col: 49}
-				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
-				if templ_7745c5c3_Err != nil {
-					return templ_7745c5c3_Err
-				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "\" style=\"display:none\"></div><script type=\"text/javascript\">\n\t\t\t\t\t(function() {\n\t\t\t\t\t\tconst debug = document.getElementById('photo-debug-initial');\n\t\t\t\t\t\tif (debug) {\n\t\t\t\t\t\t\tconsole.log('🚀 Initial Photos Load:', {\n\t\t\t\t\t\t\t\ttotalPhotos: parseInt(debug.dataset.total),\n\t\t\t\t\t\t\t\tfirstPageCount: parseInt(debug.dataset.firstPage),\n\t\t\t\t\t\t\t\tpageSize: parseInt(debug.dataset.pageSize)\n\t\t\t\t\t\t\t});\n\t\t\t\t\t\t}\n\t\t\t\t\t})();\n\t\t\t\t</script> ")
-				if templ_7745c5c3_Err != nil {
-					return templ_7745c5c3_Err
-				}
-				templ_7745c5c3_Err = photos.Component(pageState, firstPage, totalPhotos, summary).Render(ctx, templ_7745c5c3_Buffer)
+			} else {
+				templ_7745c5c3_Err = photos.Component(pageState, page, totalPhotos, summary).Render(ctx, templ_7745c5c3_Buffer)
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -142,7 +109,7 @@ func Photos(pageState types.PageState, summary storage.Summary) templ.Component
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "</html>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "</html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
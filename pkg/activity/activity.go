@@ -0,0 +1,173 @@
+package activity
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"time"
+)
+
+// The activity feed records what happens to files and folders, by their path
+// relative to the files root at the time. Unlike tags and stars, entries stay
+// as they were when files move on, since they're history.
+
+// Action is something that happened to a file or folder.
+type Action string
+
+const (
+	ActionOpened   Action = "opened"
+	ActionAdded    Action = "added"
+	ActionModified Action = "modified"
+	ActionMoved    Action = "moved"
+	ActionDeleted  Action = "deleted"
+)
+
+const (
+	// maxEvents is how many events are kept. Older ones are pruned as new ones
+	// are recorded.
+	maxEvents = 10000
+	// reopenInterval is how long opening a file again doesn't count as
+	// another event, so that flicking through previews doesn't flood the feed.
+	reopenInterval = time.Minute
+	// DefaultLimit is how many events or recent files are listed by default.
+	DefaultLimit = 50
+	// MaxLimit is the most events or recent files listed at once.
+	MaxLimit = 500
+)
+
+// Event is one thing that happened to a file or folder. Its paths start with
+// a slash, like those of the rest of the API.
+type Event struct {
+	ID     int64  `json:"id"`
+	Action Action `json:"action"`
+	Path   string `json:"path"`
+	// OldPath is where moved files and folders came from.
+	OldPath string    `json:"oldPath,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+func fromRow(row db.Activity) Event {
+	event := Event{
+		ID:     row.ID,
+		Action: Action(row.Action),
+		Path:   "/" + row.Path,
+		Time:   row.CreatedAt,
+	}
+	if row.OldPath != "" {
+		event.OldPath = "/" + row.OldPath
+	}
+	return event
+}
+
+// Record adds an event for the file or folder at filePath. Failures are only
+// logged, since they shouldn't get in the way of what happened.
+func Record(action Action, filePath string) {
+	record(action, filePath, "")
+}
+
+// RecordMove adds an event for a file or folder moved from oldPath to newPath.
+func RecordMove(oldPath string, newPath string) {
+	oldLocal, ok := clean(oldPath)
+	if !ok {
+		return
+	}
+	record(ActionMoved, newPath, oldLocal)
+}
+
+func record(action Action, filePath string, oldLocal string) {
+	local, ok := clean(filePath)
+	if !ok {
+		return
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	if action == ActionOpened {
+		latest, err := db.DatabaseQueries.GetLatestActivity(ctx)
+		if err == nil && Action(latest.Action) == action && latest.Path == local && now.Sub(latest.CreatedAt) < reopenInterval {
+			return
+		}
+	}
+	row, err := db.DatabaseQueries.CreateActivity(ctx, db.CreateActivityParams{
+		Action:    string(action),
+		Path:      local,
+		OldPath:   oldLocal,
+		CreatedAt: now,
+	})
+	if err != nil {
+		fmt.Printf("Error recording activity on %s: %v\n", local, err)
+		return
+	}
+	// Pruning every so often is plenty to keep the table from growing forever
+	if row.ID%100 == 0 && row.ID > maxEvents {
+		if err := db.DatabaseQueries.PruneActivity(ctx, row.ID-maxEvents); err != nil {
+			fmt.Printf("Error pruning activity: %v\n", err)
+		}
+	}
+}
+
+// List returns up to limit events, newest first, from before the event with
+// the ID before. A before of 0 starts from the newest event.
+func List(before int64, limit int) ([]Event, error) {
+	if before <= 0 {
+		before = math.MaxInt64
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	rows, err := db.DatabaseQueries.ListActivity(context.Background(), db.ListActivityParams{
+		ID:    before,
+		Limit: int64(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list activity: %w", err)
+	}
+	events := make([]Event, len(rows))
+	for i, row := range rows {
+		events[i] = fromRow(row)
+	}
+	return events, nil
+}
+
+// RecentPaths returns the paths of up to limit files and folders that were
+// opened or changed most recently, newest first. Ones that are gone are left
+// out.
+func RecentPaths(limit int) ([]string, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	root := fileutil.GetFilesRoot()
+	var paths []string
+	before := int64(math.MaxInt64)
+	for len(paths) < limit {
+		rows, err := db.DatabaseQueries.ListRecentPaths(context.Background(), db.ListRecentPathsParams{
+			Before: before,
+			Limit:  int64(limit),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list recent files: %w", err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			if _, err := root.Stat(row.Path); err == nil && len(paths) < limit {
+				paths = append(paths, row.Path)
+			}
+			before = row.LastID
+		}
+	}
+	return paths, nil
+}
+
+// clean returns the path of a file or folder to record activity on, which is
+// anything but the files root itself.
+func clean(filePath string) (string, bool) {
+	local, err := fileutil.GetFilesRoot().Clean(filePath)
+	if err != nil || local == "." {
+		return "", false
+	}
+	return filepath.ToSlash(local), true
+}
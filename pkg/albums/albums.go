@@ -0,0 +1,499 @@
+package albums
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/photos"
+	"autobutler/pkg/thumbnails"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Albums gather photos from anywhere in the files root in an order of their
+// own. Like tags, photos are kept by path relative to the files root, and
+// follow the files as they are moved or deleted through the API. Photos stay
+// where they are when an album is deleted.
+
+const (
+	maxNameLength        = 128
+	maxDescriptionLength = 2000
+)
+
+var (
+	// ErrNotFound is returned for albums that don't exist.
+	ErrNotFound = errors.New("album not found")
+	// ErrInvalid is returned for names, descriptions and photos that can't be
+	// used.
+	ErrInvalid = errors.New("invalid album")
+)
+
+// Album is a named selection of photos. CoverPath is the photo chosen to
+// stand for the album, or its first photo when none was chosen.
+type Album struct {
+	ID                int64     `json:"id"`
+	Name              string    `json:"name"`
+	Description       string    `json:"description"`
+	CoverPath         string    `json:"coverPath"`
+	CoverThumbnailURL string    `json:"coverThumbnailUrl,omitempty"`
+	PhotoCount        int64     `json:"photoCount"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// Changes are what Update changes about an album. Nil fields are left as
+// they are, and an empty CoverPath goes back to the first photo.
+type Changes struct {
+	Name        *string
+	Description *string
+	CoverPath   *string
+}
+
+func fromRow(row db.Album, photoCount int64) Album {
+	album := Album{
+		ID:          row.ID,
+		Name:        row.Name,
+		Description: row.Description,
+		CoverPath:   row.CoverPath,
+		PhotoCount:  photoCount,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+	if album.CoverPath == "" {
+		first, err := db.DatabaseQueries.GetFirstAlbumPhoto(context.Background(), row.ID)
+		if err == nil {
+			album.CoverPath = first
+		}
+	}
+	if album.CoverPath != "" {
+		if info, err := fileutil.GetFilesRoot().Stat(album.CoverPath); err == nil {
+			album.CoverThumbnailURL = thumbnails.URL(album.CoverPath, info, thumbnails.SizeGrid)
+		}
+	}
+	return album
+}
+
+// List returns every album, most recently changed first.
+func List() ([]Album, error) {
+	rows, err := db.DatabaseQueries.ListAlbums(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list albums: %w", err)
+	}
+	list := make([]Album, len(rows))
+	for i, row := range rows {
+		list[i] = fromRow(db.Album{
+			ID:          row.ID,
+			Name:        row.Name,
+			Description: row.Description,
+			CoverPath:   row.CoverPath,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		}, row.PhotoCount)
+	}
+	return list, nil
+}
+
+// Get returns the album with the given ID.
+func Get(id int64) (Album, error) {
+	row, err := db.DatabaseQueries.GetAlbum(context.Background(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Album{}, ErrNotFound
+	}
+	if err != nil {
+		return Album{}, fmt.Errorf("failed to get album: %w", err)
+	}
+	count, err := db.DatabaseQueries.CountAlbumPhotos(context.Background(), id)
+	if err != nil {
+		return Album{}, fmt.Errorf("failed to count album photos: %w", err)
+	}
+	return fromRow(row, count), nil
+}
+
+// Create adds an empty album.
+func Create(name string, description string) (Album, error) {
+	name, err := validName(name)
+	if err != nil {
+		return Album{}, err
+	}
+	if description, err = validDescription(description); err != nil {
+		return Album{}, err
+	}
+	now := time.Now().UTC()
+	row, err := db.DatabaseQueries.CreateAlbum(context.Background(), db.CreateAlbumParams{
+		Name:        name,
+		Description: description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		return Album{}, fmt.Errorf("failed to create album: %w", err)
+	}
+	return fromRow(row, 0), nil
+}
+
+// Update renames an album, describes it or chooses its cover, which has to
+// be one of its photos.
+func Update(id int64, changes Changes) (Album, error) {
+	row, err := db.DatabaseQueries.GetAlbum(context.Background(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Album{}, ErrNotFound
+	}
+	if err != nil {
+		return Album{}, fmt.Errorf("failed to get album: %w", err)
+	}
+	if changes.Name != nil {
+		if row.Name, err = validName(*changes.Name); err != nil {
+			return Album{}, err
+		}
+	}
+	if changes.Description != nil {
+		if row.Description, err = validDescription(*changes.Description); err != nil {
+			return Album{}, err
+		}
+	}
+	if changes.CoverPath != nil {
+		row.CoverPath = ""
+		if *changes.CoverPath != "" {
+			local, err := clean(*changes.CoverPath)
+			if err != nil {
+				return Album{}, err
+			}
+			paths, err := db.DatabaseQueries.ListAlbumPaths(context.Background(), id)
+			if err != nil {
+				return Album{}, fmt.Errorf("failed to list album photos: %w", err)
+			}
+			if !slices.Contains(paths, local) {
+				return Album{}, fmt.Errorf("%w: the cover has to be a photo in the album", ErrInvalid)
+			}
+			row.CoverPath = local
+		}
+	}
+	if _, err := db.DatabaseQueries.UpdateAlbum(context.Background(), db.UpdateAlbumParams{
+		Name:        row.Name,
+		Description: row.Description,
+		CoverPath:   row.CoverPath,
+		UpdatedAt:   time.Now().UTC(),
+		ID:          id,
+	}); err != nil {
+		return Album{}, fmt.Errorf("failed to update album: %w", err)
+	}
+	return Get(id)
+}
+
+// Delete removes an album, leaving its photos where they are.
+func Delete(id int64) error {
+	ctx := context.Background()
+	return db.InTx(ctx, func(q *db.Queries) error {
+		if err := q.DeleteAlbumPhotosForAlbum(ctx, id); err != nil {
+			return fmt.Errorf("failed to empty album: %w", err)
+		}
+		deleted, err := q.DeleteAlbum(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete album: %w", err)
+		}
+		if deleted == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// AddPhotos adds the photos at paths to the end of an album, in the order
+// given. Photos already in the album stay where they are. Nothing is added
+// unless every path is a photo.
+func AddPhotos(id int64, paths []string) error {
+	if _, err := Get(id); err != nil {
+		return err
+	}
+	root := fileutil.GetFilesRoot()
+	locals := make([]string, len(paths))
+	for i, filePath := range paths {
+		local, err := clean(filePath)
+		if err != nil {
+			return err
+		}
+		info, err := root.Stat(local)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || root.DetectFileType(local) != fileutil.FileTypeImage {
+			return fmt.Errorf("%w: %s isn't a photo", ErrInvalid, info.Name())
+		}
+		locals[i] = local
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	return db.InTx(ctx, func(q *db.Queries) error {
+		for _, local := range locals {
+			if err := q.AddAlbumPhoto(ctx, db.AddAlbumPhotoParams{
+				AlbumID: id,
+				Path:    local,
+				AddedAt: now,
+			}); err != nil {
+				return fmt.Errorf("failed to add %s to album: %w", local, err)
+			}
+		}
+		return touch(ctx, q, id)
+	})
+}
+
+// RemovePhotos takes the photos at paths out of an album. If one of them was
+// its cover, the album goes back to its first photo.
+func RemovePhotos(id int64, paths []string) error {
+	row, err := db.DatabaseQueries.GetAlbum(context.Background(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get album: %w", err)
+	}
+	locals := make([]string, len(paths))
+	for i, filePath := range paths {
+		if locals[i], err = clean(filePath); err != nil {
+			return err
+		}
+		if locals[i] == row.CoverPath {
+			row.CoverPath = ""
+		}
+	}
+	ctx := context.Background()
+	return db.InTx(ctx, func(q *db.Queries) error {
+		for _, local := range locals {
+			if err := q.RemoveAlbumPhoto(ctx, db.RemoveAlbumPhotoParams{
+				AlbumID: id,
+				Path:    local,
+			}); err != nil {
+				return fmt.Errorf("failed to remove %s from album: %w", local, err)
+			}
+		}
+		if _, err := q.UpdateAlbum(ctx, db.UpdateAlbumParams{
+			Name:        row.Name,
+			Description: row.Description,
+			CoverPath:   row.CoverPath,
+			UpdatedAt:   time.Now().UTC(),
+			ID:          id,
+		}); err != nil {
+			return fmt.Errorf("failed to update album: %w", err)
+		}
+		return nil
+	})
+}
+
+// Reorder puts the photos at paths first in an album, in the order given.
+// The photos left out follow them in the order they were in.
+func Reorder(id int64, paths []string) error {
+	if _, err := Get(id); err != nil {
+		return err
+	}
+	locals := make([]string, len(paths))
+	for i, filePath := range paths {
+		local, err := clean(filePath)
+		if err != nil {
+			return err
+		}
+		locals[i] = local
+	}
+	ctx := context.Background()
+	// Every position is renumbered, or none
+	return db.InTx(ctx, func(q *db.Queries) error {
+		current, err := q.ListAlbumPaths(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to list album photos: %w", err)
+		}
+		order := make([]string, 0, len(current))
+		for _, local := range locals {
+			if !slices.Contains(current, local) {
+				return fmt.Errorf("%w: %s isn't in the album", ErrInvalid, filepath.Base(local))
+			}
+			if !slices.Contains(order, local) {
+				order = append(order, local)
+			}
+		}
+		for _, local := range current {
+			if !slices.Contains(order, local) {
+				order = append(order, local)
+			}
+		}
+		for position, local := range order {
+			if err := q.SetAlbumPhotoPosition(ctx, db.SetAlbumPhotoPositionParams{
+				Position: int64(position),
+				AlbumID:  id,
+				Path:     local,
+			}); err != nil {
+				return fmt.Errorf("failed to reorder album: %w", err)
+			}
+		}
+		return touch(ctx, q, id)
+	})
+}
+
+// Paths returns the paths of the photos in an album, relative to the files
+// root, in album order.
+func Paths(id int64) ([]string, error) {
+	if _, err := Get(id); err != nil {
+		return nil, err
+	}
+	paths, err := db.DatabaseQueries.ListAlbumPaths(context.Background(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list album photos: %w", err)
+	}
+	return paths, nil
+}
+
+// Photos returns a page of the photos in an album, in album order, starting
+// after cursor, or at the start when it is empty. Photos the catalogue
+// hasn't read yet are left out until it has.
+func Photos(id int64, cursor string, limit int) (photos.Page, error) {
+	if _, err := Get(id); err != nil {
+		return photos.Page{}, err
+	}
+	after := int64(-1)
+	if cursor != "" {
+		position, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			return photos.Page{}, photos.ErrInvalidCursor
+		}
+		after = position
+	}
+	if limit <= 0 {
+		limit = photos.DefaultLimit
+	}
+	limit = min(limit, photos.MaxLimit)
+	// One more than asked for tells whether there is a next page
+	rows, err := db.DatabaseQueries.ListAlbumPhotos(context.Background(), db.ListAlbumPhotosParams{
+		AlbumID:       id,
+		AfterPosition: after,
+		Limit:         int64(limit) + 1,
+	})
+	if err != nil {
+		return photos.Page{}, fmt.Errorf("failed to list album photos: %w", err)
+	}
+	page := photos.Page{Photos: make([]photos.Photo, 0, min(len(rows), limit))}
+	for i, row := range rows {
+		if i == limit {
+			page.NextCursor = strconv.FormatInt(rows[i-1].Position, 10)
+			break
+		}
+		page.Photos = append(page.Photos, photos.FromRow(row.Photo))
+	}
+	return page, nil
+}
+
+// Moved carries the photos of a folder, or a single photo, over from oldPath
+// to newPath in every album, covers included. replaced is what was at
+// newPath before, if anything, which is taken out of the albums.
+func Moved(oldPath string, newPath string, replaced fs.FileInfo) {
+	oldLocal, err := clean(oldPath)
+	if err != nil {
+		return
+	}
+	newLocal, err := clean(newPath)
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	err = db.InTx(ctx, func(q *db.Queries) error {
+		if replaced != nil {
+			if err := forget(ctx, q, newLocal); err != nil {
+				return err
+			}
+		}
+		if err := q.RenameAlbumPhotos(ctx, db.RenameAlbumPhotosParams{
+			NewPath:       newLocal,
+			OldPath:       oldLocal,
+			PrefixPattern: db.PrefixPattern(oldLocal),
+		}); err != nil {
+			return fmt.Errorf("failed to move album photos: %w", err)
+		}
+		if err := q.RenameAlbumCovers(ctx, db.RenameAlbumCoversParams{
+			NewPath:       newLocal,
+			OldPath:       oldLocal,
+			PrefixPattern: db.PrefixPattern(oldLocal),
+		}); err != nil {
+			return fmt.Errorf("failed to move album covers: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("Error moving %s in albums: %v\n", oldLocal, err)
+	}
+}
+
+// Removed takes a photo, or the photos of a folder, out of every album after
+// it was removed.
+func Removed(filePath string) {
+	local, err := clean(filePath)
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	if err := db.InTx(ctx, func(q *db.Queries) error {
+		return forget(ctx, q, local)
+	}); err != nil {
+		fmt.Printf("Error dropping %s from albums: %v\n", local, err)
+	}
+}
+
+// forget takes local and everything below it out of every album, covers
+// included.
+func forget(ctx context.Context, q *db.Queries, local string) error {
+	if err := q.DeleteAlbumPhotosUnder(ctx, db.DeleteAlbumPhotosUnderParams{
+		Path:          local,
+		PrefixPattern: db.PrefixPattern(local),
+	}); err != nil {
+		return fmt.Errorf("failed to drop album photos: %w", err)
+	}
+	if err := q.ClearAlbumCoversUnder(ctx, db.ClearAlbumCoversUnderParams{
+		Path:          local,
+		PrefixPattern: db.PrefixPattern(local),
+	}); err != nil {
+		return fmt.Errorf("failed to drop album covers: %w", err)
+	}
+	return nil
+}
+
+func touch(ctx context.Context, q *db.Queries, id int64) error {
+	if err := q.TouchAlbum(ctx, db.TouchAlbumParams{
+		UpdatedAt: time.Now().UTC(),
+		ID:        id,
+	}); err != nil {
+		return fmt.Errorf("failed to update album: %w", err)
+	}
+	return nil
+}
+
+// clean returns the path of something that can be in an album, which is
+// anything but the files root itself.
+func clean(filePath string) (string, error) {
+	local, err := fileutil.GetFilesRoot().CleanSlash(filePath)
+	if err != nil {
+		return "", err
+	}
+	if local == "." {
+		return "", fmt.Errorf("%w: the files root isn't a photo", ErrInvalid)
+	}
+	return local, nil
+}
+
+func validName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxNameLength {
+		return "", fmt.Errorf("%w: names must be 1 to %d characters", ErrInvalid, maxNameLength)
+	}
+	return name, nil
+}
+
+func validDescription(description string) (string, error) {
+	description = strings.TrimSpace(description)
+	if len(description) > maxDescriptionLength {
+		return "", fmt.Errorf("%w: descriptions can be at most %d characters", ErrInvalid, maxDescriptionLength)
+	}
+	return description, nil
+}
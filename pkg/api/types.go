@@ -1,6 +1,11 @@
 package api
 
-import "net/http"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/a-h/templ"
+)
 
 type ContentType string
 
@@ -9,13 +14,39 @@ const (
 	ContentTypeJSON ContentType = "application/json"
 )
 
+// Response is what a route answers with. Routes with ContentTypeHTML answer
+// JSON clients with Data, and everyone else with Component, or Data if there
+// is no component. Routes with ContentTypeJSON always answer with JSON.
 type Response struct {
 	StatusCode  int
 	Data        any
+	Component   templ.Component
 	Error       error
+	ErrorCode   string
 	ContentType ContentType
 }
 
+// ErrorEnvelope is how errors are sent to JSON clients.
+type ErrorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	// Code is a stable, machine-readable name for the kind of error, like
+	// "not_found".
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Details holds what else clients need to act on the error, if anything.
+	Details any `json:"details,omitempty"`
+}
+
+// DetailedError is implemented by errors that carry more for JSON clients
+// than their message.
+type DetailedError interface {
+	error
+	ErrorDetails() any
+}
+
 func Ok() *Response {
 	return NewResponse().WithContentType(ContentTypeHTML).WithStatusCode(http.StatusOK)
 }
@@ -39,7 +70,72 @@ func (r *Response) WithData(data any) *Response {
 	return r
 }
 
+// WithComponent sets the component HTML clients get, for routes answering
+// JSON clients with Data.
+func (r *Response) WithComponent(component templ.Component) *Response {
+	r.Component = component
+	return r
+}
+
 func (r *Response) WithError(err error) *Response {
 	r.Error = err
 	return r
 }
+
+// WithErrorCode overrides the error code derived from the status code.
+func (r *Response) WithErrorCode(code string) *Response {
+	r.ErrorCode = code
+	return r
+}
+
+// Envelope returns the error of the response as it's sent to JSON clients.
+func (r *Response) Envelope() ErrorEnvelope {
+	code := r.ErrorCode
+	if code == "" {
+		code = ErrorCode(r.StatusCode)
+	}
+	body := ErrorBody{Code: code, Message: r.Error.Error()}
+	var detailed DetailedError
+	if errors.As(r.Error, &detailed) {
+		body.Details = detailed.ErrorDetails()
+	}
+	return ErrorEnvelope{Error: body}
+}
+
+// ErrorCode returns the default error code for an HTTP status code.
+func ErrorCode(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusGone:
+		return "gone"
+	case http.StatusPreconditionFailed:
+		return "precondition_failed"
+	case http.StatusRequestEntityTooLarge:
+		return "too_large"
+	case http.StatusUnsupportedMediaType:
+		return "unsupported_media_type"
+	case http.StatusRequestedRangeNotSatisfiable:
+		return "range_not_satisfiable"
+	case http.StatusUnprocessableEntity:
+		return "unprocessable"
+	case http.StatusLocked:
+		return "locked"
+	case http.StatusPreconditionRequired:
+		return "precondition_required"
+	case http.StatusInsufficientStorage:
+		return "insufficient_storage"
+	}
+	if statusCode >= 500 {
+		return "internal"
+	}
+	return "error"
+}
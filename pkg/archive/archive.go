@@ -0,0 +1,253 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"autobutler/pkg/util/fileutil"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Archives are read straight from the files root, without extracting them
+// anywhere first. Tarballs can only be read front to back, so every operation
+// on them streams through the whole archive.
+
+type format int
+
+const (
+	formatZip format = iota
+	formatTar
+	formatTarGzip
+	formatTarZstd
+)
+
+// maxEntries caps the number of entries read from a single archive.
+const maxEntries = 100_000
+
+var (
+	ErrUnsupported   = errors.New("unsupported archive format")
+	ErrEntryNotFound = errors.New("archive entry not found")
+	ErrTooManyFiles  = fmt.Errorf("archive has more than %d entries", maxEntries)
+)
+
+// Entry is a file or folder inside an archive. Name is its cleaned,
+// slash-separated path within the archive.
+type Entry struct {
+	Name      string    `json:"name"`
+	IsDir     bool      `json:"isDir"`
+	SizeBytes int64     `json:"sizeBytes"`
+	ModTime   time.Time `json:"modTime"`
+	mode      fs.FileMode
+	// compressedBytes is the stored size of a zip entry, or 0 when the
+	// archive is compressed as a whole.
+	compressedBytes int64
+}
+
+// Supported reports whether the archive at filePath can be read, judging by
+// its name.
+func Supported(filePath string) bool {
+	_, err := formatOf(filePath)
+	return err == nil
+}
+
+// Stem returns the name of an archive without its extension, like "photos"
+// for "photos.tar.gz".
+func Stem(filePath string) string {
+	name := path.Base(strings.ReplaceAll(filePath, `\`, "/"))
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tar.zst", ".tgz", ".tzst", ".tar", ".zip"} {
+		if strings.HasSuffix(lower, ext) && len(name) > len(ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// List returns every entry of the archive at filePath.
+func List(filePath string) ([]Entry, error) {
+	var entries []Entry
+	err := walk(filePath, func(entry Entry, _ func() (io.Reader, error)) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// ReadEntry calls read with the content of the file called name inside the
+// archive at filePath.
+func ReadEntry(filePath string, name string, read func(entry Entry, content io.Reader) error) error {
+	name = cleanName(name)
+	found := false
+	err := walk(filePath, func(entry Entry, open func() (io.Reader, error)) error {
+		if entry.Name != name || entry.IsDir || !entry.mode.IsRegular() {
+			return nil
+		}
+		found = true
+		content, err := open()
+		if err != nil {
+			return err
+		}
+		if err := read(entry, content); err != nil {
+			return err
+		}
+		return errStop
+	})
+	if errors.Is(err, errStop) {
+		return nil
+	}
+	if err == nil && !found {
+		return fmt.Errorf("%s: %w", name, ErrEntryNotFound)
+	}
+	return err
+}
+
+// errStop ends a walk early without failing it.
+var errStop = errors.New("stop walking")
+
+// walk calls fn for every entry of the archive at filePath in order. open
+// returns the content of the entry, and is only valid until fn returns.
+func walk(filePath string, fn func(entry Entry, open func() (io.Reader, error)) error) error {
+	archiveFormat, err := formatOf(filePath)
+	if err != nil {
+		return err
+	}
+	file, err := fileutil.GetFilesRoot().Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if archiveFormat == formatZip {
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		reader, err := zip.NewReader(file, info.Size())
+		if err != nil {
+			return err
+		}
+		return walkZip(reader, fn)
+	}
+	var stream io.Reader = file
+	switch archiveFormat {
+	case formatTarGzip:
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		stream = gzipReader
+	case formatTarZstd:
+		zstdReader, err := zstd.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer zstdReader.Close()
+		stream = zstdReader
+	}
+	return walkTar(tar.NewReader(stream), fn)
+}
+
+func walkZip(reader *zip.Reader, fn func(entry Entry, open func() (io.Reader, error)) error) error {
+	if len(reader.File) > maxEntries {
+		return ErrTooManyFiles
+	}
+	for _, file := range reader.File {
+		entry := Entry{
+			Name:            cleanName(file.Name),
+			IsDir:           file.FileInfo().IsDir(),
+			SizeBytes:       int64(file.UncompressedSize64),
+			ModTime:         file.Modified,
+			mode:            file.Mode(),
+			compressedBytes: int64(file.CompressedSize64),
+		}
+		if entry.Name == "" {
+			continue
+		}
+		var content io.ReadCloser
+		open := func() (io.Reader, error) {
+			var err error
+			content, err = file.Open()
+			return content, err
+		}
+		err := fn(entry, open)
+		if content != nil {
+			content.Close()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkTar(reader *tar.Reader, fn func(entry Entry, open func() (io.Reader, error)) error) error {
+	for count := 0; ; count++ {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if count >= maxEntries {
+			return ErrTooManyFiles
+		}
+		info := header.FileInfo()
+		entry := Entry{
+			Name:      cleanName(header.Name),
+			IsDir:     info.IsDir(),
+			SizeBytes: info.Size(),
+			ModTime:   header.ModTime,
+			mode:      info.Mode(),
+		}
+		if entry.IsDir {
+			entry.SizeBytes = 0
+		}
+		if entry.Name == "" {
+			continue
+		}
+		if err := fn(entry, func() (io.Reader, error) { return reader, nil }); err != nil {
+			return err
+		}
+	}
+}
+
+func formatOf(filePath string) (format, error) {
+	name := strings.ToLower(path.Base(strings.ReplaceAll(filePath, `\`, "/")))
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return formatZip, nil
+	case strings.HasSuffix(name, ".tar"):
+		return formatTar, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return formatTarGzip, nil
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return formatTarZstd, nil
+	default:
+		return 0, fmt.Errorf("%s: %w", path.Base(name), ErrUnsupported)
+	}
+}
+
+// cleanName normalises the path of an entry. Paths that would leave the
+// archive, like "../x" or "/etc/x", keep their ".." and leading "/" so they
+// can be told apart and refused.
+func cleanName(name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+	leading := strings.HasPrefix(name, "/")
+	name = path.Clean(name)
+	if name == "." || name == "/" {
+		return ""
+	}
+	if leading {
+		return name
+	}
+	return strings.TrimPrefix(name, "./")
+}
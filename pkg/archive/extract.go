@@ -0,0 +1,317 @@
+package archive
+
+import (
+	"autobutler/pkg/jobs"
+	"autobutler/pkg/quotas"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// maxExtractedBytes caps the total size of an extraction.
+	maxExtractedBytes = 64 << 30
+	// maxRatio caps how much larger than its compressed size an archive, or
+	// a zip entry, may expand to. Anything up to ratioGraceBytes is let
+	// through, since small files of zeroes compress very well legitimately.
+	maxRatio        = 200
+	ratioGraceBytes = 16 << 20
+)
+
+var (
+	ErrTooLarge = errors.New("archive is too large to extract")
+	// ErrUnsafePath is returned for archives with entries that would end up
+	// outside of the destination, like "../x" or "/etc/x".
+	ErrUnsafePath = errors.New("archive entry escapes the destination")
+)
+
+// Extract unpacks the entries of the archive at filePath called names, and
+// everything below those that are folders, into destDir. An empty names
+// extracts everything. destDir is created if needed, and entries colliding
+// with existing files at the top level are given the next free name. Links
+// and special files are skipped.
+//
+// Nothing is left behind when extraction fails or ctx is canceled. The paths
+// created directly inside destDir are returned, relative to the files root
+// and starting with a "/".
+func Extract(ctx context.Context, filePath string, names []string, destDir string, progress *jobs.Progress) ([]string, error) {
+	root := fileutil.GetFilesRoot()
+	destLocal, err := root.Clean(destDir)
+	if err != nil {
+		return nil, err
+	}
+	selected, items, totalBytes, err := plan(filePath, names, destLocal)
+	if err != nil {
+		return nil, err
+	}
+	progress.SetTotals(items, totalBytes)
+
+	createdDest := false
+	if _, err := root.Lstat(destLocal); errors.Is(err, fs.ErrNotExist) {
+		if err := root.MkdirAll(destLocal, 0o755); err != nil {
+			return nil, err
+		}
+		createdDest = true
+	} else if err != nil {
+		return nil, err
+	}
+
+	extraction := &extraction{
+		ctx:      ctx,
+		root:     root,
+		dest:     destLocal,
+		progress: progress,
+		renamed:  map[string]string{},
+		dirTimes: map[string]time.Time{},
+	}
+	defer func() {
+		for _, release := range extraction.releases {
+			release()
+		}
+	}()
+	err = walk(filePath, func(entry Entry, open func() (io.Reader, error)) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, ok := selected[entry.Name]; !ok {
+			return nil
+		}
+		return extraction.entry(entry, open)
+	})
+	if err == nil {
+		err = extraction.restoreDirTimes()
+	}
+	if err != nil {
+		extraction.cleanUp(createdDest)
+		return nil, err
+	}
+	if createdDest {
+		return []string{display(destLocal)}, nil
+	}
+	created := make([]string, 0, len(extraction.created))
+	for _, local := range extraction.created {
+		created = append(created, display(local))
+	}
+	return created, nil
+}
+
+// plan checks the selected entries of an archive before anything is written,
+// including that they fit into destLocal, returning the names of the entries
+// to extract along with their number and total size.
+func plan(filePath string, names []string, destLocal string) (map[string]struct{}, int64, int64, error) {
+	entries, err := List(filePath)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name = cleanName(name); name != "" {
+			wanted[name] = false
+		}
+	}
+	selected := map[string]struct{}{}
+	var items, files, totalBytes int64
+	for _, entry := range entries {
+		if !isSelected(entry.Name, wanted) {
+			continue
+		}
+		if !filepath.IsLocal(filepath.FromSlash(entry.Name)) {
+			return nil, 0, 0, fmt.Errorf("%s: %w", entry.Name, ErrUnsafePath)
+		}
+		if !entry.IsDir && !entry.mode.IsRegular() {
+			continue
+		}
+		if entry.compressedBytes > 0 && entry.SizeBytes > ratioGraceBytes && entry.SizeBytes/entry.compressedBytes > maxRatio {
+			return nil, 0, 0, fmt.Errorf("%s expands more than %d times: %w", entry.Name, maxRatio, ErrTooLarge)
+		}
+		selected[entry.Name] = struct{}{}
+		items++
+		if !entry.IsDir {
+			files++
+		}
+		totalBytes += entry.SizeBytes
+	}
+	for name, found := range wanted {
+		if !found {
+			return nil, 0, 0, fmt.Errorf("%s: %w", name, ErrEntryNotFound)
+		}
+	}
+	if totalBytes > maxExtractedBytes {
+		return nil, 0, 0, fmt.Errorf("%s of content: %w", fileutil.SizeBytesToString(totalBytes), ErrTooLarge)
+	}
+	info, err := fileutil.GetFilesRoot().Stat(filePath)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if compressed := info.Size(); totalBytes > ratioGraceBytes && compressed > 0 && totalBytes/compressed > maxRatio {
+		return nil, 0, 0, fmt.Errorf("archive expands more than %d times: %w", maxRatio, ErrTooLarge)
+	}
+	if err := quotas.Check(destLocal, totalBytes, files); err != nil {
+		return nil, 0, 0, err
+	}
+	return selected, items, totalBytes, nil
+}
+
+// isSelected reports whether name is wanted, or is below a wanted folder,
+// marking what it matched as found.
+func isSelected(name string, wanted map[string]bool) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	for dir := name; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if _, ok := wanted[dir]; ok {
+			wanted[dir] = true
+			return true
+		}
+	}
+	return false
+}
+
+type extraction struct {
+	ctx      context.Context
+	root     *fileutil.Root
+	dest     string
+	progress *jobs.Progress
+	// renamed maps the top level names in the archive to the free names they
+	// are extracted under.
+	renamed map[string]string
+	// releases gives up the free names once extraction is over.
+	releases []func()
+	created  []string
+	dirTimes map[string]time.Time
+}
+
+func (e *extraction) entry(entry Entry, open func() (io.Reader, error)) error {
+	target, err := e.target(entry.Name)
+	if err != nil {
+		return err
+	}
+	if entry.IsDir {
+		if err := e.root.MkdirAll(target, 0o755); err != nil {
+			return err
+		}
+		e.dirTimes[target] = entry.ModTime
+		e.progress.AddItems(1)
+		return nil
+	}
+	if err := e.root.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	content, err := open()
+	if err != nil {
+		return err
+	}
+	out, err := e.root.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, entry.mode.Perm()|0o600)
+	if err != nil {
+		return err
+	}
+	// Never write more than the entry declared, which the size checks were
+	// based on.
+	written, err := io.Copy(out, &progressReader{ctx: e.ctx, reader: io.LimitReader(content, entry.SizeBytes+1), progress: e.progress})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", entry.Name, err)
+	}
+	if written > entry.SizeBytes {
+		return fmt.Errorf("%s is larger than it claims: %w", entry.Name, ErrTooLarge)
+	}
+	if err := e.chtimes(target, entry.ModTime); err != nil {
+		return err
+	}
+	e.progress.AddItems(1)
+	return nil
+}
+
+// target returns where an entry is extracted to, relative to the files root.
+func (e *extraction) target(name string) (string, error) {
+	top, rest, _ := strings.Cut(name, "/")
+	renamed, ok := e.renamed[top]
+	if !ok {
+		available, release, err := e.root.AvailablePath(filepath.Join(e.dest, top))
+		if err != nil {
+			return "", err
+		}
+		e.releases = append(e.releases, release)
+		renamed = filepath.Base(available)
+		e.renamed[top] = renamed
+		e.created = append(e.created, available)
+	}
+	return e.root.Join(e.dest, renamed, filepath.FromSlash(rest))
+}
+
+// restoreDirTimes sets the modification times of folders, deepest first,
+// since they change while the folders are filled.
+func (e *extraction) restoreDirTimes() error {
+	dirs := make([]string, 0, len(e.dirTimes))
+	for dir := range e.dirTimes {
+		dirs = append(dirs, dir)
+	}
+	slices.SortFunc(dirs, func(a, b string) int {
+		return strings.Count(b, string(filepath.Separator)) - strings.Count(a, string(filepath.Separator))
+	})
+	for _, dir := range dirs {
+		if err := e.chtimes(dir, e.dirTimes[dir]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *extraction) chtimes(name string, modTime time.Time) error {
+	if modTime.IsZero() {
+		return nil
+	}
+	fullPath, err := e.root.Abs(name)
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(fullPath, modTime, modTime)
+}
+
+func (e *extraction) cleanUp(createdDest bool) {
+	if createdDest {
+		if err := e.root.RemoveAll(e.dest); err != nil {
+			fmt.Printf("Failed to remove partial extraction %s: %v\n", e.dest, err)
+		}
+		return
+	}
+	for _, created := range e.created {
+		if err := e.root.RemoveAll(created); err != nil {
+			fmt.Printf("Failed to remove partial extraction %s: %v\n", created, err)
+		}
+	}
+}
+
+// progressReader stops reading once ctx is canceled, and reports every read
+// as processed bytes.
+type progressReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	progress *jobs.Progress
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.progress.AddBytes(int64(n))
+	}
+	return n, err
+}
+
+func display(local string) string {
+	return "/" + filepath.ToSlash(local)
+}
@@ -3,14 +3,14 @@ package calendar
 import "time"
 
 type CalendarEvent struct {
-	ID          int64
-	Title       string
-	Description string
-	StartTime   time.Time
-	EndTime     *time.Time
-	AllDay      bool
-	Location    string
-	CalendarID  int64
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	StartTime   time.Time  `json:"startTime"`
+	EndTime     *time.Time `json:"endTime,omitempty"`
+	AllDay      bool       `json:"allDay"`
+	Location    string     `json:"location"`
+	CalendarID  int64      `json:"calendarId"`
 }
 
 type EventMap map[int][]*CalendarEvent
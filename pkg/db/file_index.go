@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// The file_index FTS5 table can't be described to sqlc, so its queries are
+// written by hand. Its rowids are the IDs of the matching indexed_files rows.
+
+const (
+	// HighlightStart and HighlightEnd surround matched terms in search
+	// results. They are control characters so they can't clash with file
+	// content, and are left for the caller to turn into markup.
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+type FileIndexSearchParams struct {
+	// Match is an FTS5 query expression.
+	Match     string
+	FileTypes []string
+	Limit     int
+	Offset    int
+}
+
+type FileIndexSearchResult struct {
+	IndexedFile
+	NameHighlight string
+	Snippet       string
+}
+
+// SetFileIndexContent replaces the indexed text of an indexed file.
+func (q *Queries) SetFileIndexContent(ctx context.Context, id int64, name string, path string, content string) error {
+	if _, err := q.db.ExecContext(ctx, "DELETE FROM file_index WHERE rowid = ?", id); err != nil {
+		return err
+	}
+	_, err := q.db.ExecContext(ctx, "INSERT INTO file_index (rowid, name, path, content) VALUES (?, ?, ?, ?)", id, name, path, content)
+	return err
+}
+
+// UpdateFileIndexPath updates the name and path of an indexed file, keeping
+// its content.
+func (q *Queries) UpdateFileIndexPath(ctx context.Context, id int64, name string, path string) error {
+	_, err := q.db.ExecContext(ctx, "UPDATE file_index SET name = ?, path = ? WHERE rowid = ?", name, path, id)
+	return err
+}
+
+func (q *Queries) DeleteFileIndexContent(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, "DELETE FROM file_index WHERE rowid = ?", id)
+	return err
+}
+
+// SearchFileIndex runs a full-text search, ranking name matches above path
+// and content matches.
+func (q *Queries) SearchFileIndex(ctx context.Context, params FileIndexSearchParams) ([]FileIndexSearchResult, error) {
+	query := `SELECT
+    indexed_files.id, indexed_files.path, indexed_files.name, indexed_files.file_type, indexed_files.size_bytes, indexed_files.mod_time,
+    highlight(file_index, 0, ?, ?),
+    snippet(file_index, 2, ?, ?, '…', 16)
+FROM
+    file_index
+    JOIN indexed_files ON indexed_files.id = file_index.rowid
+WHERE
+    file_index MATCH ?`
+	args := []any{HighlightStart, HighlightEnd, HighlightStart, HighlightEnd, params.Match}
+	if len(params.FileTypes) > 0 {
+		query += fmt.Sprintf("\n    AND indexed_files.file_type IN (%s)", strings.TrimSuffix(strings.Repeat("?, ", len(params.FileTypes)), ", "))
+		for _, fileType := range params.FileTypes {
+			args = append(args, fileType)
+		}
+	}
+	query += `
+ORDER BY
+    bm25(file_index, 10.0, 2.0, 1.0)
+LIMIT
+    ?
+OFFSET
+    ?`
+	args = append(args, params.Limit, params.Offset)
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error searching file index: %w", err)
+	}
+	defer rows.Close()
+	var results []FileIndexSearchResult
+	for rows.Next() {
+		var r FileIndexSearchResult
+		if err := rows.Scan(
+			&r.ID,
+			&r.Path,
+			&r.Name,
+			&r.FileType,
+			&r.SizeBytes,
+			&r.ModTime,
+			&r.NameHighlight,
+			&r.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
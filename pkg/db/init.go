@@ -2,11 +2,11 @@ package db
 
 import (
 	"autobutler/pkg/util/fileutil"
-	"context"
 	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 var DatabaseQueries *Queries
@@ -21,15 +21,21 @@ func init() {
 	dataFilePath := filepath.Join(dataDir, "autobutler.db")
 	healthFilePath := filepath.Join(dataDir, "autobutler.health.db")
 
-	Instance.Db, err = sql.Open("sqlite", dataFilePath)
+	// Queries run on any connection of the pool, transactions included. In
+	// WAL mode writers don't hold up readers, and transactions take the write
+	// lock up front, so that connections wait for each other's writes rather
+	// than failing. LIKE ignores ASCII case by default, so the patterns
+	// matching everything below /A would match everything below /a as well.
+	Instance.Db, err = sql.Open("sqlite", dataFilePath+"?"+strings.Join([]string{
+		"_pragma=busy_timeout(5000)",
+		"_pragma=journal_mode(WAL)",
+		"_pragma=case_sensitive_like(1)",
+		"_txlock=immediate",
+	}, "&"))
 	if err != nil {
 		panic(fmt.Sprintf("failed to open database: %v", err))
 	}
-	sqlConn, err := Instance.Db.Conn(context.Background())
-	if err != nil {
-		panic(fmt.Sprintf("failed to get database connection: %v", err))
-	}
-	DatabaseQueries = New(sqlConn)
+	DatabaseQueries = New(Instance.Db)
 
 	if err := initSchema(); err != nil {
 		panic(fmt.Sprintf("failed to initialize database schema: %v", err))
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS trash_items_deleted_at;
+
+DROP TABLE IF EXISTS trash_items;
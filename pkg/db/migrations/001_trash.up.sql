@@ -0,0 +1,11 @@
+CREATE TABLE
+    IF NOT EXISTS trash_items (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        original_path TEXT NOT NULL,
+        trash_name TEXT NOT NULL UNIQUE,
+        is_dir BOOLEAN NOT NULL DEFAULT 0,
+        size_bytes INTEGER NOT NULL DEFAULT 0,
+        deleted_at DATETIME NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS trash_items_deleted_at ON trash_items (deleted_at);
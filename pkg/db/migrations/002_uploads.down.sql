@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS uploads_expires_at;
+
+DROP TABLE IF EXISTS uploads;
@@ -0,0 +1,12 @@
+CREATE TABLE
+    IF NOT EXISTS uploads (
+        id TEXT PRIMARY KEY,
+        file_name TEXT NOT NULL,
+        root_dir TEXT NOT NULL,
+        size_bytes INTEGER NOT NULL,
+        metadata TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL,
+        expires_at DATETIME NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS uploads_expires_at ON uploads (expires_at);
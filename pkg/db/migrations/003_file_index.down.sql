@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS file_index;
+
+DROP INDEX IF EXISTS indexed_files_file_type;
+
+DROP TABLE IF EXISTS indexed_files;
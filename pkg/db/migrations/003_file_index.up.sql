@@ -0,0 +1,19 @@
+CREATE TABLE
+    IF NOT EXISTS indexed_files (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        path TEXT NOT NULL UNIQUE,
+        name TEXT NOT NULL,
+        file_type TEXT NOT NULL,
+        size_bytes INTEGER NOT NULL DEFAULT 0,
+        mod_time DATETIME NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS indexed_files_file_type ON indexed_files (file_type);
+
+-- Full-text index over indexed_files, sharing its rowids
+CREATE VIRTUAL TABLE IF NOT EXISTS file_index USING fts5 (
+    name,
+    path,
+    content,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
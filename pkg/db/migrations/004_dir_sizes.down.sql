@@ -0,0 +1 @@
+DROP TABLE IF EXISTS dir_sizes;
@@ -0,0 +1,6 @@
+CREATE TABLE
+    IF NOT EXISTS dir_sizes (
+        path TEXT PRIMARY KEY,
+        size_bytes INTEGER NOT NULL,
+        mod_time DATETIME NOT NULL
+    );
@@ -0,0 +1,18 @@
+CREATE TABLE
+    IF NOT EXISTS jobs (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        kind TEXT NOT NULL,
+        description TEXT NOT NULL,
+        status TEXT NOT NULL,
+        items_done INTEGER NOT NULL DEFAULT 0,
+        items_total INTEGER NOT NULL DEFAULT 0,
+        bytes_done INTEGER NOT NULL DEFAULT 0,
+        bytes_total INTEGER NOT NULL DEFAULT 0,
+        result TEXT NOT NULL DEFAULT '',
+        error TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL,
+        started_at DATETIME,
+        finished_at DATETIME
+    );
+
+CREATE INDEX IF NOT EXISTS jobs_finished_at ON jobs (finished_at);
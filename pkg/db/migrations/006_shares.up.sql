@@ -0,0 +1,12 @@
+CREATE TABLE
+    IF NOT EXISTS shares (
+        id TEXT PRIMARY KEY,
+        path TEXT NOT NULL,
+        password_hash TEXT NOT NULL DEFAULT '',
+        max_downloads INTEGER NOT NULL DEFAULT 0,
+        downloads INTEGER NOT NULL DEFAULT 0,
+        created_at DATETIME NOT NULL,
+        expires_at DATETIME
+    );
+
+CREATE INDEX IF NOT EXISTS shares_expires_at ON shares (expires_at);
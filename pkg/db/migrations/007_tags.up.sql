@@ -0,0 +1,17 @@
+CREATE TABLE
+    IF NOT EXISTS tags (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+        color TEXT NOT NULL,
+        created_at DATETIME NOT NULL
+    );
+
+-- Tags of files and folders, by their path relative to the files root
+CREATE TABLE
+    IF NOT EXISTS file_tags (
+        path TEXT NOT NULL,
+        tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+        PRIMARY KEY (path, tag_id)
+    );
+
+CREATE INDEX IF NOT EXISTS file_tags_tag_id ON file_tags (tag_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stars;
+
+DROP TABLE IF EXISTS activity;
@@ -0,0 +1,18 @@
+-- What happened to files and folders, by their path relative to the files
+-- root. old_path is where moved ones came from.
+CREATE TABLE
+    IF NOT EXISTS activity (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        action TEXT NOT NULL,
+        path TEXT NOT NULL,
+        old_path TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS activity_path ON activity (path);
+
+CREATE TABLE
+    IF NOT EXISTS stars (
+        path TEXT PRIMARY KEY,
+        created_at DATETIME NOT NULL
+    );
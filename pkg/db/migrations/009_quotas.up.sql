@@ -0,0 +1,9 @@
+-- Limits on what top-level folders of the files root may hold. Zero means
+-- no limit.
+CREATE TABLE
+    IF NOT EXISTS quotas (
+        folder TEXT PRIMARY KEY,
+        max_bytes INTEGER NOT NULL DEFAULT 0,
+        max_files INTEGER NOT NULL DEFAULT 0,
+        created_at DATETIME NOT NULL
+    );
@@ -0,0 +1,28 @@
+-- The photo catalogue, by path relative to the files root. taken_local is
+-- the wall clock time the photo was taken where it was taken, as
+-- "YYYY-MM-DDTHH:MM:SS", so that it sorts and groups by date as text.
+-- taken_offset is that place's offset from UTC in minutes, when known.
+-- taken_source is "exif", or "file" when the time is the modification time.
+CREATE TABLE
+    IF NOT EXISTS photos (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        path TEXT NOT NULL UNIQUE,
+        size_bytes INTEGER NOT NULL DEFAULT 0,
+        mod_time DATETIME NOT NULL,
+        taken_local TEXT NOT NULL,
+        taken_offset INTEGER,
+        taken_source TEXT NOT NULL,
+        camera_make TEXT NOT NULL DEFAULT '',
+        camera_model TEXT NOT NULL DEFAULT '',
+        lens_model TEXT NOT NULL DEFAULT '',
+        exposure_time TEXT NOT NULL DEFAULT '',
+        f_number REAL,
+        iso INTEGER,
+        focal_length REAL,
+        width INTEGER NOT NULL DEFAULT 0,
+        height INTEGER NOT NULL DEFAULT 0,
+        latitude REAL,
+        longitude REAL
+    );
+
+CREATE INDEX IF NOT EXISTS photos_taken_local ON photos (taken_local, id);
@@ -0,0 +1,26 @@
+-- Albums gather photos from anywhere in the files root. cover_path is the
+-- photo chosen to stand for the album, or empty for its first photo.
+CREATE TABLE
+    IF NOT EXISTS albums (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        name TEXT NOT NULL,
+        description TEXT NOT NULL DEFAULT '',
+        cover_path TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL,
+        updated_at DATETIME NOT NULL
+    );
+
+-- Photos of albums, by their path relative to the files root, in the order
+-- of position within each album
+CREATE TABLE
+    IF NOT EXISTS album_photos (
+        album_id INTEGER NOT NULL REFERENCES albums (id) ON DELETE CASCADE,
+        path TEXT NOT NULL,
+        position INTEGER NOT NULL,
+        added_at DATETIME NOT NULL,
+        PRIMARY KEY (album_id, path)
+    );
+
+CREATE INDEX IF NOT EXISTS album_photos_position ON album_photos (album_id, position);
+
+CREATE INDEX IF NOT EXISTS album_photos_path ON album_photos (path);
@@ -0,0 +1,18 @@
+-- Hashes of catalogued photos, for finding duplicates. content_hash is the
+-- SHA-256 of the file, and dhash and phash are its perceptual hashes, or
+-- NULL when it can't be decoded. size_bytes and mod_time are those of the
+-- photo when it was hashed. Photos in a cluster_id are duplicates of each
+-- other, and the cluster is named after its lowest photo ID.
+CREATE TABLE
+    IF NOT EXISTS photo_hashes (
+        photo_id INTEGER PRIMARY KEY REFERENCES photos (id) ON DELETE CASCADE,
+        size_bytes INTEGER NOT NULL,
+        mod_time DATETIME NOT NULL,
+        content_hash TEXT NOT NULL,
+        dhash INTEGER,
+        phash INTEGER,
+        cluster_id INTEGER,
+        hashed_at DATETIME NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS photo_hashes_cluster_id ON photo_hashes (cluster_id);
@@ -9,6 +9,30 @@ import (
 	"time"
 )
 
+type Activity struct {
+	ID        int64
+	Action    string
+	Path      string
+	OldPath   string
+	CreatedAt time.Time
+}
+
+type Album struct {
+	ID          int64
+	Name        string
+	Description string
+	CoverPath   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type AlbumPhoto struct {
+	AlbumID  int64
+	Path     string
+	Position int64
+	AddedAt  time.Time
+}
+
 type Calendar struct {
 	ID   int64
 	Name string
@@ -24,3 +48,119 @@ type CalendarEvent struct {
 	Location    string
 	CalendarID  int64
 }
+
+type DirSize struct {
+	Path      string
+	SizeBytes int64
+	ModTime   time.Time
+}
+
+type FileTag struct {
+	Path  string
+	TagID int64
+}
+
+type IndexedFile struct {
+	ID        int64
+	Path      string
+	Name      string
+	FileType  string
+	SizeBytes int64
+	ModTime   time.Time
+}
+
+type Job struct {
+	ID          int64
+	Kind        string
+	Description string
+	Status      string
+	ItemsDone   int64
+	ItemsTotal  int64
+	BytesDone   int64
+	BytesTotal  int64
+	Result      string
+	Error       string
+	CreatedAt   time.Time
+	StartedAt   sql.NullTime
+	FinishedAt  sql.NullTime
+}
+
+type Photo struct {
+	ID           int64
+	Path         string
+	SizeBytes    int64
+	ModTime      time.Time
+	TakenLocal   string
+	TakenOffset  sql.NullInt64
+	TakenSource  string
+	CameraMake   string
+	CameraModel  string
+	LensModel    string
+	ExposureTime string
+	FNumber      sql.NullFloat64
+	Iso          sql.NullInt64
+	FocalLength  sql.NullFloat64
+	Width        int64
+	Height       int64
+	Latitude     sql.NullFloat64
+	Longitude    sql.NullFloat64
+}
+
+type PhotoHash struct {
+	PhotoID     int64
+	SizeBytes   int64
+	ModTime     time.Time
+	ContentHash string
+	Dhash       sql.NullInt64
+	Phash       sql.NullInt64
+	ClusterID   sql.NullInt64
+	HashedAt    time.Time
+}
+
+type Quota struct {
+	Folder    string
+	MaxBytes  int64
+	MaxFiles  int64
+	CreatedAt time.Time
+}
+
+type Share struct {
+	ID           string
+	Path         string
+	PasswordHash string
+	MaxDownloads int64
+	Downloads    int64
+	CreatedAt    time.Time
+	ExpiresAt    sql.NullTime
+}
+
+type Star struct {
+	Path      string
+	CreatedAt time.Time
+}
+
+type Tag struct {
+	ID        int64
+	Name      string
+	Color     string
+	CreatedAt time.Time
+}
+
+type TrashItem struct {
+	ID           int64
+	OriginalPath string
+	TrashName    string
+	IsDir        bool
+	SizeBytes    int64
+	DeletedAt    time.Time
+}
+
+type Upload struct {
+	ID        string
+	FileName  string
+	RootDir   string
+	SizeBytes int64
+	Metadata  string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
@@ -0,0 +1,23 @@
+package db
+
+import "strings"
+
+// Paths below a folder are matched with LIKE patterns, which are case
+// sensitive on the connection DatabaseQueries runs on.
+
+// PrefixPattern is a LIKE pattern matching every path below local, a slash
+// separated path relative to the files root, for queries using ESCAPE '\'.
+// Everything is below the files root itself, ".".
+func PrefixPattern(local string) string {
+	if local == "." {
+		return "%"
+	}
+	return EscapeLike(local) + "/%"
+}
+
+// EscapeLike escapes the LIKE wildcards in s, for queries using ESCAPE '\'.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
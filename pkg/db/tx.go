@@ -0,0 +1,21 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// InTx runs fn in a transaction, which is committed if fn returns nil and
+// rolled back otherwise. fn must only use the Queries it is given, as writes
+// through DatabaseQueries wait for the transaction to finish.
+func InTx(ctx context.Context, fn func(q *Queries) error) error {
+	tx, err := Instance.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(New(tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
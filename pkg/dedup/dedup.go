@@ -0,0 +1,172 @@
+package dedup
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/photos"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned for clusters that don't exist, or no longer
+	// have duplicates in them.
+	ErrNotFound = errors.New("duplicates not found")
+	// ErrInvalid is returned for keeping a photo that isn't in the cluster.
+	ErrInvalid = errors.New("invalid duplicates")
+	// ErrChanged is returned for resolving a cluster whose photos aren't the
+	// ones that were reviewed, as a scan has changed it since.
+	ErrChanged = errors.New("duplicates changed since they were reviewed")
+)
+
+// Kind says how alike the photos of a cluster are.
+type Kind string
+
+const (
+	// KindExact clusters hold copies with the same content.
+	KindExact Kind = "exact"
+	// KindSimilar clusters hold the same picture at different sizes or
+	// qualities.
+	KindSimilar Kind = "similar"
+)
+
+// Copy is one of the photos of a cluster. Best is set on the one worth
+// keeping: the largest resolution, then the largest file.
+type Copy struct {
+	photos.Photo
+	ContentHash string `json:"contentHash"`
+	Best        bool   `json:"best"`
+}
+
+// Cluster is a group of photos that are duplicates of each other, best
+// first. ReclaimableBytes is what trashing all but the best would free.
+type Cluster struct {
+	ID               int64  `json:"id"`
+	Kind             Kind   `json:"kind"`
+	ReclaimableBytes int64  `json:"reclaimableBytes"`
+	Photos           []Copy `json:"photos"`
+}
+
+// List returns every cluster of duplicates found by the last scan, in the
+// order their photos were catalogued. Photos removed since then are left
+// out, and with them clusters that have no duplicates left.
+func List() ([]Cluster, error) {
+	rows, err := db.DatabaseQueries.ListDuplicatePhotos(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list duplicates: %w", err)
+	}
+	var clusters []Cluster
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && rows[end].ClusterID == rows[start].ClusterID {
+			end++
+		}
+		if cluster, ok := newCluster(rows[start].ClusterID.Int64, rows[start:end]); ok {
+			clusters = append(clusters, cluster)
+		}
+		start = end
+	}
+	return clusters, nil
+}
+
+// Get returns the cluster of duplicates with the given ID.
+func Get(id int64) (Cluster, error) {
+	rows, err := db.DatabaseQueries.ListDuplicateCluster(context.Background(), sql.NullInt64{Int64: id, Valid: true})
+	if err != nil {
+		return Cluster{}, fmt.Errorf("failed to list duplicates: %w", err)
+	}
+	cluster, ok := newCluster(id, rows)
+	if !ok {
+		return Cluster{}, ErrNotFound
+	}
+	return cluster, nil
+}
+
+// Others returns the paths of the photos of the cluster other than keep,
+// which are the ones to trash to keep it. reviewed are the paths of the
+// photos the choice was made from, which have to be the photos of the
+// cluster still.
+func (c Cluster) Others(keep string, reviewed []string) ([]string, error) {
+	root := fileutil.GetFilesRoot()
+	local, err := root.Clean(keep)
+	if err != nil {
+		return nil, err
+	}
+	local = filepath.ToSlash(local)
+	reviewedLocals := make([]string, len(reviewed))
+	for i, filePath := range reviewed {
+		reviewedLocal, err := root.Clean(filePath)
+		if err != nil {
+			return nil, err
+		}
+		reviewedLocals[i] = filepath.ToSlash(reviewedLocal)
+	}
+	if !slices.Contains(reviewedLocals, local) {
+		return nil, fmt.Errorf("%w: %s isn't one of the duplicates", ErrInvalid, filepath.Base(local))
+	}
+	paths := make([]string, len(c.Photos))
+	for i, photo := range c.Photos {
+		paths[i] = photo.Path
+	}
+	slices.Sort(paths)
+	slices.Sort(reviewedLocals)
+	if !slices.Equal(paths, slices.Compact(reviewedLocals)) {
+		return nil, ErrChanged
+	}
+	others := make([]string, 0, len(paths)-1)
+	for _, path := range paths {
+		if path != local {
+			others = append(others, path)
+		}
+	}
+	return others, nil
+}
+
+// newCluster describes the rows of a cluster, unless fewer than two are
+// left.
+func newCluster[Row db.ListDuplicatePhotosRow | db.ListDuplicateClusterRow](id int64, rows []Row) (Cluster, bool) {
+	if len(rows) < 2 {
+		return Cluster{}, false
+	}
+	cluster := Cluster{ID: id, Kind: KindExact, Photos: make([]Copy, len(rows))}
+	for i, row := range rows {
+		row := db.ListDuplicatePhotosRow(row)
+		cluster.Photos[i] = Copy{Photo: photos.FromRow(row.Photo), ContentHash: row.ContentHash}
+		if row.ContentHash != cluster.Photos[0].ContentHash {
+			cluster.Kind = KindSimilar
+		}
+	}
+	slices.SortStableFunc(cluster.Photos, compareCopies)
+	cluster.Photos[0].Best = true
+	for _, photo := range cluster.Photos[1:] {
+		cluster.ReclaimableBytes += photo.SizeBytes
+	}
+	return cluster, true
+}
+
+// compareCopies sorts better copies first: larger resolutions, then larger
+// files, which have lost less to recompression, then ones with EXIF dates,
+// then older ones, which are more likely the originals.
+func compareCopies(a Copy, b Copy) int {
+	if pixelsA, pixelsB := a.Width*a.Height, b.Width*b.Height; pixelsA != pixelsB {
+		return int(pixelsB - pixelsA)
+	}
+	if a.SizeBytes != b.SizeBytes {
+		return int(b.SizeBytes - a.SizeBytes)
+	}
+	if exifA, exifB := a.TakenAtSource == photos.SourceExif, b.TakenAtSource == photos.SourceExif; exifA != exifB {
+		if exifA {
+			return -1
+		}
+		return 1
+	}
+	if !a.ModTime.Equal(b.ModTime) {
+		return a.ModTime.Compare(b.ModTime)
+	}
+	return strings.Compare(a.Path, b.Path)
+}
@@ -0,0 +1,212 @@
+package dedup
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/photos"
+	"autobutler/pkg/util/fileutil"
+	"autobutler/pkg/util/imageutil"
+	"autobutler/pkg/util/queueutil"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The scanner hashes every catalogued photo once, and again when it
+// changes, and then clusters the photos that are duplicates of each other.
+// Photos with the same content hash are exact copies. Photos whose
+// perceptual hashes are both close are the same picture, resized or
+// recompressed.
+
+const (
+	// How many bits the perceptual hashes of near duplicates may differ by
+	maxDHashDistance = 10
+	maxPHashDistance = 10
+)
+
+// Scans are run by a single worker, after the photo catalogue changes.
+var queue = queueutil.NewQueue("finding duplicate photos")
+
+var (
+	// pending is set while a scan is waiting to run, so that a burst of
+	// changes is scanned once
+	pending atomic.Bool
+
+	scannedMutex sync.Mutex
+	scannedAt    time.Time
+)
+
+// StartScanner scans for duplicates whenever the photo catalogue changes,
+// which includes its scan at startup.
+func StartScanner() {
+	photos.OnChange(Scan)
+}
+
+// Scan schedules a scan for duplicates, unless one is waiting already.
+func Scan() {
+	if !pending.CompareAndSwap(false, true) {
+		return
+	}
+	queue.Push(func() error {
+		// Changes from here on need another scan
+		pending.Store(false)
+		return scan()
+	})
+}
+
+// ScannedAt returns when the last scan finished, or the zero time when none
+// has yet since startup.
+func ScannedAt() time.Time {
+	scannedMutex.Lock()
+	defer scannedMutex.Unlock()
+	return scannedAt
+}
+
+func scan() error {
+	ctx := context.Background()
+	if err := db.DatabaseQueries.DeleteOrphanPhotoHashes(ctx); err != nil {
+		return fmt.Errorf("failed to drop hashes of removed photos: %w", err)
+	}
+	unhashed, err := db.DatabaseQueries.ListUnhashedPhotos(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list photos to hash: %w", err)
+	}
+	root := fileutil.GetFilesRoot()
+	for _, photo := range unhashed {
+		hashes, err := hashPhoto(root, photo)
+		if err != nil {
+			// Most likely gone since it was catalogued
+			fmt.Printf("Error hashing %s: %v\n", photo.Path, err)
+			continue
+		}
+		if err := db.DatabaseQueries.UpsertPhotoHash(ctx, hashes); err != nil {
+			return fmt.Errorf("failed to store hashes of %s: %w", photo.Path, err)
+		}
+	}
+	if err := cluster(ctx); err != nil {
+		return err
+	}
+	scannedMutex.Lock()
+	scannedAt = time.Now()
+	scannedMutex.Unlock()
+	return nil
+}
+
+// hashPhoto reads the content hash of a photo, and its perceptual hashes if
+// it can be decoded.
+func hashPhoto(root *fileutil.Root, photo db.ListUnhashedPhotosRow) (db.UpsertPhotoHashParams, error) {
+	file, err := root.Open(photo.Path)
+	if err != nil {
+		return db.UpsertPhotoHashParams{}, err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return db.UpsertPhotoHashParams{}, err
+	}
+	hashes := db.UpsertPhotoHashParams{
+		PhotoID:     photo.ID,
+		SizeBytes:   photo.SizeBytes,
+		ModTime:     photo.ModTime,
+		ContentHash: hex.EncodeToString(hash.Sum(nil)),
+		HashedAt:    time.Now().UTC(),
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return db.UpsertPhotoHashParams{}, err
+	}
+	// Formats that can't be decoded, like HEIC, only have exact copies
+	if img, _, err := imageutil.DecodeImage(file); err == nil {
+		// SQLite integers are signed, so the bits are stored as they are
+		hashes.Dhash = sql.NullInt64{Int64: int64(imageutil.DHash(img)), Valid: true}
+		hashes.Phash = sql.NullInt64{Int64: int64(imageutil.PHash(img)), Valid: true}
+	}
+	return hashes, nil
+}
+
+// cluster groups the hashed photos into clusters of duplicates. Each
+// cluster is built around its first photo, and only takes photos near that
+// one, so that a chain of photos that each look like the next never makes
+// a cluster of photos that look nothing alike. Each photo is compared with
+// the first photo of every cluster, which takes a fraction of a second for
+// tens of thousands of photos.
+func cluster(ctx context.Context) error {
+	rows, err := db.DatabaseQueries.ListPhotoHashes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list photo hashes: %w", err)
+	}
+	// firsts[i] is the first photo of the cluster of photo i. The rows are
+	// sorted by photo ID, so clusters are named after their lowest one.
+	firsts := make([]int, len(rows))
+	var clusterFirsts []int
+	byContent := make(map[string]int, len(rows))
+	for i, row := range rows {
+		if copyOf, ok := byContent[row.ContentHash]; ok {
+			firsts[i] = firsts[copyOf]
+			continue
+		}
+		byContent[row.ContentHash] = i
+		firsts[i] = i
+		for _, first := range clusterFirsts {
+			if nearDuplicates(rows[first], row) {
+				firsts[i] = first
+				break
+			}
+		}
+		if firsts[i] == i {
+			clusterFirsts = append(clusterFirsts, i)
+		}
+	}
+	sizes := make(map[int]int)
+	for _, first := range firsts {
+		sizes[first]++
+	}
+
+	// Only photos that moved between clusters are updated, so that the
+	// clusters listed meanwhile are never emptied
+	for i, row := range rows {
+		clusterID := sql.NullInt64{}
+		if first := firsts[i]; sizes[first] >= 2 {
+			clusterID = sql.NullInt64{Int64: rows[first].PhotoID, Valid: true}
+		}
+		if clusterID == row.ClusterID {
+			continue
+		}
+		if err := db.DatabaseQueries.SetPhotoCluster(ctx, db.SetPhotoClusterParams{
+			ClusterID: clusterID,
+			PhotoID:   row.PhotoID,
+		}); err != nil {
+			return fmt.Errorf("failed to store duplicates: %w", err)
+		}
+	}
+	return nil
+}
+
+// nearDuplicates reports whether two photos are the same picture, resized or
+// recompressed.
+func nearDuplicates(a db.ListPhotoHashesRow, b db.ListPhotoHashesRow) bool {
+	if !nearMatchable(a) || !nearMatchable(b) {
+		return false
+	}
+	return imageutil.HashDistance(uint64(a.Phash.Int64), uint64(b.Phash.Int64)) <= maxPHashDistance &&
+		imageutil.HashDistance(uint64(a.Dhash.Int64), uint64(b.Dhash.Int64)) <= maxDHashDistance
+}
+
+// nearMatchable reports whether a photo can be a near duplicate of others.
+// Flat images, like blank scans, hash alike without looking alike. Their
+// hashes are all ones or all zeros, which gradients can give one hash but not
+// both.
+func nearMatchable(row db.ListPhotoHashesRow) bool {
+	if !row.Dhash.Valid || !row.Phash.Valid {
+		return false
+	}
+	return !flatHash(row.Dhash.Int64) || !flatHash(row.Phash.Int64)
+}
+
+func flatHash(hash int64) bool {
+	return hash == 0 || hash == -1
+}
@@ -0,0 +1,352 @@
+package dirsize
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/util/fileutil"
+	"autobutler/pkg/util/queueutil"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sync"
+)
+
+// Folder sizes are cached in the database along with the modification time
+// the folder had when its size was computed. A folder whose modification time
+// has changed since is recomputed in the background, reusing the cached sizes
+// of its subfolders that are still current. Changes made through the files
+// API are applied to the cached sizes of every folder above them directly, so
+// that those don't have to be recomputed.
+//
+// A folder's modification time only changes when entries are added to or
+// removed from it, not when anything deeper down changes. Changes made
+// outside of the API are reported by the file watcher through Changed, which
+// drops the cached sizes of every folder above them.
+
+// All cache updates go through a single worker, so they can't interleave.
+var queue = queueutil.NewQueue("updating folder sizes")
+
+var (
+	pendingMutex sync.Mutex
+	// pending holds the folders that are queued to be recomputed.
+	pending = map[string]bool{}
+)
+
+// Get returns the cached size of the folder at dirPath. When the size isn't
+// cached yet or the folder has changed since, it is recomputed in the
+// background and ok is false.
+func Get(dirPath string) (size int64, ok bool) {
+	local, err := fileutil.GetFilesRoot().CleanSlash(dirPath)
+	if err != nil {
+		return 0, false
+	}
+	if size, ok := cached(local); ok {
+		return size, true
+	}
+	pendingMutex.Lock()
+	defer pendingMutex.Unlock()
+	if !pending[local] {
+		pending[local] = true
+		queue.Push(func() error {
+			pendingMutex.Lock()
+			delete(pending, local)
+			pendingMutex.Unlock()
+			return recompute(local)
+		})
+	}
+	return 0, false
+}
+
+// Cached returns the cached size of the folder at dirPath if it is current,
+// without recomputing it otherwise.
+func Cached(dirPath string) (int64, bool) {
+	local, err := fileutil.GetFilesRoot().CleanSlash(dirPath)
+	if err != nil {
+		return 0, false
+	}
+	return cached(local)
+}
+
+// Added updates the folders above filePath after a file or folder was
+// created there.
+func Added(filePath string) {
+	queue.Push(func() error {
+		local, err := fileutil.GetFilesRoot().CleanSlash(filePath)
+		if err != nil {
+			return err
+		}
+		size, err := sizeOf(local)
+		if err != nil {
+			return invalidateAbove(local)
+		}
+		return grow(local, size)
+	})
+}
+
+// Removed updates the folders above filePath after the file or folder there
+// was removed. info is what filePath was before it was removed, or nil if it
+// is unknown.
+func Removed(filePath string, info fs.FileInfo) {
+	queue.Push(func() error {
+		local, err := fileutil.GetFilesRoot().CleanSlash(filePath)
+		if err != nil {
+			return err
+		}
+		return subtract(local, info)
+	})
+}
+
+// Resized updates the folders above filePath after the file there was
+// overwritten. oldSize is the size it had before.
+func Resized(filePath string, oldSize int64) {
+	queue.Push(func() error {
+		local, err := fileutil.GetFilesRoot().CleanSlash(filePath)
+		if err != nil {
+			return err
+		}
+		info, err := fileutil.GetFilesRoot().Stat(local)
+		if err != nil {
+			return invalidateAbove(local)
+		}
+		return grow(local, info.Size()-oldSize)
+	})
+}
+
+// Changed drops the cached sizes of the folders above filePath, and of
+// filePath itself if it's a folder, after it changed in a way whose size
+// isn't known, like outside of the API. They are recomputed the next time
+// they are asked for.
+func Changed(filePath string) {
+	queue.Push(func() error {
+		local, err := fileutil.GetFilesRoot().CleanSlash(filePath)
+		if err != nil {
+			return err
+		}
+		if err := db.DatabaseQueries.DeleteDirSize(context.Background(), local); err != nil {
+			return fmt.Errorf("failed to invalidate cached folder size for %s: %w", local, err)
+		}
+		return invalidateAbove(local)
+	})
+}
+
+// Moved updates the cached sizes after a file or folder was moved from
+// oldPath to newPath. replaced is what was at newPath before, if anything.
+func Moved(oldPath string, newPath string, replaced fs.FileInfo) {
+	queue.Push(func() error {
+		root := fileutil.GetFilesRoot()
+		oldLocal, err := root.CleanSlash(oldPath)
+		if err != nil {
+			return err
+		}
+		newLocal, err := root.CleanSlash(newPath)
+		if err != nil {
+			return err
+		}
+		if replaced != nil {
+			if err := subtract(newLocal, replaced); err != nil {
+				return err
+			}
+		}
+		if err := db.DatabaseQueries.RenameDirSizes(context.Background(), db.RenameDirSizesParams{
+			NewPath:       newLocal,
+			OldPath:       oldLocal,
+			PrefixPattern: db.PrefixPattern(oldLocal),
+		}); err != nil {
+			return fmt.Errorf("failed to move cached sizes of %s: %w", oldLocal, err)
+		}
+		size, err := sizeOf(newLocal)
+		if err != nil {
+			if err := invalidateAbove(oldLocal); err != nil {
+				return err
+			}
+			return invalidateAbove(newLocal)
+		}
+		if err := grow(oldLocal, -size); err != nil {
+			return err
+		}
+		return grow(newLocal, size)
+	})
+}
+
+// cached returns the cached size of a folder if it is still current.
+func cached(local string) (int64, bool) {
+	entry, err := db.DatabaseQueries.GetDirSize(context.Background(), local)
+	if err != nil {
+		return 0, false
+	}
+	info, err := fileutil.GetFilesRoot().Stat(local)
+	if err != nil || !info.ModTime().UTC().Equal(entry.ModTime) {
+		return 0, false
+	}
+	return entry.SizeBytes, true
+}
+
+// recompute brings the cached size of a folder up to date, and passes the
+// difference on to the folders above it.
+func recompute(local string) error {
+	if _, ok := cached(local); ok {
+		// Computed by an earlier job in the meantime
+		return nil
+	}
+	previous, previousErr := db.DatabaseQueries.GetDirSize(context.Background(), local)
+	size, err := compute(local)
+	if err != nil {
+		return err
+	}
+	if previousErr != nil {
+		if errors.Is(previousErr, sql.ErrNoRows) {
+			// The folders above have no record of this one either
+			return invalidateAbove(local)
+		}
+		return previousErr
+	}
+	return addAbove(local, size-previous.SizeBytes)
+}
+
+// compute walks a folder to total its size, skipping subfolders whose cached
+// size is current and caching the size of every other one on the way.
+func compute(local string) (int64, error) {
+	root := fileutil.GetFilesRoot()
+	info, err := root.Stat(local)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := root.ReadDir(local)
+	if err != nil {
+		return 0, fmt.Errorf("error calculating folder size for %s: %w", local, err)
+	}
+	var size int64
+	for _, entry := range entries {
+		child := path.Join(local, entry.Name())
+		if entry.IsDir() {
+			childSize, ok := cached(child)
+			if !ok {
+				if childSize, err = compute(child); err != nil {
+					return 0, err
+				}
+			}
+			size += childSize
+			continue
+		}
+		childInfo, err := entry.Info()
+		if err != nil {
+			return 0, fmt.Errorf("error calculating folder size for %s: %w", local, err)
+		}
+		size += childInfo.Size()
+	}
+	if err := db.DatabaseQueries.UpsertDirSize(context.Background(), db.UpsertDirSizeParams{
+		Path:      local,
+		SizeBytes: size,
+		ModTime:   info.ModTime().UTC(),
+	}); err != nil {
+		return 0, fmt.Errorf("failed to cache folder size for %s: %w", local, err)
+	}
+	return size, nil
+}
+
+// sizeOf returns the size of a file, or the size of a folder computing it if
+// necessary.
+func sizeOf(local string) (int64, error) {
+	info, err := fileutil.GetFilesRoot().Stat(local)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		return info.Size(), nil
+	}
+	if size, ok := cached(local); ok {
+		return size, nil
+	}
+	return compute(local)
+}
+
+// subtract takes a removed file or folder out of the folders above it. info
+// is what was at local before it was removed, or nil if it is unknown.
+func subtract(local string, info fs.FileInfo) error {
+	size, known := int64(0), false
+	if info != nil && !info.IsDir() {
+		size, known = info.Size(), true
+	} else if info != nil {
+		entry, err := db.DatabaseQueries.GetDirSize(context.Background(), local)
+		size, known = entry.SizeBytes, err == nil
+	}
+	if err := forget(local); err != nil {
+		return err
+	}
+	if !known {
+		return invalidateAbove(local)
+	}
+	return grow(local, -size)
+}
+
+// grow applies a change in size at local to the folders above it. The
+// folder directly above has gained or lost an entry, so its modification time
+// is brought up to date as well to keep it from being recomputed.
+func grow(local string, delta int64) error {
+	if err := addAbove(local, delta); err != nil {
+		return err
+	}
+	if local == "." {
+		return nil
+	}
+	parent := path.Dir(local)
+	info, err := fileutil.GetFilesRoot().Stat(parent)
+	if err != nil {
+		return nil
+	}
+	if err := db.DatabaseQueries.UpdateDirSizeModTime(context.Background(), db.UpdateDirSizeModTimeParams{
+		ModTime: info.ModTime().UTC(),
+		Path:    parent,
+	}); err != nil {
+		return fmt.Errorf("failed to update cached folder size for %s: %w", parent, err)
+	}
+	return nil
+}
+
+func addAbove(local string, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	for _, dir := range ancestors(local) {
+		if err := db.DatabaseQueries.AddDirSizeDelta(context.Background(), db.AddDirSizeDeltaParams{
+			Delta: delta,
+			Path:  dir,
+		}); err != nil {
+			return fmt.Errorf("failed to update cached folder size for %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// invalidateAbove drops the cached sizes of the folders above local, for when
+// the change at local can't be expressed as a difference in size.
+func invalidateAbove(local string) error {
+	for _, dir := range ancestors(local) {
+		if err := db.DatabaseQueries.DeleteDirSize(context.Background(), dir); err != nil {
+			return fmt.Errorf("failed to invalidate cached folder size for %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// forget drops the cached sizes of local and every folder below it.
+func forget(local string) error {
+	if err := db.DatabaseQueries.DeleteDirSizesUnder(context.Background(), db.DeleteDirSizesUnderParams{
+		Path:          local,
+		PrefixPattern: db.PrefixPattern(local),
+	}); err != nil {
+		return fmt.Errorf("failed to drop cached folder sizes under %s: %w", local, err)
+	}
+	return nil
+}
+
+func ancestors(local string) []string {
+	var dirs []string
+	for local != "." {
+		local = path.Dir(local)
+		dirs = append(dirs, local)
+	}
+	return dirs
+}
@@ -0,0 +1,186 @@
+package filechanges
+
+import (
+	"autobutler/pkg/activity"
+	"autobutler/pkg/albums"
+	"autobutler/pkg/dirsize"
+	"autobutler/pkg/fsevents"
+	"autobutler/pkg/photos"
+	"autobutler/pkg/quotas"
+	"autobutler/pkg/search"
+	"autobutler/pkg/stars"
+	"autobutler/pkg/tags"
+	"autobutler/pkg/thumbnails"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Changes to the files are passed on to the search index, the folder size
+// cache, tags, stars, thumbnails, the photo catalogue, albums, the activity
+// feed and open file explorers. Changes made through the API are reported by
+// the API, and those made outside of it by the file watcher, which the
+// activity feed leaves out. The paths are relative to the files root.
+
+// reportedWindow is how long after the API reported a change the watcher
+// leaves the same path alone, as it sees the API's own changes too.
+const reportedWindow = 10 * time.Second
+
+var (
+	apiMutex sync.Mutex
+	// held counts the API operations that are changing each path.
+	held = map[string]int{}
+	// reported holds when the API last reported each path.
+	reported = map[string]time.Time{}
+	// waiting holds the paths the watcher saw change while they were held,
+	// which it looks at again once they are released.
+	waiting = map[string]struct{}{}
+)
+
+// Added reports a file or folder that was created.
+func Added(filePath string) {
+	report(filePath)
+	search.IndexPath(filePath)
+	dirsize.Added(filePath)
+	thumbnails.Added(filePath)
+	photos.Added(filePath)
+	activity.Record(activity.ActionAdded, filePath)
+	fsevents.Changed(filePath)
+}
+
+// Modified reports a file that was overwritten, which had oldSize before.
+func Modified(filePath string, oldSize int64) {
+	report(filePath)
+	search.IndexPath(filePath)
+	dirsize.Resized(filePath, oldSize)
+	thumbnails.Added(filePath)
+	photos.Added(filePath)
+	activity.Record(activity.ActionModified, filePath)
+	fsevents.Changed(filePath)
+}
+
+// Removed reports a file or folder that was removed. info is what it was
+// before it was removed.
+func Removed(filePath string, info fs.FileInfo) {
+	report(filePath)
+	search.RemovePath(filePath)
+	dirsize.Removed(filePath, info)
+	tags.Removed(filePath)
+	stars.Removed(filePath)
+	quotas.Removed(filePath)
+	thumbnails.Removed(filePath, info)
+	photos.Removed(filePath)
+	albums.Removed(filePath)
+	activity.Record(activity.ActionDeleted, filePath)
+	fsevents.Changed(filePath)
+}
+
+// Moved reports a file or folder that was moved from oldPath to newPath.
+// replaced is what was at newPath before, if anything.
+func Moved(oldPath string, newPath string, replaced fs.FileInfo) {
+	report(oldPath, newPath)
+	search.MovePath(oldPath, newPath)
+	dirsize.Moved(oldPath, newPath, replaced)
+	tags.Moved(oldPath, newPath, replaced)
+	stars.Moved(oldPath, newPath, replaced)
+	quotas.Moved(oldPath, newPath, replaced)
+	thumbnails.Moved(oldPath, newPath)
+	photos.Moved(oldPath, newPath)
+	albums.Moved(oldPath, newPath, replaced)
+	activity.RecordMove(oldPath, newPath)
+	fsevents.Changed(oldPath)
+	fsevents.Changed(newPath)
+}
+
+// Hold tells the file watcher that the API is changing the files and folders
+// at paths, and will report the changes itself, until release is called.
+// Operations that take a while, like copies and uploads, hold their paths so
+// that the watcher doesn't take what they have done so far for changes made
+// outside of the API, like a moved file for a deleted one. What the watcher
+// sees change at or below held paths is looked at again once they are
+// released, as something else may have changed it too.
+func Hold(paths ...string) (release func()) {
+	keys := make([]string, len(paths))
+	apiMutex.Lock()
+	for i, filePath := range paths {
+		keys[i] = key(filePath)
+		held[keys[i]]++
+	}
+	apiMutex.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			now := time.Now()
+			apiMutex.Lock()
+			for _, k := range keys {
+				if held[k]--; held[k] <= 0 {
+					delete(held, k)
+				}
+				// Events for the last changes may still be on their way
+				reported[k] = now
+			}
+			var released []string
+			for filePath := range waiting {
+				if !isHeld(key(filePath)) {
+					released = append(released, filePath)
+					delete(waiting, filePath)
+				}
+			}
+			apiMutex.Unlock()
+			for _, filePath := range released {
+				watched(filePath)
+			}
+		})
+	}
+}
+
+func report(paths ...string) {
+	now := time.Now()
+	apiMutex.Lock()
+	defer apiMutex.Unlock()
+	for k, at := range reported {
+		if now.Sub(at) > reportedWindow {
+			delete(reported, k)
+		}
+	}
+	for _, filePath := range paths {
+		reported[key(filePath)] = now
+	}
+}
+
+// byAPI reports whether the change at filePath is the API's to report,
+// because the API has just reported that path, or is changing it or a folder
+// above it. Changes at held paths wait to be looked at again.
+func byAPI(filePath string) bool {
+	k := key(filePath)
+	apiMutex.Lock()
+	defer apiMutex.Unlock()
+	if at, ok := reported[k]; ok && time.Since(at) <= reportedWindow {
+		return true
+	}
+	if isHeld(k) {
+		waiting[filePath] = struct{}{}
+		return true
+	}
+	return false
+}
+
+// isHeld reports whether the path under key k, or a folder above it, is held.
+// The files root never covers the whole tree. apiMutex must be held.
+func isHeld(k string) bool {
+	for dir := k; dir != "/"; dir = path.Dir(dir) {
+		if held[dir] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// key turns a path relative to the files root into the form paths are held
+// and reported under, where the files root is "/".
+func key(filePath string) string {
+	return path.Clean("/" + strings.TrimPrefix(filepath.ToSlash(filePath), "/"))
+}
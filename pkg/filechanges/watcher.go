@@ -0,0 +1,192 @@
+package filechanges
+
+import (
+	"autobutler/pkg/albums"
+	"autobutler/pkg/dirsize"
+	"autobutler/pkg/fsevents"
+	"autobutler/pkg/photos"
+	"autobutler/pkg/quotas"
+	"autobutler/pkg/search"
+	"autobutler/pkg/stars"
+	"autobutler/pkg/tags"
+	"autobutler/pkg/thumbnails"
+	"autobutler/pkg/util/fileutil"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// settleDelay is how long changed paths are collected for after the last
+	// change, so that a file being written is looked at once it's done.
+	settleDelay = 500 * time.Millisecond
+	// maxSettleDelay caps how long a steady stream of changes, like a large
+	// rsync, holds back the ones so far.
+	maxSettleDelay = 2 * time.Second
+)
+
+var (
+	changedMutex sync.Mutex
+	// changed holds the paths with changes that haven't been looked at yet.
+	changed      = map[string]struct{}{}
+	changedSince time.Time
+	settleTimer  *time.Timer
+)
+
+// StartWatcher watches every folder in the files root for changes made
+// outside of the API, and passes them on like the API's own once they have
+// settled. Folders that are created later are watched as they appear.
+// Without a working watcher, only changes made through the API are noticed
+// until the periodic rescans.
+func StartWatcher() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fmt.Printf("Error starting file watcher, external changes won't be noticed: %v\n", err)
+		return
+	}
+	filesDir := fileutil.GetFilesDir()
+	watchTree(watcher, filesDir)
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				handleWatchEvent(watcher, filesDir, event)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Printf("Error watching files: %v\n", err)
+			}
+		}
+	}()
+}
+
+func handleWatchEvent(watcher *fsnotify.Watcher, filesDir string, event fsnotify.Event) {
+	if event.Op == fsnotify.Chmod {
+		return
+	}
+	rel, err := filepath.Rel(filesDir, event.Name)
+	if err != nil || !filepath.IsLocal(rel) {
+		return
+	}
+	switch {
+	case event.Has(fsnotify.Create):
+		if info, err := os.Lstat(event.Name); err == nil && info.IsDir() {
+			// Anything created in the new folder before it was watched is
+			// picked up when the folder is passed on, as everything below a
+			// changed folder is looked at too
+			watchTree(watcher, event.Name)
+		}
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		// A moved folder shows up as created at its destination, where it is
+		// watched again
+		if err := watcher.Remove(event.Name); err != nil && !errors.Is(err, fsnotify.ErrNonExistentWatch) {
+			fmt.Printf("Error unwatching %s: %v\n", event.Name, err)
+		}
+	}
+	watched(rel)
+}
+
+func watched(filePath string) {
+	changedMutex.Lock()
+	defer changedMutex.Unlock()
+	if len(changed) == 0 {
+		changedSince = time.Now()
+	}
+	changed[filePath] = struct{}{}
+	delay := min(settleDelay, time.Until(changedSince.Add(maxSettleDelay)))
+	if settleTimer == nil {
+		settleTimer = time.AfterFunc(delay, settle)
+	} else {
+		settleTimer.Reset(delay)
+	}
+}
+
+// settle passes on the changes collected so far that weren't made through the
+// API. The events themselves are unreliable about what happened, as they are
+// often merged or cut short, so what is at each path now decides it. A file
+// renamed outside of the API is removed at its old path and added at its new
+// one, losing its tags, stars and albums.
+func settle() {
+	changedMutex.Lock()
+	paths := make([]string, 0, len(changed))
+	for filePath := range changed {
+		paths = append(paths, filePath)
+	}
+	changed = map[string]struct{}{}
+	changedMutex.Unlock()
+
+	// Folders before what is in them
+	slices.Sort(paths)
+	root := fileutil.GetFilesRoot()
+	for _, filePath := range paths {
+		if byAPI(filePath) {
+			continue
+		}
+		_, err := root.Lstat(filePath)
+		switch {
+		case err == nil:
+			changedOutside(filePath)
+		case errors.Is(err, fs.ErrNotExist):
+			removedOutside(filePath)
+		}
+	}
+}
+
+// changedOutside passes on a file or folder that was created or modified
+// outside of the API. Every consumer picks up what is there now, so it
+// doesn't matter which it was.
+func changedOutside(filePath string) {
+	search.IndexPath(filePath)
+	// Files changed deeper down don't change the modification times of the
+	// folders above them, which the cached sizes are checked against
+	dirsize.Changed(filePath)
+	thumbnails.Added(filePath)
+	photos.Added(filePath)
+	fsevents.Changed(filePath)
+}
+
+// removedOutside passes on a file or folder that was removed outside of the
+// API, whose size and type are no longer known.
+func removedOutside(filePath string) {
+	search.RemovePath(filePath)
+	dirsize.Removed(filePath, nil)
+	tags.Removed(filePath)
+	stars.Removed(filePath)
+	quotas.Removed(filePath)
+	photos.Removed(filePath)
+	albums.Removed(filePath)
+	fsevents.Changed(filePath)
+}
+
+// watchTree adds a watch for dir and every folder below it.
+func watchTree(watcher *fsnotify.Watcher, dir string) {
+	err := filepath.WalkDir(dir, func(walkPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Keep watching the rest of the tree
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := watcher.Add(walkPath); err != nil {
+			// Most likely the inotify watch limit, which won't get better
+			// deeper down
+			return fmt.Errorf("failed to watch %s: %w", walkPath, err)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("Error watching files: %v\n", err)
+	}
+}
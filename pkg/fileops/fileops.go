@@ -0,0 +1,381 @@
+package fileops
+
+import (
+	"autobutler/pkg/dirsize"
+	"autobutler/pkg/jobs"
+	"autobutler/pkg/quotas"
+	"autobutler/pkg/trash"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// CollisionPolicy decides what happens when something already exists at the
+// destination of a move or copy.
+type CollisionPolicy string
+
+const (
+	// CollisionSkip leaves both the source and the existing entry alone.
+	CollisionSkip CollisionPolicy = "skip"
+	// CollisionOverwrite moves the existing entry to the trash first.
+	CollisionOverwrite CollisionPolicy = "overwrite"
+	// CollisionKeepBoth gives the new entry the next free "name_(n).ext".
+	CollisionKeepBoth CollisionPolicy = "keep-both"
+)
+
+// Status is the outcome of a single item of an operation.
+type Status string
+
+const (
+	StatusMoved   Status = "moved"
+	StatusCopied  Status = "copied"
+	StatusSkipped Status = "skipped"
+	StatusDeleted Status = "deleted"
+	StatusFailed  Status = "failed"
+)
+
+var (
+	ErrUnknownPolicy = errors.New("unknown collision policy")
+	ErrNotFolder     = errors.New("destination is not a folder")
+	// ErrIntoItself is returned for a folder that would end up inside itself,
+	// or replace a folder it is in.
+	ErrIntoItself = errors.New("cannot move or copy a folder into itself")
+)
+
+// Result reports what happened to one source of an operation. Paths are
+// relative to the files root and start with a "/".
+type Result struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination,omitempty"`
+	Status      Status `json:"status"`
+	Error       string `json:"error,omitempty"`
+	// Replaced is what was at Destination before it was overwritten, if
+	// anything.
+	Replaced fs.FileInfo `json:"-"`
+	// Removed is what Source was before it was deleted.
+	Removed fs.FileInfo `json:"-"`
+	err     error
+}
+
+// Err returns the error that made the item fail, if it did.
+func (r Result) Err() error {
+	return r.err
+}
+
+// ParseCollisionPolicy parses a policy name, defaulting to keeping both when
+// it is empty.
+func ParseCollisionPolicy(name string) (CollisionPolicy, error) {
+	switch policy := CollisionPolicy(name); policy {
+	case "":
+		return CollisionKeepBoth, nil
+	case CollisionSkip, CollisionOverwrite, CollisionKeepBoth:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownPolicy, name)
+	}
+}
+
+// Move moves every source into the folder destDir. Moves across devices are
+// done by copying and removing the source afterwards, preserving permissions
+// and modification times. Sources that haven't been moved when ctx is
+// canceled are reported as failed.
+func Move(ctx context.Context, sources []string, destDir string, policy CollisionPolicy, progress *jobs.Progress) []Result {
+	return transferAll(ctx, sources, destDir, policy, false, progress)
+}
+
+// Copy copies every source into the folder destDir, preserving permissions
+// and modification times.
+func Copy(ctx context.Context, sources []string, destDir string, policy CollisionPolicy, progress *jobs.Progress) []Result {
+	return transferAll(ctx, sources, destDir, policy, true, progress)
+}
+
+// Duplicate copies every source next to itself under the next free
+// "name_(n).ext".
+func Duplicate(ctx context.Context, sources []string, progress *jobs.Progress) []Result {
+	op := newOperation(ctx, progress)
+	return op.each(sources, func(srcLocal string, result *Result) error {
+		return op.copyTo(srcLocal, filepath.Dir(srcLocal), CollisionKeepBoth, result)
+	})
+}
+
+// Delete moves every source to the trash.
+func Delete(ctx context.Context, sources []string, progress *jobs.Progress) []Result {
+	op := newOperation(ctx, progress)
+	return op.each(sources, func(srcLocal string, result *Result) error {
+		item, err := trash.MoveToTrash(srcLocal)
+		if err != nil {
+			return err
+		}
+		result.Removed = trash.ItemInfo{Item: *item}
+		result.Status = StatusDeleted
+		op.progress.AddBytes(item.SizeBytes)
+		return nil
+	})
+}
+
+// CheckCopy returns an error wrapping quotas.ErrInsufficientStorage if the
+// sources that exist don't fit into destDir together, so that a copy can be
+// refused before it starts. Each item is checked again as it's copied.
+func CheckCopy(sources []string, destDir string) error {
+	return checkSpace(sources, func(string) string { return destDir })
+}
+
+// CheckDuplicate is CheckCopy for duplicating sources next to themselves.
+func CheckDuplicate(sources []string) error {
+	return checkSpace(sources, filepath.Dir)
+}
+
+func checkSpace(sources []string, destDirOf func(srcLocal string) string) error {
+	root := fileutil.GetFilesRoot()
+	byDir := map[string][]string{}
+	for _, source := range sources {
+		// Missing sources are reported item by item
+		if local, err := sourcePath(root, source); err == nil {
+			dir := destDirOf(local)
+			byDir[dir] = append(byDir[dir], local)
+		}
+	}
+	for dir, locals := range byDir {
+		if err := quotas.CheckCopy(locals, dir); errors.Is(err, quotas.ErrInsufficientStorage) {
+			return err
+		}
+	}
+	return nil
+}
+
+func transferAll(ctx context.Context, sources []string, destDir string, policy CollisionPolicy, copying bool, progress *jobs.Progress) []Result {
+	op := newOperation(ctx, progress)
+	dirLocal, dirErr := destinationDir(op.root, destDir)
+	return op.each(sources, func(srcLocal string, result *Result) error {
+		if dirErr != nil {
+			return dirErr
+		}
+		if copying {
+			return op.copyTo(srcLocal, dirLocal, policy, result)
+		}
+		return op.moveTo(srcLocal, dirLocal, policy, result)
+	})
+}
+
+// operation runs one batch of work for a job.
+type operation struct {
+	ctx      context.Context
+	root     *fileutil.Root
+	progress *jobs.Progress
+}
+
+func newOperation(ctx context.Context, progress *jobs.Progress) *operation {
+	return &operation{
+		ctx:      ctx,
+		root:     fileutil.GetFilesRoot(),
+		progress: progress,
+	}
+}
+
+// each runs apply on every source that exists, and collects the results.
+// Totals are measured up front, so progress can be shown as a fraction.
+// Operations on the same paths are run one after another by the jobs they
+// are submitted as, so that two of them can't pick the same free name or
+// overwrite each other's destination.
+func (op *operation) each(sources []string, apply func(srcLocal string, result *Result) error) []Result {
+	var totalBytes int64
+	for _, source := range sources {
+		if local, err := sourcePath(op.root, source); err == nil {
+			totalBytes += sizeOf(op.root, local)
+		}
+	}
+	op.progress.SetTotals(int64(len(sources)), totalBytes)
+
+	results := make([]Result, len(sources))
+	for i, source := range sources {
+		result := Result{Source: source}
+		srcLocal, err := sourcePath(op.root, source)
+		if err == nil {
+			result.Source = display(srcLocal)
+			err = op.ctx.Err()
+		}
+		if err == nil {
+			err = apply(srcLocal, &result)
+		}
+		if err != nil {
+			result.Status = StatusFailed
+			result.Error = err.Error()
+			result.err = err
+		}
+		results[i] = result
+		op.progress.AddItems(1)
+	}
+	return results
+}
+
+func (op *operation) moveTo(srcLocal string, dirLocal string, policy CollisionPolicy, result *Result) error {
+	dstLocal := filepath.Join(dirLocal, filepath.Base(srcLocal))
+	result.Destination = display(dstLocal)
+	if dstLocal == srcLocal {
+		result.Status = StatusSkipped
+		return nil
+	}
+	if within(dirLocal, srcLocal) {
+		return ErrIntoItself
+	}
+	if err := quotas.CheckMove(srcLocal, dirLocal); err != nil {
+		return err
+	}
+	dstLocal, release, skip, err := resolveCollision(op.root, srcLocal, dstLocal, policy, result)
+	if err != nil || skip {
+		return err
+	}
+	defer release()
+	size := sizeOf(op.root, srcLocal)
+	copied := op.counter()
+	if err := fileutil.MoveBetweenRootsContext(op.ctx, op.root, srcLocal, op.root, dstLocal, copied.add); err != nil {
+		return err
+	}
+	// A rename doesn't copy anything, but still counts as the whole item
+	op.progress.AddBytes(max(size-copied.bytes, 0))
+	result.Status = StatusMoved
+	return nil
+}
+
+func (op *operation) copyTo(srcLocal string, dirLocal string, policy CollisionPolicy, result *Result) error {
+	dstLocal := filepath.Join(dirLocal, filepath.Base(srcLocal))
+	result.Destination = display(dstLocal)
+	if within(dirLocal, srcLocal) {
+		return ErrIntoItself
+	}
+	if dstLocal == srcLocal && policy == CollisionOverwrite {
+		// Copying something over itself changes nothing
+		result.Status = StatusSkipped
+		return nil
+	}
+	if err := quotas.CheckCopy([]string{srcLocal}, dirLocal); err != nil {
+		return err
+	}
+	dstLocal, release, skip, err := resolveCollision(op.root, srcLocal, dstLocal, policy, result)
+	if err != nil || skip {
+		return err
+	}
+	defer release()
+	copied := op.counter()
+	if err := fileutil.CopyBetweenRootsContext(op.ctx, op.root, srcLocal, op.root, dstLocal, copied.add); err != nil {
+		if removeErr := op.root.RemoveAll(dstLocal); removeErr != nil {
+			return fmt.Errorf("%w (and failed to remove the partial copy: %v)", err, removeErr)
+		}
+		return err
+	}
+	result.Status = StatusCopied
+	return nil
+}
+
+// byteCounter counts the bytes copied for one item, passing them on to the
+// progress of the whole operation.
+type byteCounter struct {
+	progress *jobs.Progress
+	bytes    int64
+}
+
+func (op *operation) counter() *byteCounter {
+	return &byteCounter{progress: op.progress}
+}
+
+func (c *byteCounter) add(n int64) {
+	c.bytes += n
+	c.progress.AddBytes(n)
+}
+
+// resolveCollision applies policy when something already exists at dstLocal,
+// returning the path to write to instead, or skip if nothing should be.
+// release gives up the path once it has been written to.
+func resolveCollision(root *fileutil.Root, srcLocal string, dstLocal string, policy CollisionPolicy, result *Result) (string, func(), bool, error) {
+	if policy != CollisionSkip && policy != CollisionOverwrite {
+		// Free names are kept from anything else picking them too until
+		// written to, even when dstLocal itself is free
+		available, release, err := root.AvailablePath(dstLocal)
+		if err != nil {
+			return "", nil, false, err
+		}
+		result.Destination = display(available)
+		return available, release, false, nil
+	}
+	existing, err := root.Lstat(dstLocal)
+	if errors.Is(err, fs.ErrNotExist) {
+		return dstLocal, func() {}, false, nil
+	}
+	if err != nil {
+		return "", nil, false, err
+	}
+	if policy == CollisionSkip {
+		result.Status = StatusSkipped
+		return "", nil, true, nil
+	}
+	if within(srcLocal, dstLocal) {
+		return "", nil, false, ErrIntoItself
+	}
+	// Overwritten entries can still be restored from the trash
+	if _, err := trash.MoveToTrash(dstLocal); err != nil {
+		return "", nil, false, err
+	}
+	result.Replaced = existing
+	return dstLocal, func() {}, false, nil
+}
+
+func sourcePath(root *fileutil.Root, source string) (string, error) {
+	local, err := root.Clean(source)
+	if err != nil {
+		return "", err
+	}
+	if local == "." {
+		return "", &fs.PathError{Op: "transfer", Path: source, Err: fileutil.ErrRootPath}
+	}
+	if _, err := root.Lstat(local); err != nil {
+		return "", err
+	}
+	return local, nil
+}
+
+// sizeOf returns the size of a file or folder, or 0 if it can't be measured.
+func sizeOf(root *fileutil.Root, local string) int64 {
+	info, err := root.Lstat(local)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	if size, ok := dirsize.Cached(local); ok {
+		return size
+	}
+	size, err := root.FolderSize(local)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+func destinationDir(root *fileutil.Root, destDir string) (string, error) {
+	local, err := root.Clean(destDir)
+	if err != nil {
+		return "", err
+	}
+	info, err := root.Stat(local)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", &fs.PathError{Op: "transfer", Path: destDir, Err: ErrNotFolder}
+	}
+	return local, nil
+}
+
+// within reports whether local is dir or somewhere below it.
+func within(local string, dir string) bool {
+	rel, err := filepath.Rel(dir, local)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+func display(local string) string {
+	return "/" + filepath.ToSlash(local)
+}
@@ -0,0 +1,108 @@
+package fsevents
+
+import (
+	"autobutler/pkg/util/fileutil"
+	"path"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// debounceDelay is how long changes are collected for after the last one,
+	// so that a burst of changes becomes a single event per folder.
+	debounceDelay = 250 * time.Millisecond
+	// maxDebounceDelay caps how long a steady stream of changes, like a large
+	// rsync, holds back events.
+	maxDebounceDelay = 2 * time.Second
+	subscriberBuffer = 64
+)
+
+// Event reports that entries of the folder Dir changed. Paths are relative to
+// the files root and start with a "/".
+type Event struct {
+	Dir   string   `json:"dir"`
+	Paths []string `json:"paths"`
+}
+
+var (
+	subscribersMutex sync.Mutex
+	subscribers      = map[chan Event]struct{}{}
+
+	pendingMutex sync.Mutex
+	// pending maps folders with unpublished changes to their changed paths.
+	pending      = map[string]map[string]struct{}{}
+	pendingSince time.Time
+	flushTimer   *time.Timer
+)
+
+// Subscribe returns a channel that receives every published event, and a
+// function that ends the subscription. Events are dropped for subscribers
+// that fall behind.
+func Subscribe() (<-chan Event, func()) {
+	events := make(chan Event, subscriberBuffer)
+	subscribersMutex.Lock()
+	subscribers[events] = struct{}{}
+	subscribersMutex.Unlock()
+	return events, func() {
+		subscribersMutex.Lock()
+		delete(subscribers, events)
+		subscribersMutex.Unlock()
+	}
+}
+
+// Changed reports that the file or folder at filePath, relative to the files
+// root, was created, modified, moved or removed. Events for its folder are
+// published once changes have settled.
+func Changed(filePath string) {
+	local, err := fileutil.GetFilesRoot().Clean(filePath)
+	if err != nil || local == "." {
+		return
+	}
+	changedPath := "/" + filepath.ToSlash(local)
+	dir := path.Dir(changedPath)
+
+	pendingMutex.Lock()
+	defer pendingMutex.Unlock()
+	if len(pending) == 0 {
+		pendingSince = time.Now()
+	}
+	if pending[dir] == nil {
+		pending[dir] = map[string]struct{}{}
+	}
+	pending[dir][changedPath] = struct{}{}
+	delay := min(debounceDelay, time.Until(pendingSince.Add(maxDebounceDelay)))
+	if flushTimer == nil {
+		flushTimer = time.AfterFunc(delay, flush)
+	} else {
+		flushTimer.Reset(delay)
+	}
+}
+
+func flush() {
+	pendingMutex.Lock()
+	changes := pending
+	pending = map[string]map[string]struct{}{}
+	pendingMutex.Unlock()
+
+	for dir, paths := range changes {
+		event := Event{Dir: dir}
+		for changedPath := range paths {
+			event.Paths = append(event.Paths, changedPath)
+		}
+		slices.Sort(event.Paths)
+		publish(event)
+	}
+}
+
+func publish(event Event) {
+	subscribersMutex.Lock()
+	defer subscribersMutex.Unlock()
+	for events := range subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package jobs
+
+import (
+	"autobutler/pkg/db"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Jobs run in the background, a few at a time, and are recorded in the
+// database along with their progress, so clients can pick them up again after
+// a reload. A job that touches the same files or folders as an earlier one
+// waits for it to finish, so that jobs on the same paths run in the order
+// they were submitted, while a long copy doesn't hold up unrelated jobs. The
+// functions they run only live in memory, so jobs that were unfinished when
+// the server stopped are marked as failed on the next start.
+
+const (
+	// DefaultListLimit is how many of the most recent jobs List returns.
+	DefaultListLimit = 50
+	historyMaxAge    = 7 * 24 * time.Hour
+	cleanupInterval  = time.Hour
+	// reportInterval throttles how often progress is saved and published.
+	reportInterval   = 250 * time.Millisecond
+	subscriberBuffer = 64
+	// workers is how many jobs may run at once.
+	workers = 4
+)
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+var (
+	ErrNotFound = errors.New("job not found")
+	// ErrFinished is returned when canceling a job that has already finished.
+	ErrFinished = errors.New("job has already finished")
+)
+
+// Func does the work of a job. It should stop when ctx is canceled, and
+// report what it has done through progress. Its result is stored as JSON.
+type Func func(ctx context.Context, progress *Progress) (any, error)
+
+// Job is a snapshot of a job and its progress.
+type Job struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`
+	Description string          `json:"description"`
+	Status      Status          `json:"status"`
+	ItemsDone   int64           `json:"itemsDone"`
+	ItemsTotal  int64           `json:"itemsTotal"`
+	BytesDone   int64           `json:"bytesDone"`
+	BytesTotal  int64           `json:"bytesTotal"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	StartedAt   *time.Time      `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time      `json:"finishedAt,omitempty"`
+}
+
+// Finished reports whether the job has stopped, for whatever reason.
+func (j Job) Finished() bool {
+	return j.FinishedAt != nil
+}
+
+var (
+	scheduleMutex sync.Mutex
+	// waiting holds the queued jobs in the order they were submitted, and
+	// running the ones that have started.
+	waiting []*scheduledJob
+	running []*scheduledJob
+
+	activeMutex sync.Mutex
+	// active holds the jobs that are queued or running.
+	active = map[int64]*activeJob{}
+
+	subscribersMutex sync.Mutex
+	subscribers      = map[chan Job]struct{}{}
+)
+
+type scheduledJob struct {
+	paths []string
+	run   func() error
+}
+
+type activeJob struct {
+	cancel context.CancelFunc
+	// done is closed once the job has finished.
+	done chan struct{}
+}
+
+// Submit queues run as a new job. itemsTotal is the number of items the job
+// is expected to process, if it is known up front. paths are the files and
+// folders the job reads or changes, relative to the files root, which decide
+// the jobs it has to wait for.
+func Submit(kind string, description string, itemsTotal int64, paths []string, run Func) (Job, error) {
+	row, err := db.DatabaseQueries.CreateJob(context.Background(), db.CreateJobParams{
+		Kind:        kind,
+		Description: description,
+		ItemsTotal:  itemsTotal,
+		CreatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to create job: %w", err)
+	}
+	job := fromRow(row)
+	ctx, cancel := context.WithCancel(context.Background())
+	activeMutex.Lock()
+	active[job.ID] = &activeJob{cancel: cancel, done: make(chan struct{})}
+	activeMutex.Unlock()
+	publish(job)
+	schedule(&scheduledJob{
+		paths: cleanPaths(paths),
+		run: func() error {
+			return execute(ctx, job, run)
+		},
+	})
+	return job, nil
+}
+
+// Get returns the job with the given ID.
+func Get(id int64) (Job, error) {
+	row, err := db.DatabaseQueries.GetJob(context.Background(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Job{}, ErrNotFound
+	}
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to get job %d: %w", id, err)
+	}
+	return fromRow(row), nil
+}
+
+// List returns up to limit of the most recently submitted jobs, newest first.
+func List(limit int) ([]Job, error) {
+	rows, err := db.DatabaseQueries.ListJobs(context.Background(), int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	jobs := make([]Job, len(rows))
+	for i, row := range rows {
+		jobs[i] = fromRow(row)
+	}
+	return jobs, nil
+}
+
+// Cancel asks a queued or running job to stop. It finishes as canceled once
+// its function has returned.
+func Cancel(id int64) error {
+	activeMutex.Lock()
+	job, ok := active[id]
+	activeMutex.Unlock()
+	if ok {
+		job.cancel()
+		return nil
+	}
+	if _, err := Get(id); err != nil {
+		return err
+	}
+	return ErrFinished
+}
+
+// Wait waits up to timeout for a job to finish, returning its final state and
+// whether it finished in time.
+func Wait(ctx context.Context, id int64, timeout time.Duration) (Job, bool, error) {
+	activeMutex.Lock()
+	job, ok := active[id]
+	activeMutex.Unlock()
+	if ok {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-job.done:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+	finished, err := Get(id)
+	if err != nil {
+		return Job{}, false, err
+	}
+	return finished, finished.Finished(), nil
+}
+
+// Subscribe returns a channel that receives a snapshot of a job whenever it
+// changes, and a function that ends the subscription. Snapshots are dropped
+// for subscribers that fall behind.
+func Subscribe() (<-chan Job, func()) {
+	jobs := make(chan Job, subscriberBuffer)
+	subscribersMutex.Lock()
+	subscribers[jobs] = struct{}{}
+	subscribersMutex.Unlock()
+	return jobs, func() {
+		subscribersMutex.Lock()
+		delete(subscribers, jobs)
+		subscribersMutex.Unlock()
+	}
+}
+
+// StartJobs marks the jobs that were cut short by the last shutdown as
+// failed, and periodically drops the history of old jobs.
+func StartJobs() {
+	now := time.Now().UTC()
+	if err := db.DatabaseQueries.FailUnfinishedJobs(context.Background(), db.FailUnfinishedJobsParams{
+		Error:      "interrupted by a server restart",
+		FinishedAt: sql.NullTime{Time: now, Valid: true},
+	}); err != nil {
+		fmt.Printf("Error failing interrupted jobs: %v\n", err)
+	}
+	go func() {
+		for {
+			cutoff := time.Now().UTC().Add(-historyMaxAge)
+			if err := db.DatabaseQueries.DeleteJobsFinishedBefore(context.Background(), sql.NullTime{Time: cutoff, Valid: true}); err != nil {
+				fmt.Printf("Error deleting old jobs: %v\n", err)
+			}
+			time.Sleep(cleanupInterval)
+		}
+	}()
+}
+
+// schedule queues a job, if given, and starts every queued job that can run.
+// A job can run while there is a free worker, unless it shares paths with a
+// job that is running, or that was submitted before it and is still queued.
+func schedule(submitted *scheduledJob) {
+	scheduleMutex.Lock()
+	defer scheduleMutex.Unlock()
+	if submitted != nil {
+		waiting = append(waiting, submitted)
+	}
+	var blocked []*scheduledJob
+	for _, job := range waiting {
+		if len(running) >= workers || overlapsAny(job, running) || overlapsAny(job, blocked) {
+			blocked = append(blocked, job)
+			continue
+		}
+		running = append(running, job)
+		go func() {
+			if err := job.run(); err != nil {
+				fmt.Printf("Error running job: %v\n", err)
+			}
+			scheduleMutex.Lock()
+			running = slices.DeleteFunc(running, func(other *scheduledJob) bool {
+				return other == job
+			})
+			scheduleMutex.Unlock()
+			schedule(nil)
+		}()
+	}
+	waiting = blocked
+}
+
+func overlapsAny(job *scheduledJob, others []*scheduledJob) bool {
+	for _, other := range others {
+		for _, a := range job.paths {
+			for _, b := range other.paths {
+				if strings.HasPrefix(a+"/", b+"/") || strings.HasPrefix(b+"/", a+"/") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// cleanPaths turns paths into a form that is inside another exactly when it
+// starts with it and a slash. The files root becomes an empty string.
+func cleanPaths(paths []string) []string {
+	cleaned := make([]string, len(paths))
+	for i, filePath := range paths {
+		cleaned[i] = strings.TrimSuffix(path.Clean("/"+filepath.ToSlash(filePath)), "/")
+	}
+	return cleaned
+}
+
+func execute(ctx context.Context, job Job, run Func) error {
+	defer func() {
+		activeMutex.Lock()
+		if finished, ok := active[job.ID]; ok {
+			finished.cancel()
+			close(finished.done)
+			delete(active, job.ID)
+		}
+		activeMutex.Unlock()
+	}()
+
+	progress := &Progress{job: job}
+	if ctx.Err() != nil {
+		// Canceled while it was still queued
+		return progress.finish(nil, ctx.Err())
+	}
+	now := time.Now().UTC()
+	if err := db.DatabaseQueries.StartJob(context.Background(), db.StartJobParams{
+		StartedAt: sql.NullTime{Time: now, Valid: true},
+		ID:        job.ID,
+	}); err != nil {
+		return progress.finish(nil, fmt.Errorf("failed to start job: %w", err))
+	}
+	progress.job.Status = StatusRunning
+	progress.job.StartedAt = &now
+	publish(progress.job)
+
+	result, err := run(ctx, progress)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return progress.finish(result, err)
+}
+
+func publish(job Job) {
+	subscribersMutex.Lock()
+	defer subscribersMutex.Unlock()
+	for jobs := range subscribers {
+		select {
+		case jobs <- job:
+		default:
+		}
+	}
+}
+
+func fromRow(row db.Job) Job {
+	job := Job{
+		ID:          row.ID,
+		Kind:        row.Kind,
+		Description: row.Description,
+		Status:      Status(row.Status),
+		ItemsDone:   row.ItemsDone,
+		ItemsTotal:  row.ItemsTotal,
+		BytesDone:   row.BytesDone,
+		BytesTotal:  row.BytesTotal,
+		Error:       row.Error,
+		CreatedAt:   row.CreatedAt,
+	}
+	if row.Result != "" {
+		job.Result = json.RawMessage(row.Result)
+	}
+	if row.StartedAt.Valid {
+		job.StartedAt = &row.StartedAt.Time
+	}
+	if row.FinishedAt.Valid {
+		job.FinishedAt = &row.FinishedAt.Time
+	}
+	return job
+}
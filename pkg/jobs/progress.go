@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"autobutler/pkg/db"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Progress tracks the items and bytes a running job has processed. A nil
+// Progress ignores every update, so work can be run outside of a job as well.
+type Progress struct {
+	mutex      sync.Mutex
+	job        Job
+	reportedAt time.Time
+}
+
+// SetTotals sets the number of items and bytes the job is expected to
+// process.
+func (p *Progress) SetTotals(items int64, bytes int64) {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	p.job.ItemsTotal = items
+	p.job.BytesTotal = bytes
+	p.mutex.Unlock()
+	p.report(true)
+}
+
+// AddItems records that n more items were processed.
+func (p *Progress) AddItems(n int64) {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	p.job.ItemsDone += n
+	p.mutex.Unlock()
+	p.report(false)
+}
+
+// AddBytes records that n more bytes were processed.
+func (p *Progress) AddBytes(n int64) {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	p.job.BytesDone += n
+	p.mutex.Unlock()
+	p.report(false)
+}
+
+// report saves and publishes the progress, at most once per reportInterval
+// unless forced.
+func (p *Progress) report(force bool) {
+	p.mutex.Lock()
+	if !force && time.Since(p.reportedAt) < reportInterval {
+		p.mutex.Unlock()
+		return
+	}
+	p.reportedAt = time.Now()
+	job := p.job
+	p.mutex.Unlock()
+
+	if err := db.DatabaseQueries.UpdateJobProgress(context.Background(), db.UpdateJobProgressParams{
+		ItemsDone:  job.ItemsDone,
+		ItemsTotal: job.ItemsTotal,
+		BytesDone:  job.BytesDone,
+		BytesTotal: job.BytesTotal,
+		ID:         job.ID,
+	}); err != nil {
+		fmt.Printf("Error saving progress of job %d: %v\n", job.ID, err)
+	}
+	publish(job)
+}
+
+// finish records the outcome of the job.
+func (p *Progress) finish(result any, err error) error {
+	p.mutex.Lock()
+	now := time.Now().UTC()
+	p.job.FinishedAt = &now
+	switch {
+	case errors.Is(err, context.Canceled):
+		p.job.Status = StatusCanceled
+	case err != nil:
+		p.job.Status = StatusFailed
+		p.job.Error = err.Error()
+	default:
+		p.job.Status = StatusSucceeded
+	}
+	if result != nil {
+		encoded, encodeErr := json.Marshal(result)
+		if encodeErr != nil {
+			p.job.Status = StatusFailed
+			p.job.Error = fmt.Sprintf("failed to encode result: %v", encodeErr)
+		} else {
+			p.job.Result = encoded
+		}
+	}
+	job := p.job
+	p.mutex.Unlock()
+
+	defer publish(job)
+	if err := db.DatabaseQueries.FinishJob(context.Background(), db.FinishJobParams{
+		Status:     string(job.Status),
+		ItemsDone:  job.ItemsDone,
+		ItemsTotal: job.ItemsTotal,
+		BytesDone:  job.BytesDone,
+		BytesTotal: job.BytesTotal,
+		Result:     string(job.Result),
+		Error:      job.Error,
+		FinishedAt: sql.NullTime{Time: now, Valid: true},
+		ID:         job.ID,
+	}); err != nil {
+		return fmt.Errorf("failed to finish job %d: %w", job.ID, err)
+	}
+	return nil
+}
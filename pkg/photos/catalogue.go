@@ -0,0 +1,172 @@
+package photos
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/util/fileutil"
+	"autobutler/pkg/util/imageutil"
+	"autobutler/pkg/util/queueutil"
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// The catalogue keeps the metadata of every photo in the files root, so the
+// photos can be listed by when they were taken without reading them all
+// again. Like the search index, it is rescanned every hour to pick up
+// changes made outside of the API.
+
+const rescanInterval = time.Hour
+
+// The catalogue is updated by a single worker, so that updates for the same
+// path are applied in the order they were made.
+var queue = queueutil.NewQueue("updating photo catalogue")
+
+var (
+	listenersMutex sync.Mutex
+	listeners      []func()
+)
+
+// OnChange has listener called after every update of the catalogue, like
+// for work that needs photos to be catalogued first.
+func OnChange(listener func()) {
+	listenersMutex.Lock()
+	defer listenersMutex.Unlock()
+	listeners = append(listeners, listener)
+}
+
+// StartScanner schedules a scan of the files root now and every hour after.
+func StartScanner() {
+	go func() {
+		for {
+			update(func() error {
+				return scan(".")
+			})
+			time.Sleep(rescanInterval)
+		}
+	}()
+}
+
+// Added catalogues the photo at filePath, or the photos in the folder at
+// filePath, after it was added or changed.
+func Added(filePath string) {
+	update(func() error {
+		return scan(filePath)
+	})
+}
+
+// Removed drops the photo at filePath, or the photos in the folder at
+// filePath, after it was removed.
+func Removed(filePath string) {
+	update(func() error {
+		local, err := fileutil.GetFilesRoot().CleanSlash(filePath)
+		if err != nil {
+			return err
+		}
+		return forget(local)
+	})
+}
+
+// Moved carries the catalogued photos over from oldPath to newPath, and
+// catalogues any that weren't yet.
+func Moved(oldPath string, newPath string) {
+	update(func() error {
+		root := fileutil.GetFilesRoot()
+		oldLocal, err := root.CleanSlash(oldPath)
+		if err != nil {
+			return err
+		}
+		newLocal, err := root.CleanSlash(newPath)
+		if err != nil {
+			return err
+		}
+		// Whatever was at the destination has been replaced
+		if err := forget(newLocal); err != nil {
+			return err
+		}
+		if err := db.DatabaseQueries.RenamePhotos(context.Background(), db.RenamePhotosParams{
+			NewPath:       newLocal,
+			OldPath:       oldLocal,
+			PrefixPattern: db.PrefixPattern(oldLocal),
+		}); err != nil {
+			return fmt.Errorf("failed to move photos of %s: %w", oldLocal, err)
+		}
+		return scan(newLocal)
+	})
+}
+
+// update applies a change to the catalogue on its worker, and then tells the
+// listeners.
+func update(change func() error) {
+	queue.Push(func() error {
+		err := change()
+		listenersMutex.Lock()
+		defer listenersMutex.Unlock()
+		for _, listener := range listeners {
+			listener()
+		}
+		return err
+	})
+}
+
+// scan brings the catalogue of filePath, and everything below it, in line
+// with the files root. Photos whose size or modification time changed are
+// read again, and ones that are gone are dropped.
+func scan(filePath string) error {
+	ctx := context.Background()
+	root := fileutil.GetFilesRoot()
+	local, err := fileutil.GetFilesRoot().CleanSlash(filePath)
+	if err != nil {
+		return err
+	}
+	catalogued, err := db.DatabaseQueries.ListPhotoVersionsUnder(ctx, db.ListPhotoVersionsUnderParams{
+		Path:          local,
+		PrefixPattern: db.PrefixPattern(local),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list photos under %s: %w", local, err)
+	}
+	stale := make(map[string]db.ListPhotoVersionsUnderRow, len(catalogued))
+	for _, photo := range catalogued {
+		stale[photo.Path] = photo
+	}
+	found, err := imageutil.FindAllPhotosRecursively(local)
+	if err != nil {
+		// Leave the catalogue as it is rather than dropping what is
+		// there, like when a disk has gone missing
+		return err
+	}
+	for _, photo := range found {
+		photoPath := filepath.ToSlash(filepath.Join(local, photo.RelPath))
+		existing, ok := stale[photoPath]
+		delete(stale, photoPath)
+		if ok && existing.SizeBytes == photo.FileInfo.Size() && existing.ModTime.Equal(photo.FileInfo.ModTime().UTC()) {
+			continue
+		}
+		metadata, err := readMetadata(root, photoPath, photo.FileInfo)
+		if err != nil {
+			fmt.Printf("Error reading metadata of %s: %v\n", photoPath, err)
+			continue
+		}
+		if err := db.DatabaseQueries.UpsertPhoto(ctx, metadata); err != nil {
+			return fmt.Errorf("failed to catalogue %s: %w", photoPath, err)
+		}
+	}
+	for _, photo := range stale {
+		if err := db.DatabaseQueries.DeletePhoto(ctx, photo.ID); err != nil {
+			return fmt.Errorf("failed to drop %s from the catalogue: %w", photo.Path, err)
+		}
+	}
+	return nil
+}
+
+func forget(local string) error {
+	if err := db.DatabaseQueries.DeletePhotosUnder(context.Background(), db.DeletePhotosUnderParams{
+		Path:          local,
+		PrefixPattern: db.PrefixPattern(local),
+	}); err != nil {
+		return fmt.Errorf("failed to drop photos of %s: %w", local, err)
+	}
+	return nil
+}
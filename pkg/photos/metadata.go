@@ -0,0 +1,257 @@
+package photos
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/util/fileutil"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+)
+
+const (
+	SourceExif = "exif"
+	SourceFile = "file"
+
+	exifTimeLayout = "2006:01:02 15:04:05"
+	// localTimeLayout sorts the same as text and as time
+	localTimeLayout = "2006-01-02T15:04:05"
+)
+
+// The offset tags of EXIF 2.31 aren't known to goexif, so they are loaded
+// from the EXIF sub-IFD by a parser of our own.
+const (
+	offsetTime          exif.FieldName = "OffsetTime"
+	offsetTimeOriginal  exif.FieldName = "OffsetTimeOriginal"
+	offsetTimeDigitized exif.FieldName = "OffsetTimeDigitized"
+)
+
+var offsetFields = map[uint16]exif.FieldName{
+	0x9010: offsetTime,
+	0x9011: offsetTimeOriginal,
+	0x9012: offsetTimeDigitized,
+}
+
+// Each date tag, most telling first, with the tag holding its offset.
+var dateFields = []struct {
+	date   exif.FieldName
+	offset exif.FieldName
+}{
+	{exif.DateTimeOriginal, offsetTimeOriginal},
+	{exif.DateTimeDigitized, offsetTimeDigitized},
+	{exif.DateTime, offsetTime},
+}
+
+var offsetPattern = regexp.MustCompile(`^([+-])(\d{2}):(\d{2})$`)
+
+type offsetParser struct{}
+
+func init() {
+	exif.RegisterParsers(offsetParser{})
+}
+
+func (offsetParser) Parse(x *exif.Exif) error {
+	tag, err := x.Get(exif.ExifIFDPointer)
+	if err != nil {
+		return nil
+	}
+	offset, err := tag.Int64(0)
+	if err != nil {
+		return nil
+	}
+	r := bytes.NewReader(x.Raw)
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil
+	}
+	dir, _, err := tiff.DecodeDir(r, x.Tiff.Order)
+	if err != nil {
+		// goexif's own parser reports a broken sub-IFD
+		return nil
+	}
+	x.LoadTags(dir, offsetFields, false)
+	return nil
+}
+
+// readMetadata reads what the catalogue keeps about the photo at filePath.
+// Photos without EXIF data, or that can't be decoded at all, are still
+// catalogued by their modification time.
+func readMetadata(root *fileutil.Root, filePath string, info fs.FileInfo) (db.UpsertPhotoParams, error) {
+	photo := db.UpsertPhotoParams{
+		Path:      filePath,
+		SizeBytes: info.Size(),
+		ModTime:   info.ModTime().UTC(),
+	}
+	setTaken(&photo, info.ModTime(), true, SourceFile)
+
+	file, err := root.Open(filePath)
+	if err != nil {
+		return photo, err
+	}
+	defer file.Close()
+	if config, _, err := image.DecodeConfig(file); err == nil {
+		photo.Width = int64(config.Width)
+		photo.Height = int64(config.Height)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return photo, err
+	}
+	x, err := exif.Decode(file)
+	if err != nil && (x == nil || exif.IsCriticalError(err)) {
+		// Most formats other than JPEG have no EXIF data
+		return photo, nil
+	}
+
+	if taken, offsetKnown, ok := takenTime(x); ok {
+		setTaken(&photo, taken, offsetKnown, SourceExif)
+	}
+	photo.CameraMake = stringField(x, exif.Make)
+	photo.CameraModel = stringField(x, exif.Model)
+	photo.LensModel = stringField(x, exif.LensModel)
+	photo.ExposureTime = exposureTime(x)
+	photo.FNumber = ratField(x, exif.FNumber)
+	photo.FocalLength = ratField(x, exif.FocalLength)
+	if tag, err := x.Get(exif.ISOSpeedRatings); err == nil {
+		if iso, err := tag.Int(0); err == nil && iso > 0 {
+			photo.Iso = sql.NullInt64{Int64: int64(iso), Valid: true}
+		}
+	}
+	if photo.Width == 0 || photo.Height == 0 {
+		// Only the EXIF data of images that can't be decoded says their size
+		photo.Width = intField(x, exif.PixelXDimension)
+		photo.Height = intField(x, exif.PixelYDimension)
+	}
+	if lat, long, err := x.LatLong(); err == nil && validCoordinates(lat, long) {
+		photo.Latitude = sql.NullFloat64{Float64: lat, Valid: true}
+		photo.Longitude = sql.NullFloat64{Float64: long, Valid: true}
+	}
+	// Orientations 5 to 8 turn the photo on its side
+	if tag, err := x.Get(exif.Orientation); err == nil {
+		if orientation, err := tag.Int(0); err == nil && orientation >= 5 && orientation <= 8 {
+			photo.Width, photo.Height = photo.Height, photo.Width
+		}
+	}
+	return photo, nil
+}
+
+func setTaken(photo *db.UpsertPhotoParams, taken time.Time, offsetKnown bool, source string) {
+	photo.TakenLocal = taken.Format(localTimeLayout)
+	photo.TakenSource = source
+	photo.TakenOffset = sql.NullInt64{}
+	if offsetKnown {
+		_, offset := taken.Zone()
+		photo.TakenOffset = sql.NullInt64{Int64: int64(offset / 60), Valid: true}
+	}
+}
+
+// takenTime returns when the photo was taken, by the clock of the camera.
+// offsetKnown is false when the EXIF data doesn't say which time zone that
+// clock was in, in which case the time is in UTC.
+func takenTime(x *exif.Exif) (taken time.Time, offsetKnown bool, ok bool) {
+	for _, field := range dateFields {
+		value := stringField(x, field.date)
+		if value == "" {
+			continue
+		}
+		location := time.UTC
+		offsetKnown = false
+		if zone, ok := parseOffset(stringField(x, field.offset)); ok {
+			location, offsetKnown = zone, true
+		} else if zone, err := x.TimeZone(); err == nil {
+			location, offsetKnown = zone, true
+		}
+		// Cameras without a set clock write zeros, which don't parse
+		taken, err := time.ParseInLocation(exifTimeLayout, value, location)
+		if err != nil {
+			continue
+		}
+		return taken, offsetKnown, true
+	}
+	return time.Time{}, false, false
+}
+
+func parseOffset(value string) (*time.Location, bool) {
+	match := offsetPattern.FindStringSubmatch(value)
+	if match == nil {
+		return nil, false
+	}
+	hours := int(match[2][0]-'0')*10 + int(match[2][1]-'0')
+	minutes := int(match[3][0]-'0')*10 + int(match[3][1]-'0')
+	seconds := (hours*60 + minutes) * 60
+	if match[1] == "-" {
+		seconds = -seconds
+	}
+	return time.FixedZone("", seconds), true
+}
+
+func stringField(x *exif.Exif, name exif.FieldName) string {
+	tag, err := x.Get(name)
+	if err != nil {
+		return ""
+	}
+	value, err := tag.StringVal()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(value, "\x00"))
+}
+
+func intField(x *exif.Exif, name exif.FieldName) int64 {
+	tag, err := x.Get(name)
+	if err != nil {
+		return 0
+	}
+	value, err := tag.Int64(0)
+	if err != nil || value < 0 {
+		return 0
+	}
+	return value
+}
+
+func ratField(x *exif.Exif, name exif.FieldName) sql.NullFloat64 {
+	tag, err := x.Get(name)
+	if err != nil {
+		return sql.NullFloat64{}
+	}
+	num, den, err := tag.Rat2(0)
+	if err != nil || num <= 0 || den <= 0 {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: float64(num) / float64(den), Valid: true}
+}
+
+// exposureTime returns the exposure time the way cameras show it, like
+// "1/250" or "2" seconds.
+func exposureTime(x *exif.Exif) string {
+	tag, err := x.Get(exif.ExposureTime)
+	if err != nil {
+		return ""
+	}
+	num, den, err := tag.Rat2(0)
+	if err != nil || num <= 0 || den <= 0 {
+		return ""
+	}
+	if num < den {
+		return fmt.Sprintf("1/%d", int64(math.Round(float64(den)/float64(num))))
+	}
+	return fmt.Sprintf("%g", float64(num)/float64(den))
+}
+
+func validCoordinates(lat float64, long float64) bool {
+	if math.IsNaN(lat) || math.IsNaN(long) {
+		return false
+	}
+	// Cameras without a GPS fix sometimes write zeros
+	if lat == 0 && long == 0 {
+		return false
+	}
+	return lat >= -90 && lat <= 90 && long >= -180 && long <= 180
+}
@@ -0,0 +1,337 @@
+package photos
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/thumbnails"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidDate   = errors.New("invalid date")
+	ErrInvalidGroup  = errors.New("invalid timeline grouping")
+)
+
+// Group is how a timeline groups photos by the date they were taken.
+type Group string
+
+const (
+	GroupYear  Group = "year"
+	GroupMonth Group = "month"
+	GroupDay   Group = "day"
+)
+
+// The layouts of dates at each grouping, which are prefixes of the
+// catalogue's local times.
+var groupLayouts = map[Group]string{
+	GroupYear:  "2006",
+	GroupMonth: "2006-01",
+	GroupDay:   "2006-01-02",
+}
+
+// ParseGroup returns the grouping called name, grouping by month by default.
+func ParseGroup(name string) (Group, error) {
+	if name == "" {
+		return GroupMonth, nil
+	}
+	if _, ok := groupLayouts[Group(name)]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrInvalidGroup, name)
+	}
+	return Group(name), nil
+}
+
+// Filter narrows down which catalogued photos are listed. From and To are
+// dates like "2024", "2024-05" or "2024-05-17", and both are inclusive, so
+// To "2024-05" takes in all of May. They are compared with the time the
+// photos were taken where they were taken. Empty ones are unbounded.
+type Filter struct {
+	RootDir string
+	From    string
+	To      string
+}
+
+// Photo is a catalogued photo. TakenAt is when it was taken, in RFC 3339
+// form, without a time zone offset when it isn't known. TakenAtSource says
+// where that came from: "exif", or "file" for the modification time.
+type Photo struct {
+	Path          string    `json:"path"`
+	Name          string    `json:"name"`
+	SizeBytes     int64     `json:"sizeBytes"`
+	ModTime       time.Time `json:"modTime"`
+	TakenAt       string    `json:"takenAt"`
+	TakenAtSource string    `json:"takenAtSource"`
+	CameraMake    string    `json:"cameraMake,omitempty"`
+	CameraModel   string    `json:"cameraModel,omitempty"`
+	LensModel     string    `json:"lensModel,omitempty"`
+	ExposureTime  string    `json:"exposureTime,omitempty"`
+	FNumber       *float64  `json:"fNumber,omitempty"`
+	ISO           *int64    `json:"iso,omitempty"`
+	FocalLength   *float64  `json:"focalLength,omitempty"`
+	Width         int64     `json:"width"`
+	Height        int64     `json:"height"`
+	Latitude      *float64  `json:"latitude,omitempty"`
+	Longitude     *float64  `json:"longitude,omitempty"`
+	ThumbnailURL  string    `json:"thumbnailUrl"`
+}
+
+// Period is a year, month or day of a timeline, like "2024-05".
+type Period struct {
+	Period string `json:"period"`
+	Count  int64  `json:"count"`
+	// CoverPath is the last photo taken in the period
+	CoverPath string `json:"coverPath"`
+}
+
+// Page is a page of photos. NextCursor continues the listing after it, and
+// is empty on the last page.
+type Page struct {
+	Photos     []Photo `json:"photos"`
+	NextCursor string  `json:"nextCursor,omitempty"`
+}
+
+// List returns a page of the catalogued photos matching filter, newest
+// first, starting after cursor, or at the start when it is empty. Pages are
+// found by their position in the catalogue's index, so later pages cost no
+// more than the first, and photos added while paging don't shift them.
+func List(filter Filter, cursor string, limit int) (Page, error) {
+	prefix, from, until, err := filterParams(filter)
+	if err != nil {
+		return Page{}, err
+	}
+	beforeTaken, beforeID, err := parseCursor(cursor)
+	if err != nil {
+		return Page{}, err
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+	// One more than asked for tells whether there is a next page
+	rows, err := db.DatabaseQueries.ListPhotos(context.Background(), db.ListPhotosParams{
+		PrefixPattern: prefix,
+		TakenFrom:     from,
+		TakenUntil:    until,
+		BeforeTaken:   beforeTaken,
+		BeforeID:      beforeID,
+		Limit:         int64(limit) + 1,
+	})
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to list photos: %w", err)
+	}
+	page := Page{Photos: make([]Photo, 0, min(len(rows), limit))}
+	for i, row := range rows {
+		if i == limit {
+			last := rows[i-1]
+			page.NextCursor = formatCursor(last.TakenLocal, last.ID)
+			break
+		}
+		page.Photos = append(page.Photos, FromRow(row))
+	}
+	return page, nil
+}
+
+// Count returns how many photos are catalogued in the folder at rootDir.
+func Count(rootDir string) (int64, error) {
+	local, err := fileutil.GetFilesRoot().CleanSlash(rootDir)
+	if err != nil {
+		return 0, err
+	}
+	count, err := db.DatabaseQueries.CountPhotos(context.Background(), db.PrefixPattern(local))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count photos: %w", err)
+	}
+	return count, nil
+}
+
+// A cursor is the sort key of the last photo of a page: its local time and
+// ID.
+func formatCursor(takenLocal string, id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(takenLocal + "/" + strconv.FormatInt(id, 10)))
+}
+
+func parseCursor(cursor string) (beforeTaken string, beforeID int64, err error) {
+	if cursor == "" {
+		// Past any local time and ID
+		return "~", math.MaxInt64, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	takenLocal, idText, ok := strings.Cut(string(decoded), "/")
+	if !ok {
+		return "", 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(idText, 10, 64)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	return takenLocal, id, nil
+}
+
+// Timeline counts the catalogued photos matching filter by the year, month
+// or day they were taken, newest first.
+func Timeline(filter Filter, group Group) ([]Period, error) {
+	layout, ok := groupLayouts[group]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidGroup, group)
+	}
+	prefix, from, until, err := filterParams(filter)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.DatabaseQueries.ListPhotoTimeline(context.Background(), db.ListPhotoTimelineParams{
+		PeriodLength:  int64(len(layout)),
+		PrefixPattern: prefix,
+		TakenFrom:     from,
+		TakenUntil:    until,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list photo timeline: %w", err)
+	}
+	periods := make([]Period, len(rows))
+	for i, row := range rows {
+		periods[i] = Period{
+			Period:    row.Period,
+			Count:     row.PhotoCount,
+			CoverPath: row.CoverPath,
+		}
+	}
+	return periods, nil
+}
+
+// filterParams turns filter into a LIKE pattern for the paths of photos in
+// its folder, and the range of local times [from, until) it covers.
+func filterParams(filter Filter) (prefix string, from string, until string, err error) {
+	local, err := fileutil.GetFilesRoot().CleanSlash(filter.RootDir)
+	if err != nil {
+		return "", "", "", err
+	}
+	prefix = db.PrefixPattern(local)
+	if filter.From != "" {
+		start, layout, err := parseDate(filter.From)
+		if err != nil {
+			return "", "", "", err
+		}
+		from = start.Format(layout)
+	}
+	// Past any local time
+	until = "~"
+	if filter.To != "" {
+		end, layout, err := parseDate(filter.To)
+		if err != nil {
+			return "", "", "", err
+		}
+		switch layout {
+		case groupLayouts[GroupYear]:
+			end = end.AddDate(1, 0, 0)
+		case groupLayouts[GroupMonth]:
+			end = end.AddDate(0, 1, 0)
+		default:
+			end = end.AddDate(0, 0, 1)
+		}
+		until = end.Format(layout)
+	}
+	return prefix, from, until, nil
+}
+
+// parseDate parses a year, month or day, returning its start and layout.
+func parseDate(value string) (time.Time, string, error) {
+	for _, group := range []Group{GroupDay, GroupMonth, GroupYear} {
+		layout := groupLayouts[group]
+		if len(value) != len(layout) {
+			continue
+		}
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, layout, nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("%w: %s", ErrInvalidDate, value)
+}
+
+// FromRow describes a row of the catalogue, for listings of photos made
+// elsewhere, like albums.
+func FromRow(row db.Photo) Photo {
+	photo := Photo{
+		Path:          row.Path,
+		Name:          path.Base(row.Path),
+		SizeBytes:     row.SizeBytes,
+		ModTime:       row.ModTime,
+		TakenAt:       takenAt(row.TakenLocal, row.TakenOffset),
+		TakenAtSource: row.TakenSource,
+		CameraMake:    row.CameraMake,
+		CameraModel:   row.CameraModel,
+		LensModel:     row.LensModel,
+		ExposureTime:  row.ExposureTime,
+		FNumber:       nullFloat(row.FNumber),
+		FocalLength:   nullFloat(row.FocalLength),
+		Width:         row.Width,
+		Height:        row.Height,
+		Latitude:      nullFloat(row.Latitude),
+		Longitude:     nullFloat(row.Longitude),
+	}
+	if row.Iso.Valid {
+		photo.ISO = &row.Iso.Int64
+	}
+	photo.ThumbnailURL = thumbnails.URL(row.Path, photoInfo{row}, thumbnails.SizeGrid)
+	return photo
+}
+
+func takenAt(local string, offset sql.NullInt64) string {
+	if !offset.Valid {
+		return local
+	}
+	taken, err := time.ParseInLocation(localTimeLayout, local, time.FixedZone("", int(offset.Int64)*60))
+	if err != nil {
+		return local
+	}
+	return taken.Format(time.RFC3339)
+}
+
+func nullFloat(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
+
+// photoInfo describes a catalogued photo as the file it was when it was
+// catalogued.
+type photoInfo struct {
+	row db.Photo
+}
+
+func (i photoInfo) Name() string {
+	return path.Base(i.row.Path)
+}
+func (i photoInfo) Size() int64 {
+	return i.row.SizeBytes
+}
+func (i photoInfo) Mode() fs.FileMode {
+	return 0644
+}
+func (i photoInfo) ModTime() time.Time {
+	return i.row.ModTime
+}
+func (i photoInfo) IsDir() bool {
+	return false
+}
+func (i photoInfo) Sys() any {
+	return i.row
+}
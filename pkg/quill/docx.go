@@ -2,6 +2,7 @@ package quill
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -11,16 +12,21 @@ import (
 )
 
 func (d Delta) SaveDocxFile(filename string) error {
-	doc, err := d.ToDocx()
-	if err != nil {
-		return fmt.Errorf("failed to convert delta to docx: %w", err)
-	}
 	writer, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to open file for saving: %w", err)
 	}
 	defer writer.Close()
-	_, err = doc.WriteTo(writer)
+	return d.WriteDocx(writer)
+}
+
+// WriteDocx converts the delta to a docx document and writes it to w.
+func (d Delta) WriteDocx(w io.Writer) error {
+	doc, err := d.ToDocx()
+	if err != nil {
+		return fmt.Errorf("failed to convert delta to docx: %w", err)
+	}
+	_, err = doc.WriteTo(w)
 	if err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
@@ -28,8 +34,17 @@ func (d Delta) SaveDocxFile(filename string) error {
 }
 
 func FromDocx(filename string) (Delta, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return Delta{}, fmt.Errorf("failed to open document: %w", err)
+	}
+	return FromDocxBytes(content)
+}
+
+// FromDocxBytes converts the contents of a docx file to a delta.
+func FromDocxBytes(content []byte) (Delta, error) {
 	delta := Delta{}
-	doc, err := docx.OpenDocument(filename)
+	doc, err := docx.Unpack(&content)
 	if err != nil {
 		return delta, fmt.Errorf("failed to open document: %w", err)
 	}
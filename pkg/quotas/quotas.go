@@ -0,0 +1,502 @@
+package quotas
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/dirsize"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Quotas cap how many bytes or files a top-level folder of the files root may
+// hold, so that one use of the disk can't crowd out the others. Writes are
+// checked against them, and against the free space on the device they land
+// on, before anything is written.
+
+var (
+	// ErrNotFound is returned for folders without a quota.
+	ErrNotFound = errors.New("quota not found")
+	// ErrInvalid is returned for folders and limits that can't be used.
+	ErrInvalid = errors.New("invalid quota")
+	// ErrInsufficientStorage is returned for writes that don't fit, wrapped
+	// in an *InsufficientError saying how much does.
+	ErrInsufficientStorage = errors.New("insufficient storage")
+)
+
+// What a write ran into when it doesn't fit.
+const (
+	LimitQuotaBytes = "quotaBytes"
+	LimitQuotaFiles = "quotaFiles"
+	LimitDevice     = "device"
+)
+
+// Quota is the limit of a top-level folder. Zero means no limit.
+type Quota struct {
+	// Folder is the path of the folder, starting with a "/".
+	Folder   string `json:"folder"`
+	MaxBytes int64  `json:"maxBytes"`
+	MaxFiles int64  `json:"maxFiles"`
+}
+
+// Usage is a quota along with how much of it is used. UsedFiles is only
+// counted for quotas with a file limit.
+type Usage struct {
+	Quota
+	UsedBytes int64 `json:"usedBytes"`
+	UsedFiles int64 `json:"usedFiles"`
+}
+
+// BytesPercent returns how much of the byte limit is used, from 0 to 100.
+func (u Usage) BytesPercent() int {
+	return percent(u.UsedBytes, u.MaxBytes)
+}
+
+// FilesPercent returns how much of the file limit is used, from 0 to 100.
+func (u Usage) FilesPercent() int {
+	return percent(u.UsedFiles, u.MaxFiles)
+}
+
+// Budget is what is left for a write that didn't fit, and what it needed.
+type Budget struct {
+	// Folder is the top-level folder whose quota applied, if any.
+	Folder string `json:"folder,omitempty"`
+	// Limit is which of the limits was reached.
+	Limit          string `json:"limit"`
+	RemainingBytes int64  `json:"remainingBytes"`
+	// RemainingFiles is only set when the folder has a file limit.
+	RemainingFiles *int64 `json:"remainingFiles,omitempty"`
+	RequiredBytes  int64  `json:"requiredBytes"`
+	RequiredFiles  int64  `json:"requiredFiles"`
+}
+
+// InsufficientError is returned for writes that don't fit.
+type InsufficientError struct {
+	Budget Budget
+}
+
+func (e *InsufficientError) Error() string {
+	b := e.Budget
+	switch b.Limit {
+	case LimitQuotaFiles:
+		return fmt.Sprintf("not enough room in %s: %d more files allowed by its quota, %d needed", b.Folder, *b.RemainingFiles, b.RequiredFiles)
+	case LimitQuotaBytes:
+		return fmt.Sprintf("not enough room in %s: %s left of its quota, %s needed", b.Folder, fileutil.SizeBytesToString(b.RemainingBytes), fileutil.SizeBytesToString(b.RequiredBytes))
+	default:
+		return fmt.Sprintf("not enough free space: %s left on the device, %s needed", fileutil.SizeBytesToString(b.RemainingBytes), fileutil.SizeBytesToString(b.RequiredBytes))
+	}
+}
+
+func (e *InsufficientError) Unwrap() error {
+	return ErrInsufficientStorage
+}
+
+// ErrorDetails passes the budget on to API clients.
+func (e *InsufficientError) ErrorDetails() any {
+	return e.Budget
+}
+
+func fromRow(row db.Quota) Quota {
+	return Quota{Folder: "/" + row.Folder, MaxBytes: row.MaxBytes, MaxFiles: row.MaxFiles}
+}
+
+// List returns every quota by folder.
+func List() ([]Quota, error) {
+	rows, err := db.DatabaseQueries.ListQuotas(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quotas: %w", err)
+	}
+	list := make([]Quota, len(rows))
+	for i, row := range rows {
+		list[i] = fromRow(row)
+	}
+	return list, nil
+}
+
+// Usages returns every quota by folder, with how much of each is used.
+func Usages() ([]Usage, error) {
+	list, err := List()
+	if err != nil {
+		return nil, err
+	}
+	usages := make([]Usage, 0, len(list))
+	for _, quota := range list {
+		usage, err := usageOf(quota)
+		if err != nil {
+			// The folder may be on a disk that isn't mounted
+			fmt.Printf("Error measuring quota of %s: %v\n", quota.Folder, err)
+			continue
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+// Get returns the quota of the top-level folder at folder, with how much of it
+// is used.
+func Get(folder string) (Usage, error) {
+	local, err := clean(folder)
+	if err != nil {
+		return Usage{}, err
+	}
+	quota, err := get(local)
+	if err != nil {
+		return Usage{}, err
+	}
+	return usageOf(quota)
+}
+
+// Set limits the top-level folder at folder to maxBytes and maxFiles,
+// replacing any quota it had.
+func Set(folder string, maxBytes int64, maxFiles int64) (Usage, error) {
+	local, err := clean(folder)
+	if err != nil {
+		return Usage{}, err
+	}
+	if maxBytes < 0 || maxFiles < 0 {
+		return Usage{}, fmt.Errorf("%w: limits can't be negative", ErrInvalid)
+	}
+	info, err := fileutil.GetFilesRoot().Stat(local)
+	if err != nil {
+		return Usage{}, err
+	}
+	if !info.IsDir() {
+		return Usage{}, fmt.Errorf("%w: only folders can have a quota", ErrInvalid)
+	}
+	row, err := db.DatabaseQueries.SetQuota(context.Background(), db.SetQuotaParams{
+		Folder:    local,
+		MaxBytes:  maxBytes,
+		MaxFiles:  maxFiles,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to set quota of %s: %w", local, err)
+	}
+	return usageOf(fromRow(row))
+}
+
+// Delete lifts the quota of the top-level folder at folder.
+func Delete(folder string) error {
+	local, err := clean(folder)
+	if err != nil {
+		return err
+	}
+	deleted, err := db.DatabaseQueries.DeleteQuota(context.Background(), local)
+	if err != nil {
+		return fmt.Errorf("failed to delete quota of %s: %w", local, err)
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Check returns an *InsufficientError if writing bytes in files new files
+// into destDir would go over the quota of its top-level folder, or over the
+// free space of the device it's on.
+func Check(destDir string, bytes int64, files int64) error {
+	local, err := fileutil.GetFilesRoot().Clean(destDir)
+	if err != nil {
+		return err
+	}
+	countFiles := func() (int64, error) { return files, nil }
+	if err := checkQuota(local, bytes, countFiles); err != nil {
+		return err
+	}
+	return checkDevice(local, bytes, countFiles)
+}
+
+// CheckCopy is Check for copying the files and folders at srcPaths into
+// destDir.
+func CheckCopy(srcPaths []string, destDir string) error {
+	root := fileutil.GetFilesRoot()
+	local, err := root.Clean(destDir)
+	if err != nil {
+		return err
+	}
+	srcLocals := make([]string, len(srcPaths))
+	var bytes int64
+	for i, srcPath := range srcPaths {
+		if srcLocals[i], err = root.Clean(srcPath); err != nil {
+			return err
+		}
+		size, err := sizeOf(srcLocals[i])
+		if err != nil {
+			return err
+		}
+		bytes += size
+	}
+	// Counting files means walking every folder, so it's only done once, and
+	// only if needed
+	counted := int64(-1)
+	countFiles := func() (int64, error) {
+		if counted >= 0 {
+			return counted, nil
+		}
+		var total int64
+		for _, srcLocal := range srcLocals {
+			count, err := root.CountFiles(srcLocal)
+			if err != nil {
+				return 0, err
+			}
+			total += count
+		}
+		counted = total
+		return counted, nil
+	}
+	if err := checkQuota(local, bytes, countFiles); err != nil {
+		return err
+	}
+	return checkDevice(local, bytes, countFiles)
+}
+
+// CheckMove is Check for moving the file or folder at srcPath into destDir.
+// Moves only take up room when they change top-level folders, and the device
+// isn't checked, since most moves are renames.
+func CheckMove(srcPath string, destDir string) error {
+	root := fileutil.GetFilesRoot()
+	srcLocal, err := root.Clean(srcPath)
+	if err != nil {
+		return err
+	}
+	local, err := root.Clean(destDir)
+	if err != nil {
+		return err
+	}
+	if topFolder(srcLocal) == topFolder(local) {
+		return nil
+	}
+	bytes, err := sizeOf(srcLocal)
+	if err != nil {
+		return err
+	}
+	return checkQuota(local, bytes, func() (int64, error) { return root.CountFiles(srcLocal) })
+}
+
+// Room returns how many bytes can still be written into destDir, the least
+// of what the quota of its top-level folder and its device have left.
+func Room(destDir string) (int64, error) {
+	root := fileutil.GetFilesRoot()
+	local, err := root.Clean(destDir)
+	if err != nil {
+		return 0, err
+	}
+	available, err := root.AvailableSpace(local)
+	if err != nil {
+		return 0, err
+	}
+	room := int64(min(available, math.MaxInt64))
+	top := topFolder(local)
+	if top == "" {
+		return room, nil
+	}
+	quota, err := get(top)
+	if errors.Is(err, ErrNotFound) || (err == nil && quota.MaxBytes == 0) {
+		return room, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	usage, err := usageOf(quota)
+	if err != nil {
+		return 0, err
+	}
+	return min(room, remainingBytes(usage)), nil
+}
+
+// Moved carries the quota of a top-level folder over to where it was moved,
+// or drops it when the folder isn't at the top anymore. replaced is what was
+// at newPath before, if anything, whose quota is dropped.
+func Moved(oldPath string, newPath string, replaced fs.FileInfo) {
+	oldLocal, oldErr := clean(oldPath)
+	newLocal, newErr := clean(newPath)
+	if replaced != nil && newErr == nil {
+		forget(newLocal)
+	}
+	if oldErr != nil {
+		return
+	}
+	if newErr != nil {
+		forget(oldLocal)
+		return
+	}
+	if err := db.DatabaseQueries.RenameQuota(context.Background(), db.RenameQuotaParams{
+		NewFolder: newLocal,
+		OldFolder: oldLocal,
+	}); err != nil {
+		fmt.Printf("Error moving quota of %s: %v\n", oldLocal, err)
+	}
+}
+
+// Removed drops the quota of a top-level folder after it was removed.
+func Removed(filePath string) {
+	if local, err := clean(filePath); err == nil {
+		forget(local)
+	}
+}
+
+func forget(local string) {
+	if _, err := db.DatabaseQueries.DeleteQuota(context.Background(), local); err != nil {
+		fmt.Printf("Error dropping quota of %s: %v\n", local, err)
+	}
+}
+
+// checkQuota checks a write into local against the quota of its top-level
+// folder. Files are only counted when the quota limits them.
+func checkQuota(local string, bytes int64, countFiles func() (int64, error)) error {
+	top := topFolder(local)
+	if top == "" {
+		return nil
+	}
+	quota, err := get(top)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	usage, err := usageOf(quota)
+	if err != nil {
+		return err
+	}
+	budget := Budget{Folder: quota.Folder, RequiredBytes: bytes}
+	if quota.MaxFiles > 0 {
+		files, err := countFiles()
+		if err != nil {
+			return err
+		}
+		remaining := max(quota.MaxFiles-usage.UsedFiles, 0)
+		budget.RemainingFiles = &remaining
+		budget.RequiredFiles = files
+		if files > remaining {
+			budget.Limit = LimitQuotaFiles
+			budget.RemainingBytes = remainingBytes(usage)
+			return &InsufficientError{Budget: budget}
+		}
+	}
+	if quota.MaxBytes > 0 {
+		budget.RemainingBytes = remainingBytes(usage)
+		if bytes > budget.RemainingBytes {
+			budget.Limit = LimitQuotaBytes
+			return &InsufficientError{Budget: budget}
+		}
+	}
+	return nil
+}
+
+// checkDevice checks a write into local against the free space of the device
+// it lands on.
+func checkDevice(local string, bytes int64, countFiles func() (int64, error)) error {
+	available, err := fileutil.GetFilesRoot().AvailableSpace(local)
+	if err != nil {
+		return err
+	}
+	if uint64(bytes) <= available {
+		return nil
+	}
+	files, err := countFiles()
+	if err != nil {
+		return err
+	}
+	return &InsufficientError{Budget: Budget{
+		Folder:         display(topFolder(local)),
+		Limit:          LimitDevice,
+		RemainingBytes: int64(available),
+		RequiredBytes:  bytes,
+		RequiredFiles:  files,
+	}}
+}
+
+func get(local string) (Quota, error) {
+	row, err := db.DatabaseQueries.GetQuota(context.Background(), local)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Quota{}, ErrNotFound
+	}
+	if err != nil {
+		return Quota{}, fmt.Errorf("failed to get quota of %s: %w", local, err)
+	}
+	return fromRow(row), nil
+}
+
+func usageOf(quota Quota) (Usage, error) {
+	local := strings.TrimPrefix(quota.Folder, "/")
+	usage := Usage{Quota: quota}
+	var err error
+	if usage.UsedBytes, err = sizeOf(local); err != nil {
+		return Usage{}, err
+	}
+	if quota.MaxFiles > 0 {
+		if usage.UsedFiles, err = fileutil.GetFilesRoot().CountFiles(local); err != nil {
+			return Usage{}, err
+		}
+	}
+	return usage, nil
+}
+
+func remainingBytes(usage Usage) int64 {
+	if usage.MaxBytes == 0 {
+		return 0
+	}
+	return max(usage.MaxBytes-usage.UsedBytes, 0)
+}
+
+// sizeOf returns the size of the file or folder at local, using the cached
+// size of folders where it's current.
+func sizeOf(local string) (int64, error) {
+	root := fileutil.GetFilesRoot()
+	info, err := root.Lstat(local)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		return info.Size(), nil
+	}
+	if size, ok := dirsize.Cached(local); ok {
+		return size, nil
+	}
+	return root.FolderSize(local)
+}
+
+// clean returns the path of a folder that can have a quota, which is any
+// folder directly in the files root.
+func clean(folder string) (string, error) {
+	local, err := fileutil.GetFilesRoot().CleanSlash(folder)
+	if err != nil {
+		return "", err
+	}
+	if local == "." || strings.Contains(local, "/") {
+		return "", fmt.Errorf("%w: only top-level folders can have a quota", ErrInvalid)
+	}
+	return local, nil
+}
+
+// topFolder returns the top-level folder that local is in, or "" for the
+// files root itself.
+func topFolder(local string) string {
+	local = filepath.ToSlash(local)
+	if local == "." {
+		return ""
+	}
+	top, _, _ := strings.Cut(local, "/")
+	return top
+}
+
+func display(local string) string {
+	if local == "" {
+		return ""
+	}
+	return "/" + local
+}
+
+func percent(used int64, limit int64) int {
+	if limit <= 0 {
+		return 0
+	}
+	return int(min(used*100/limit, 100))
+}
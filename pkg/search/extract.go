@@ -0,0 +1,213 @@
+package search
+
+import (
+	"archive/zip"
+	"autobutler/pkg/docx"
+	"autobutler/pkg/docx/types"
+	"autobutler/pkg/util/fileutil"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxIndexedTextBytes caps how much text of a single file is indexed.
+	maxIndexedTextBytes = 1 << 20
+	// maxDocumentBytes caps the size of documents that are unpacked for
+	// indexing, since they are read into memory.
+	maxDocumentBytes = 64 << 20
+)
+
+// extractText returns the searchable text of a file, or "" for files whose
+// content isn't indexed.
+func extractText(root *fileutil.Root, path string, fileType fileutil.FileType, size int64) (string, error) {
+	switch fileType {
+	case fileutil.FileTypeGeneric, fileutil.FileTypeCode, fileutil.FileTypeMarkdown:
+		return extractPlainText(root, path)
+	case fileutil.FileTypeDocx:
+		if size > maxDocumentBytes {
+			return "", nil
+		}
+		content, err := root.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return extractDocxText(content)
+	case fileutil.FileTypeEpub:
+		if size > maxDocumentBytes {
+			return "", nil
+		}
+		return extractEpubText(root, path, size)
+	default:
+		if fileutil.IsTextFile(path) {
+			return extractPlainText(root, path)
+		}
+		return "", nil
+	}
+}
+
+func extractPlainText(root *fileutil.Root, path string) (string, error) {
+	file, err := root.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, maxIndexedTextBytes))
+	if err != nil {
+		return "", err
+	}
+	if !isText(content) {
+		return "", nil
+	}
+	return strings.ToValidUTF8(string(content), ""), nil
+}
+
+func isText(content []byte) bool {
+	contentType := http.DetectContentType(content)
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.HasPrefix(contentType, "application/json") ||
+		strings.HasPrefix(contentType, "application/xml")
+}
+
+func extractDocxText(content []byte) (string, error) {
+	doc, err := docx.Unpack(&content)
+	if err != nil {
+		return "", err
+	}
+	var text textBuilder
+	for _, child := range doc.Document.Body.Children {
+		para, ok := child.(*docx.Paragraph)
+		if !ok {
+			continue
+		}
+		for _, paraChild := range para.Children {
+			var run *docx.Run
+			switch paraChild := paraChild.(type) {
+			case *docx.Hyperlink:
+				run = paraChild.Run
+			case *docx.Run:
+				run = paraChild
+			}
+			if run == nil {
+				continue
+			}
+			for _, runChild := range run.Children {
+				if t, ok := runChild.(*types.Text); ok {
+					text.WriteString(t.Text)
+				}
+			}
+		}
+		text.WriteString("\n")
+	}
+	return text.String(), nil
+}
+
+// extractEpubText collects the text of every (X)HTML document in an epub, in
+// archive order.
+func extractEpubText(root *fileutil.Root, path string, size int64) (string, error) {
+	file, err := root.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	archive, err := zip.NewReader(file, size)
+	if err != nil {
+		return "", err
+	}
+	var text textBuilder
+	for _, entry := range archive.File {
+		if text.Full() {
+			break
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name))
+		if ext != ".xhtml" && ext != ".html" && ext != ".htm" {
+			continue
+		}
+		reader, err := entry.Open()
+		if err != nil {
+			return "", err
+		}
+		err = extractMarkupText(reader, &text)
+		reader.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return text.String(), nil
+}
+
+// extractMarkupText writes the character data of an (X)HTML document into
+// text, leaving out scripts and styles.
+func extractMarkupText(r io.Reader, text *textBuilder) error {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	var skipped []string
+	for !text.Full() {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			// Keep whatever could be read from malformed documents
+			return nil
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if name := strings.ToLower(token.Name.Local); name == "script" || name == "style" {
+				skipped = append(skipped, name)
+			}
+		case xml.EndElement:
+			if len(skipped) > 0 && strings.ToLower(token.Name.Local) == skipped[len(skipped)-1] {
+				skipped = skipped[:len(skipped)-1]
+			}
+			text.WriteString(" ")
+		case xml.CharData:
+			if len(skipped) == 0 {
+				text.Write(token)
+			}
+		}
+	}
+	return nil
+}
+
+// textBuilder is a strings.Builder that stops growing at
+// maxIndexedTextBytes, and collapses runs of whitespace.
+type textBuilder struct {
+	strings.Builder
+	lastSpace bool
+}
+
+func (b *textBuilder) Full() bool {
+	return b.Len() >= maxIndexedTextBytes
+}
+
+func (b *textBuilder) Write(p []byte) {
+	b.WriteString(string(p))
+}
+
+func (b *textBuilder) WriteString(s string) {
+	for _, r := range s {
+		if b.Full() {
+			return
+		}
+		if r == utf8.RuneError {
+			continue
+		}
+		isSpace := unicode.IsSpace(r)
+		if isSpace && b.lastSpace {
+			continue
+		}
+		b.lastSpace = isSpace
+		if isSpace {
+			r = ' '
+		}
+		b.WriteRune(r)
+	}
+}
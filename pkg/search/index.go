@@ -0,0 +1,258 @@
+package search
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/util/fileutil"
+	"autobutler/pkg/util/queueutil"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const rescanInterval = time.Hour
+
+// The index is updated by a single worker, so that updates for the same path
+// are applied in the order they were made.
+var queue = queueutil.NewQueue("updating search index")
+
+// StartIndexer schedules a full scan of the files root now and every hour
+// after, to pick up changes made outside of the API.
+func StartIndexer() {
+	go func() {
+		for {
+			queue.Push(scanAll)
+			time.Sleep(rescanInterval)
+		}
+	}()
+}
+
+// IndexPath (re-)indexes a file, or a folder and everything below it. The
+// path is relative to the files root.
+func IndexPath(filePath string) {
+	queue.Push(func() error {
+		return indexTree(filePath)
+	})
+}
+
+// RemovePath removes a file, or a folder and everything below it, from the
+// index.
+func RemovePath(filePath string) {
+	queue.Push(func() error {
+		return removeTree(filePath)
+	})
+}
+
+// MovePath updates the index after a file or folder has been moved from
+// oldPath to newPath.
+func MovePath(oldPath string, newPath string) {
+	queue.Push(func() error {
+		return moveTree(oldPath, newPath)
+	})
+}
+
+// scanAll brings the whole index in line with the files root, re-indexing
+// files whose size or modification time changed and dropping ones that are
+// gone.
+func scanAll() error {
+	ctx := context.Background()
+	indexed, err := db.DatabaseQueries.ListIndexedFiles(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list indexed files: %w", err)
+	}
+	stale := make(map[string]db.IndexedFile, len(indexed))
+	for _, file := range indexed {
+		stale[file.Path] = file
+	}
+	root := fileutil.GetFilesRoot()
+	err = root.WalkDir(".", func(walkPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable entries rather than giving up on the scan
+			return nil
+		}
+		if walkPath == "." {
+			return nil
+		}
+		existing, found := stale[walkPath]
+		delete(stale, walkPath)
+		info, err := root.Stat(walkPath)
+		if err != nil {
+			return nil
+		}
+		if found && existing.SizeBytes == info.Size() && existing.ModTime.Equal(info.ModTime().UTC()) {
+			return nil
+		}
+		return indexFile(root, walkPath, info)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan files: %w", err)
+	}
+	return db.InTx(ctx, func(q *db.Queries) error {
+		for _, file := range stale {
+			if err := deleteIndexedFile(ctx, q, file.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func indexTree(filePath string) error {
+	root := fileutil.GetFilesRoot()
+	local, err := root.Clean(filePath)
+	if err != nil {
+		return err
+	}
+	return root.WalkDir(local, func(walkPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if walkPath == "." {
+			return nil
+		}
+		info, err := root.Stat(walkPath)
+		if err != nil {
+			return nil
+		}
+		return indexFile(root, walkPath, info)
+	})
+}
+
+func indexFile(root *fileutil.Root, filePath string, info fs.FileInfo) error {
+	ctx := context.Background()
+	filePath = filepath.ToSlash(filePath)
+	fileType := fileutil.FileTypeFolder
+	size := int64(0)
+	if !info.IsDir() {
+		fileType = root.DetectFileType(filePath)
+		size = info.Size()
+	}
+	content := ""
+	if !info.IsDir() {
+		var err error
+		if content, err = extractText(root, filePath, fileType, size); err != nil {
+			// Still index the name, so the file can be found
+			fmt.Printf("Error extracting text from %s: %v\n", filePath, err)
+			content = ""
+		}
+	}
+	// indexed_files and file_index are kept in step
+	return db.InTx(ctx, func(q *db.Queries) error {
+		file, err := q.UpsertIndexedFile(ctx, db.UpsertIndexedFileParams{
+			Path:      filePath,
+			Name:      path.Base(filePath),
+			FileType:  string(fileType),
+			SizeBytes: size,
+			ModTime:   info.ModTime().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to index %s: %w", filePath, err)
+		}
+		if err := q.SetFileIndexContent(ctx, file.ID, file.Name, file.Path, content); err != nil {
+			return fmt.Errorf("failed to index content of %s: %w", filePath, err)
+		}
+		return nil
+	})
+}
+
+func removeTree(filePath string) error {
+	ctx := context.Background()
+	return db.InTx(ctx, func(q *db.Queries) error {
+		return deleteTree(ctx, q, filePath)
+	})
+}
+
+// deleteTree removes the indexed file at filePath and everything indexed
+// below it.
+func deleteTree(ctx context.Context, q *db.Queries, filePath string) error {
+	files, err := listTree(ctx, q, filePath)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := deleteIndexedFile(ctx, q, file.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func moveTree(oldPath string, newPath string) error {
+	ctx := context.Background()
+	root := fileutil.GetFilesRoot()
+	newLocal, err := root.CleanSlash(newPath)
+	if err != nil {
+		return err
+	}
+	oldLocal, err := root.CleanSlash(oldPath)
+	if err != nil {
+		return err
+	}
+	moved := false
+	err = db.InTx(ctx, func(q *db.Queries) error {
+		// Whatever was at the destination has been replaced
+		if err := deleteTree(ctx, q, newLocal); err != nil {
+			return err
+		}
+		files, err := listTree(ctx, q, oldLocal)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			movedPath := newLocal + strings.TrimPrefix(file.Path, oldLocal)
+			movedName := path.Base(movedPath)
+			if err := q.UpdateIndexedFilePath(ctx, db.UpdateIndexedFilePathParams{
+				Path: movedPath,
+				Name: movedName,
+				ID:   file.ID,
+			}); err != nil {
+				return fmt.Errorf("failed to move %s in index: %w", file.Path, err)
+			}
+			if err := q.UpdateFileIndexPath(ctx, file.ID, movedName, movedPath); err != nil {
+				return fmt.Errorf("failed to move %s in index: %w", file.Path, err)
+			}
+		}
+		moved = len(files) > 0
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !moved {
+		// The source was never indexed, so index it from scratch
+		return indexTree(newLocal)
+	}
+	return nil
+}
+
+// listTree returns the indexed file at filePath followed by everything
+// indexed below it.
+func listTree(ctx context.Context, q *db.Queries, filePath string) ([]db.IndexedFile, error) {
+	local, err := fileutil.GetFilesRoot().CleanSlash(filePath)
+	if err != nil {
+		return nil, err
+	}
+	files, err := q.ListIndexedFilesUnder(ctx, db.ListIndexedFilesUnderParams{
+		Path:          local,
+		PrefixPattern: db.PrefixPattern(local),
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to list indexed files under %s: %w", local, err)
+	}
+	return files, nil
+}
+
+func deleteIndexedFile(ctx context.Context, q *db.Queries, id int64) error {
+	if err := q.DeleteFileIndexContent(ctx, id); err != nil {
+		return fmt.Errorf("failed to remove file from index: %w", err)
+	}
+	if err := q.DeleteIndexedFile(ctx, id); err != nil {
+		return fmt.Errorf("failed to remove file from index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package search
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"errors"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+var ErrEmptyQuery = errors.New("search query is empty")
+
+// Result is a file matching a search. NameHighlight and Snippet are HTML,
+// with matched terms wrapped in <mark> elements.
+type Result struct {
+	Path          string            `json:"path"`
+	Name          string            `json:"name"`
+	FileType      fileutil.FileType `json:"fileType"`
+	SizeBytes     int64             `json:"sizeBytes"`
+	ModTime       time.Time         `json:"modTime"`
+	NameHighlight string            `json:"nameHighlight"`
+	Snippet       string            `json:"snippet"`
+}
+
+// Search finds files whose name, path or content match query. Words in the
+// query match as prefixes, "quoted phrases" match exactly, and all of them
+// must match. When fileTypes is non-empty, only files of those types are
+// returned.
+func Search(query string, fileTypes []fileutil.FileType, limit int, offset int) ([]Result, error) {
+	match := parseQuery(query)
+	if match == "" {
+		return nil, ErrEmptyQuery
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+	offset = max(offset, 0)
+	types := make([]string, len(fileTypes))
+	for i, fileType := range fileTypes {
+		types[i] = string(fileType)
+	}
+	rows, err := db.DatabaseQueries.SearchFileIndex(context.Background(), db.FileIndexSearchParams{
+		Match:     match,
+		FileTypes: types,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, len(rows))
+	for i, row := range rows {
+		results[i] = Result{
+			Path:          "/" + row.Path,
+			Name:          row.Name,
+			FileType:      fileutil.FileType(row.FileType),
+			SizeBytes:     row.SizeBytes,
+			ModTime:       row.ModTime,
+			NameHighlight: highlightToHTML(row.NameHighlight),
+			Snippet:       highlightToHTML(row.Snippet),
+		}
+	}
+	return results, nil
+}
+
+// parseQuery turns user input into an FTS5 query. Every term is quoted so
+// that FTS5 operators and column filters in the input are matched literally.
+func parseQuery(query string) string {
+	var terms []string
+	addTerm := func(term string, prefix bool) {
+		term = strings.TrimSpace(term)
+		if !hasWordChars(term) {
+			return
+		}
+		quoted := `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		if prefix {
+			quoted += "*"
+		}
+		terms = append(terms, quoted)
+	}
+	for {
+		start := strings.IndexByte(query, '"')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(query[start+1:], '"')
+		if end < 0 {
+			// An unterminated quote is treated as plain words
+			query = query[:start] + " " + query[start+1:]
+			break
+		}
+		for _, word := range strings.Fields(query[:start]) {
+			addTerm(word, true)
+		}
+		addTerm(query[start+1:start+1+end], false)
+		query = query[start+1+end+1:]
+	}
+	for _, word := range strings.Fields(query) {
+		addTerm(word, true)
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// hasWordChars reports whether term contains anything the tokenizer keeps;
+// a term made only of punctuation would be an FTS5 syntax error.
+func hasWordChars(term string) bool {
+	return strings.IndexFunc(term, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsNumber(r)
+	}) >= 0
+}
+
+func highlightToHTML(text string) string {
+	return strings.NewReplacer(
+		db.HighlightStart, "<mark>",
+		db.HighlightEnd, "</mark>",
+	).Replace(html.EscapeString(text))
+}
@@ -0,0 +1,408 @@
+package shares
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// A share link is "/s/<token>", where the token is a random ID followed by
+// its signature. Tokens that weren't issued by this server are turned away
+// before the database is ever asked about them.
+
+const (
+	expiryCleanupInterval = time.Hour
+	keyFileName           = "share.key"
+	idLength              = 16
+	signatureLength       = 16
+)
+
+// DownloadGrantLifetime is how long a download can go on, in as many ranges
+// as it takes, before fetching the file again counts as another download.
+const DownloadGrantLifetime = time.Hour
+
+var (
+	// ErrNotFound is returned for tokens that were never issued or have been
+	// revoked.
+	ErrNotFound = errors.New("share not found")
+	// ErrExpired is returned for shares past their expiry, or that have been
+	// downloaded as many times as they allow.
+	ErrExpired = errors.New("share has expired")
+	// ErrOutsideShare is returned for paths that aren't inside a shared
+	// folder.
+	ErrOutsideShare = errors.New("path is not part of the share")
+	// ErrInvalidOptions is returned when creating a share that could never be
+	// used.
+	ErrInvalidOptions = errors.New("invalid share options")
+)
+
+var (
+	key     []byte
+	keyErr  error
+	keyOnce sync.Once
+)
+
+// Share is a link giving read-only access to a file or folder to anyone who
+// has it, and the password if there is one.
+type Share struct {
+	Token       string `json:"token"`
+	URL         string `json:"url"`
+	Path        string `json:"path"`
+	IsDir       bool   `json:"isDir"`
+	HasPassword bool   `json:"hasPassword"`
+	// MaxDownloads is 0 for shares that can be downloaded any number of
+	// times.
+	MaxDownloads int64      `json:"maxDownloads"`
+	Downloads    int64      `json:"downloads"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	id           string
+	passwordHash string
+}
+
+// Options restrict a new share. The zero value shares forever, with no
+// password and no limit on downloads.
+type Options struct {
+	ExpiresAt    *time.Time
+	Password     string
+	MaxDownloads int64
+}
+
+// Create shares the file or folder at filePath, relative to the files root.
+func Create(filePath string, options Options) (Share, error) {
+	root := fileutil.GetFilesRoot()
+	local, err := root.Clean(filePath)
+	if err != nil {
+		return Share{}, err
+	}
+	if _, err := root.Stat(local); err != nil {
+		return Share{}, err
+	}
+	if options.MaxDownloads < 0 {
+		return Share{}, fmt.Errorf("%w: max downloads must not be negative", ErrInvalidOptions)
+	}
+	now := time.Now().UTC()
+	expiresAt := sql.NullTime{}
+	if options.ExpiresAt != nil {
+		if !options.ExpiresAt.After(now) {
+			return Share{}, fmt.Errorf("%w: expiry must be in the future", ErrInvalidOptions)
+		}
+		expiresAt = sql.NullTime{Time: options.ExpiresAt.UTC(), Valid: true}
+	}
+	passwordHash := ""
+	if options.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(options.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return Share{}, fmt.Errorf("failed to hash password: %w", err)
+		}
+		passwordHash = string(hash)
+	}
+	id, err := newID()
+	if err != nil {
+		return Share{}, err
+	}
+	share, err := db.DatabaseQueries.CreateShare(context.Background(), db.CreateShareParams{
+		ID:           id,
+		Path:         filepath.ToSlash(local),
+		PasswordHash: passwordHash,
+		MaxDownloads: options.MaxDownloads,
+		CreatedAt:    now,
+		ExpiresAt:    expiresAt,
+	})
+	if err != nil {
+		return Share{}, fmt.Errorf("failed to record share: %w", err)
+	}
+	return fromRow(share)
+}
+
+// Get returns the share a token grants access to, as long as it hasn't
+// expired.
+func Get(token string) (Share, error) {
+	share, err := Find(token)
+	if err != nil {
+		return Share{}, err
+	}
+	if share.Expired() {
+		return Share{}, ErrExpired
+	}
+	return share, nil
+}
+
+// Find returns the share a token grants access to, even if it has expired.
+func Find(token string) (Share, error) {
+	id, ok := verify(token)
+	if !ok {
+		return Share{}, ErrNotFound
+	}
+	row, err := db.DatabaseQueries.GetShare(context.Background(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Share{}, ErrNotFound
+	}
+	if err != nil {
+		return Share{}, fmt.Errorf("failed to get share: %w", err)
+	}
+	return fromRow(row)
+}
+
+// List returns every share that hasn't expired yet, newest first.
+func List() ([]Share, error) {
+	rows, err := db.DatabaseQueries.ListShares(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shares: %w", err)
+	}
+	list := make([]Share, 0, len(rows))
+	for _, row := range rows {
+		share, err := fromRow(row)
+		if err != nil {
+			return nil, err
+		}
+		if !share.Expired() {
+			list = append(list, share)
+		}
+	}
+	return list, nil
+}
+
+// Revoke deletes a share, so its link stops working straight away.
+func Revoke(token string) error {
+	id, ok := verify(token)
+	if !ok {
+		return ErrNotFound
+	}
+	deleted, err := db.DatabaseQueries.DeleteShare(context.Background(), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke share: %w", err)
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CountDownload records a download of the share, returning ErrExpired if it
+// has already been downloaded as many times as it allows.
+func (s Share) CountDownload() error {
+	counted, err := db.DatabaseQueries.CountShareDownload(context.Background(), s.id)
+	if err != nil {
+		return fmt.Errorf("failed to count download: %w", err)
+	}
+	if counted == 0 {
+		return ErrExpired
+	}
+	return nil
+}
+
+// DownloadGrant returns a key letting whoever downloaded filePath of the
+// share fetch it again, without counting another download, until it expires.
+// It's kept in a cookie, so that players fetching a video in many ranges
+// count as one download, even the last one the share allows. Grants never
+// outlive the share.
+func (s Share) DownloadGrant(filePath string) (string, error) {
+	expiresAt := time.Now().Add(DownloadGrantLifetime)
+	if s.ExpiresAt != nil && s.ExpiresAt.Before(expiresAt) {
+		expiresAt = *s.ExpiresAt
+	}
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	signature, err := sign("download:" + s.id + ":" + filePath + ":" + expires)
+	if err != nil {
+		return "", err
+	}
+	return expires + "." + signature, nil
+}
+
+// CheckDownloadGrant reports whether grant was handed out for downloading
+// filePath of the share, and hasn't expired.
+func (s Share) CheckDownloadGrant(filePath string, grant string) bool {
+	expires, signature, ok := strings.Cut(grant, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !time.Now().Before(time.Unix(unix, 0)) {
+		return false
+	}
+	expected, err := sign("download:" + s.id + ":" + filePath + ":" + expires)
+	return err == nil && hmac.Equal([]byte(signature), []byte(expected))
+}
+
+// Expired reports whether the share is past its expiry or out of downloads.
+func (s Share) Expired() bool {
+	if s.ExpiresAt != nil && !time.Now().Before(*s.ExpiresAt) {
+		return true
+	}
+	return s.MaxDownloads > 0 && s.Downloads >= s.MaxDownloads
+}
+
+// CheckPassword reports whether password unlocks the share.
+func (s Share) CheckPassword(password string) bool {
+	if s.passwordHash == "" {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(s.passwordHash), []byte(password)) == nil
+}
+
+// AccessKey returns a key proving the password of the share was given, to be
+// kept in a cookie. It changes along with the password.
+func (s Share) AccessKey() (string, error) {
+	return sign("access:" + s.id + ":" + s.passwordHash)
+}
+
+// CheckAccessKey reports whether accessKey was handed out for this share.
+// Shares without a password need no key.
+func (s Share) CheckAccessKey(accessKey string) bool {
+	if s.passwordHash == "" {
+		return true
+	}
+	expected, err := s.AccessKey()
+	return err == nil && hmac.Equal([]byte(accessKey), []byte(expected))
+}
+
+// Resolve returns the path relative to the files root of subPath inside a
+// shared folder. A shared file only resolves an empty subPath, to itself.
+// Symlinks in a shared folder only resolve if they stay inside it.
+func (s Share) Resolve(subPath string) (string, error) {
+	subPath = strings.Trim(filepath.ToSlash(subPath), "/")
+	if subPath == "" {
+		return s.Path, nil
+	}
+	if !s.IsDir || !filepath.IsLocal(filepath.FromSlash(subPath)) {
+		return "", ErrOutsideShare
+	}
+	filePath := filepath.ToSlash(filepath.Join(s.Path, subPath))
+	root := fileutil.GetFilesRoot()
+	shareDir, err := root.Abs(s.Path)
+	if err != nil {
+		return "", err
+	}
+	fullPath, err := root.Abs(filePath)
+	if err != nil && !errors.Is(err, fileutil.ErrEscapesRoot) {
+		return "", err
+	}
+	if rel, relErr := filepath.Rel(shareDir, fullPath); err != nil || relErr != nil || !filepath.IsLocal(rel) {
+		return "", ErrOutsideShare
+	}
+	return filePath, nil
+}
+
+// DeleteExpired removes every share that has passed its expiry.
+func DeleteExpired() error {
+	now := sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	if err := db.DatabaseQueries.DeleteExpiredShares(context.Background(), now); err != nil {
+		return fmt.Errorf("failed to delete expired shares: %w", err)
+	}
+	return nil
+}
+
+// StartExpiryCleanup periodically deletes shares that have expired.
+func StartExpiryCleanup() {
+	go func() {
+		for {
+			if err := DeleteExpired(); err != nil {
+				fmt.Printf("Error deleting expired shares: %v\n", err)
+			}
+			time.Sleep(expiryCleanupInterval)
+		}
+	}()
+}
+
+func fromRow(row db.Share) (Share, error) {
+	token, err := tokenFor(row.ID)
+	if err != nil {
+		return Share{}, err
+	}
+	share := Share{
+		Token:        token,
+		URL:          "/s/" + token,
+		Path:         "/" + row.Path,
+		HasPassword:  row.PasswordHash != "",
+		MaxDownloads: row.MaxDownloads,
+		Downloads:    row.Downloads,
+		CreatedAt:    row.CreatedAt,
+		id:           row.ID,
+		passwordHash: row.PasswordHash,
+	}
+	if row.ExpiresAt.Valid {
+		share.ExpiresAt = &row.ExpiresAt.Time
+	}
+	// Folders can be replaced by files and back, so check every time
+	if info, err := fileutil.GetFilesRoot().Stat(row.Path); err == nil {
+		share.IsDir = info.IsDir()
+	}
+	return share, nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, idLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate share ID: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func tokenFor(id string) (string, error) {
+	signature, err := sign(id)
+	if err != nil {
+		return "", err
+	}
+	return id + "." + signature, nil
+}
+
+// verify returns the ID of a token if its signature is valid.
+func verify(token string) (string, bool) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", false
+	}
+	expected, err := sign(id)
+	if err != nil {
+		return "", false
+	}
+	return id, hmac.Equal([]byte(signature), []byte(expected))
+}
+
+func sign(message string) (string, error) {
+	key, err := getKey()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:signatureLength]), nil
+}
+
+// getKey loads the key share tokens are signed with, generating it the first
+// time. Deleting the key file invalidates every link handed out so far.
+func getKey() ([]byte, error) {
+	keyOnce.Do(func() {
+		keyPath := filepath.Join(fileutil.GetDataDir(), keyFileName)
+		key, keyErr = os.ReadFile(keyPath)
+		if keyErr == nil || !errors.Is(keyErr, fs.ErrNotExist) {
+			return
+		}
+		key = make([]byte, 32)
+		if _, keyErr = rand.Read(key); keyErr != nil {
+			return
+		}
+		keyErr = os.WriteFile(keyPath, key, 0o600)
+	})
+	if keyErr != nil {
+		return nil, fmt.Errorf("failed to load share key: %w", keyErr)
+	}
+	return key, nil
+}
@@ -0,0 +1,194 @@
+package stars
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/fsevents"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Stars mark the files and folders that are opened all the time, to get back
+// to them quickly. Like tags, they are kept by path relative to the files
+// root, and follow files as they are moved or deleted through the API.
+
+// ErrInvalid is returned for paths that can't be starred.
+var ErrInvalid = errors.New("invalid star")
+
+// Add stars the file or folder at filePath.
+func Add(filePath string) error {
+	local, err := clean(filePath)
+	if err != nil {
+		return err
+	}
+	if _, err := fileutil.GetFilesRoot().Stat(local); err != nil {
+		return err
+	}
+	if err := db.DatabaseQueries.AddStar(context.Background(), db.AddStarParams{
+		Path:      local,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return fmt.Errorf("failed to star %s: %w", local, err)
+	}
+	fsevents.Changed(local)
+	return nil
+}
+
+// Remove unstars the file or folder at filePath.
+func Remove(filePath string) error {
+	local, err := clean(filePath)
+	if err != nil {
+		return err
+	}
+	if err := db.DatabaseQueries.RemoveStar(context.Background(), local); err != nil {
+		return fmt.Errorf("failed to unstar %s: %w", local, err)
+	}
+	fsevents.Changed(local)
+	return nil
+}
+
+// IsStarred reports whether the file or folder at filePath is starred.
+func IsStarred(filePath string) (bool, error) {
+	local, err := clean(filePath)
+	if errors.Is(err, ErrInvalid) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	count, err := db.DatabaseQueries.CountStar(context.Background(), local)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up star of %s: %w", local, err)
+	}
+	return count > 0, nil
+}
+
+// Paths returns the paths of the starred files and folders, relative to the
+// files root.
+func Paths() ([]string, error) {
+	paths, err := db.DatabaseQueries.ListStarredPaths(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list starred files: %w", err)
+	}
+	return paths, nil
+}
+
+// InDir returns which entries of the folder at dirPath are starred, by entry
+// name.
+func InDir(dirPath string) (map[string]bool, error) {
+	local, err := fileutil.GetFilesRoot().CleanSlash(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	prefix := db.PrefixPattern(local)
+	paths, err := db.DatabaseQueries.ListStarredPathsInDir(context.Background(), db.ListStarredPathsInDirParams{
+		PrefixPattern: prefix,
+		NestedPattern: prefix + "/%",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stars in %s: %w", local, err)
+	}
+	byName := make(map[string]bool, len(paths))
+	for _, starred := range paths {
+		byName[path.Base(starred)] = true
+	}
+	return byName, nil
+}
+
+// Among returns which of the files and folders at paths are starred, by path.
+func Among(paths []string) (map[string]bool, error) {
+	starred, err := Paths()
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]bool)
+	for _, filePath := range paths {
+		local := strings.TrimPrefix(filepath.ToSlash(filePath), "/")
+		if _, found := slices.BinarySearch(starred, local); found {
+			byPath[local] = true
+		}
+	}
+	return byPath, nil
+}
+
+// ListFileInfos describes the starred files and folders for the file
+// explorer, folders first. Ones that are gone, like those on a disk that
+// isn't mounted, are left out.
+func ListFileInfos(folderSize func(dirPath string) (int64, bool)) ([]fs.FileInfo, error) {
+	paths, err := Paths()
+	if err != nil {
+		return nil, err
+	}
+	files := fileutil.GetFilesRoot().StatPaths(paths, folderSize)
+	slices.SortStableFunc(files, func(a, b fs.FileInfo) int {
+		if a.IsDir() == b.IsDir() {
+			return 0
+		}
+		if a.IsDir() {
+			return -1
+		}
+		return 1
+	})
+	return files, nil
+}
+
+// Moved carries the stars of a file or folder, and everything in it, over
+// from oldPath to newPath. replaced is what was at newPath before, if
+// anything, whose stars are dropped.
+func Moved(oldPath string, newPath string, replaced fs.FileInfo) {
+	oldLocal, err := clean(oldPath)
+	if err != nil {
+		return
+	}
+	newLocal, err := clean(newPath)
+	if err != nil {
+		return
+	}
+	if replaced != nil {
+		forget(newLocal)
+	}
+	if err := db.DatabaseQueries.RenameStars(context.Background(), db.RenameStarsParams{
+		NewPath:       newLocal,
+		OldPath:       oldLocal,
+		PrefixPattern: db.PrefixPattern(oldLocal),
+	}); err != nil {
+		fmt.Printf("Error moving stars of %s: %v\n", oldLocal, err)
+	}
+}
+
+// Removed drops the stars of a file or folder, and everything in it, after
+// it was removed.
+func Removed(filePath string) {
+	if local, err := clean(filePath); err == nil {
+		forget(local)
+	}
+}
+
+func forget(local string) {
+	if err := db.DatabaseQueries.DeleteStarsUnder(context.Background(), db.DeleteStarsUnderParams{
+		Path:          local,
+		PrefixPattern: db.PrefixPattern(local),
+	}); err != nil {
+		fmt.Printf("Error dropping stars of %s: %v\n", local, err)
+	}
+}
+
+// clean returns the path of a file or folder that can be starred, which is
+// anything but the files root itself.
+func clean(filePath string) (string, error) {
+	local, err := fileutil.GetFilesRoot().CleanSlash(filePath)
+	if err != nil {
+		return "", err
+	}
+	if local == "." {
+		return "", fmt.Errorf("%w: the files root can't be starred", ErrInvalid)
+	}
+	return local, nil
+}
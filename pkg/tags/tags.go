@@ -0,0 +1,381 @@
+package tags
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/fsevents"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Tags cut across the folder hierarchy, so that files can be found by topic
+// wherever they are. They are kept by path relative to the files root, and
+// follow files as they are moved or deleted through the API.
+
+const maxNameLength = 64
+
+var (
+	// ErrNotFound is returned for tags that don't exist.
+	ErrNotFound = errors.New("tag not found")
+	// ErrExists is returned when creating or renaming a tag to the name of
+	// another one.
+	ErrExists = errors.New("a tag with that name already exists")
+	// ErrInvalid is returned for names and colours that can't be used.
+	ErrInvalid = errors.New("invalid tag")
+)
+
+// Colors are the colours offered for new tags. Tags created without one get
+// the next in turn.
+var Colors = []string{
+	"#ef4444", "#f97316", "#eab308", "#22c55e", "#14b8a6",
+	"#3b82f6", "#8b5cf6", "#ec4899", "#6b7280",
+}
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Tag is a label given to any number of files and folders.
+type Tag struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	// FileCount is only filled in by List.
+	FileCount int64 `json:"fileCount"`
+}
+
+func fromRow(row db.Tag) Tag {
+	return Tag{ID: row.ID, Name: row.Name, Color: row.Color}
+}
+
+// List returns every tag by name, with how many files have it.
+func List() ([]Tag, error) {
+	rows, err := db.DatabaseQueries.ListTags(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	list := make([]Tag, len(rows))
+	for i, row := range rows {
+		list[i] = Tag{ID: row.ID, Name: row.Name, Color: row.Color, FileCount: row.FileCount}
+	}
+	return list, nil
+}
+
+// Get returns the tag with the given ID.
+func Get(id int64) (Tag, error) {
+	row, err := db.DatabaseQueries.GetTag(context.Background(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Tag{}, ErrNotFound
+	}
+	if err != nil {
+		return Tag{}, fmt.Errorf("failed to get tag: %w", err)
+	}
+	return fromRow(row), nil
+}
+
+// Create adds a tag. Names are unique regardless of case. An empty colour
+// picks one from Colors.
+func Create(name string, color string) (Tag, error) {
+	name, err := validName(name)
+	if err != nil {
+		return Tag{}, err
+	}
+	if color == "" {
+		existing, err := db.DatabaseQueries.ListTags(context.Background())
+		if err != nil {
+			return Tag{}, fmt.Errorf("failed to list tags: %w", err)
+		}
+		color = Colors[len(existing)%len(Colors)]
+	}
+	if err := checkColor(color); err != nil {
+		return Tag{}, err
+	}
+	if err := checkUnused(name, 0); err != nil {
+		return Tag{}, err
+	}
+	row, err := db.DatabaseQueries.CreateTag(context.Background(), db.CreateTagParams{
+		Name:      name,
+		Color:     strings.ToLower(color),
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return Tag{}, fmt.Errorf("failed to create tag: %w", err)
+	}
+	return fromRow(row), nil
+}
+
+// Update renames and recolours a tag. Empty values are left as they are.
+func Update(id int64, name string, color string) (Tag, error) {
+	tag, err := Get(id)
+	if err != nil {
+		return Tag{}, err
+	}
+	if name != "" {
+		if tag.Name, err = validName(name); err != nil {
+			return Tag{}, err
+		}
+		if err := checkUnused(tag.Name, id); err != nil {
+			return Tag{}, err
+		}
+	}
+	if color != "" {
+		if err := checkColor(color); err != nil {
+			return Tag{}, err
+		}
+		tag.Color = strings.ToLower(color)
+	}
+	row, err := db.DatabaseQueries.UpdateTag(context.Background(), db.UpdateTagParams{
+		Name:  tag.Name,
+		Color: tag.Color,
+		ID:    id,
+	})
+	if err != nil {
+		return Tag{}, fmt.Errorf("failed to update tag: %w", err)
+	}
+	return fromRow(row), nil
+}
+
+// Delete removes a tag from every file that has it, and then the tag itself.
+func Delete(id int64) error {
+	paths, err := db.DatabaseQueries.ListTaggedPaths(context.Background(), id)
+	if err != nil {
+		return fmt.Errorf("failed to list tagged files: %w", err)
+	}
+	if err := db.DatabaseQueries.DeleteFileTagsForTag(context.Background(), id); err != nil {
+		return fmt.Errorf("failed to untag files: %w", err)
+	}
+	deleted, err := db.DatabaseQueries.DeleteTag(context.Background(), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	for _, local := range paths {
+		fsevents.Changed(local)
+	}
+	return nil
+}
+
+// Add tags the file or folder at filePath.
+func Add(filePath string, id int64) error {
+	local, err := clean(filePath)
+	if err != nil {
+		return err
+	}
+	if _, err := Get(id); err != nil {
+		return err
+	}
+	if _, err := fileutil.GetFilesRoot().Stat(local); err != nil {
+		return err
+	}
+	if err := db.DatabaseQueries.AddFileTag(context.Background(), db.AddFileTagParams{
+		Path:  local,
+		TagID: id,
+	}); err != nil {
+		return fmt.Errorf("failed to tag %s: %w", local, err)
+	}
+	fsevents.Changed(local)
+	return nil
+}
+
+// Remove takes a tag off the file or folder at filePath.
+func Remove(filePath string, id int64) error {
+	local, err := clean(filePath)
+	if err != nil {
+		return err
+	}
+	if err := db.DatabaseQueries.RemoveFileTag(context.Background(), db.RemoveFileTagParams{
+		Path:  local,
+		TagID: id,
+	}); err != nil {
+		return fmt.Errorf("failed to untag %s: %w", local, err)
+	}
+	fsevents.Changed(local)
+	return nil
+}
+
+// ForPath returns the tags of the file or folder at filePath.
+func ForPath(filePath string) ([]Tag, error) {
+	local, err := clean(filePath)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.DatabaseQueries.ListTagsForPath(context.Background(), local)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %w", local, err)
+	}
+	list := make([]Tag, len(rows))
+	for i, row := range rows {
+		list[i] = fromRow(row)
+	}
+	return list, nil
+}
+
+// InDir returns the tags of the entries of the folder at dirPath, by entry
+// name.
+func InDir(dirPath string) (map[string][]Tag, error) {
+	local, err := fileutil.GetFilesRoot().CleanSlash(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	prefix := db.PrefixPattern(local)
+	rows, err := db.DatabaseQueries.ListFileTagsInDir(context.Background(), db.ListFileTagsInDirParams{
+		PrefixPattern: prefix,
+		NestedPattern: prefix + "/%",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags in %s: %w", local, err)
+	}
+	byName := make(map[string][]Tag)
+	for _, row := range rows {
+		name := path.Base(row.Path)
+		byName[name] = append(byName[name], Tag{ID: row.ID, Name: row.Name, Color: row.Color})
+	}
+	return byName, nil
+}
+
+// Paths returns the paths of the files and folders with a tag, relative to
+// the files root.
+func Paths(id int64) ([]string, error) {
+	if _, err := Get(id); err != nil {
+		return nil, err
+	}
+	paths, err := db.DatabaseQueries.ListTaggedPaths(context.Background(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tagged files: %w", err)
+	}
+	return paths, nil
+}
+
+// ListFileInfos describes the files and folders with a tag for the file
+// explorer, folders first. Ones that are gone, like those on a disk that
+// isn't mounted, are left out.
+func ListFileInfos(id int64, folderSize func(dirPath string) (int64, bool)) ([]fs.FileInfo, error) {
+	paths, err := Paths(id)
+	if err != nil {
+		return nil, err
+	}
+	files := fileutil.GetFilesRoot().StatPaths(paths, folderSize)
+	// Folders first, like in the rest of the explorer
+	slices.SortStableFunc(files, func(a, b fs.FileInfo) int {
+		if a.IsDir() == b.IsDir() {
+			return 0
+		}
+		if a.IsDir() {
+			return -1
+		}
+		return 1
+	})
+	return files, nil
+}
+
+// ForPaths returns the tags of each of the files and folders at paths, for
+// showing them wherever they are.
+func ForPaths(paths []string) (map[string][]Tag, error) {
+	byPath := make(map[string][]Tag, len(paths))
+	for _, filePath := range paths {
+		list, err := ForPath(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if len(list) > 0 {
+			byPath[strings.TrimPrefix(filepath.ToSlash(filePath), "/")] = list
+		}
+	}
+	return byPath, nil
+}
+
+// Moved carries the tags of a file or folder, and everything in it, over
+// from oldPath to newPath. replaced is what was at newPath before, if
+// anything, whose tags are dropped.
+func Moved(oldPath string, newPath string, replaced fs.FileInfo) {
+	oldLocal, err := clean(oldPath)
+	if err != nil {
+		return
+	}
+	newLocal, err := clean(newPath)
+	if err != nil {
+		return
+	}
+	if replaced != nil {
+		forget(newLocal)
+	}
+	if err := db.DatabaseQueries.RenameFileTags(context.Background(), db.RenameFileTagsParams{
+		NewPath:       newLocal,
+		OldPath:       oldLocal,
+		PrefixPattern: db.PrefixPattern(oldLocal),
+	}); err != nil {
+		fmt.Printf("Error moving tags of %s: %v\n", oldLocal, err)
+	}
+}
+
+// Removed drops the tags of a file or folder, and everything in it, after
+// it was removed.
+func Removed(filePath string) {
+	if local, err := clean(filePath); err == nil {
+		forget(local)
+	}
+}
+
+func forget(local string) {
+	if err := db.DatabaseQueries.DeleteFileTagsUnder(context.Background(), db.DeleteFileTagsUnderParams{
+		Path:          local,
+		PrefixPattern: db.PrefixPattern(local),
+	}); err != nil {
+		fmt.Printf("Error dropping tags of %s: %v\n", local, err)
+	}
+}
+
+// clean returns the path of a file or folder that can be tagged, which is
+// anything but the files root itself.
+func clean(filePath string) (string, error) {
+	local, err := fileutil.GetFilesRoot().CleanSlash(filePath)
+	if err != nil {
+		return "", err
+	}
+	if local == "." {
+		return "", fmt.Errorf("%w: the files root can't be tagged", ErrInvalid)
+	}
+	return local, nil
+}
+
+func validName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxNameLength {
+		return "", fmt.Errorf("%w: names must be 1 to %d characters", ErrInvalid, maxNameLength)
+	}
+	if strings.ContainsAny(name, "/\\") {
+		return "", fmt.Errorf("%w: names can't contain slashes", ErrInvalid)
+	}
+	return name, nil
+}
+
+func checkColor(color string) error {
+	if !colorPattern.MatchString(color) {
+		return fmt.Errorf("%w: colours must look like #rrggbb", ErrInvalid)
+	}
+	return nil
+}
+
+// checkUnused returns ErrExists if a tag other than id is called name.
+func checkUnused(name string, id int64) error {
+	existing, err := db.DatabaseQueries.GetTagByName(context.Background(), name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up tag: %w", err)
+	}
+	if existing.ID != id {
+		return ErrExists
+	}
+	return nil
+}
@@ -0,0 +1,393 @@
+package thumbnails
+
+import (
+	"autobutler/pkg/util/fileutil"
+	"autobutler/pkg/util/imageutil"
+	"autobutler/pkg/util/queueutil"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cacheDirectoryName = "thumbnails"
+	evictionInterval   = 24 * time.Hour
+	// maxUnusedAge is how long a thumbnail is kept without being served.
+	// Thumbnails of files that changed or went away are never served again,
+	// so this is also how long they linger.
+	maxUnusedAge = 30 * 24 * time.Hour
+	// touchInterval limits how often serving a thumbnail marks it as used.
+	touchInterval = 24 * time.Hour
+	jpegQuality   = 85
+)
+
+// ErrUnknownSize is returned for size names that aren't one of Sizes.
+var ErrUnknownSize = errors.New("unknown thumbnail size")
+
+// Size is a named bounding box that thumbnails are scaled to fit.
+type Size struct {
+	Name   string
+	Width  uint
+	Height uint
+}
+
+var (
+	// SizeGrid is for grids of many files, like the photo grid.
+	SizeGrid = Size{Name: "grid", Width: 400, Height: 400}
+	// SizePreview is for a single file shown in part of the page.
+	SizePreview = Size{Name: "preview", Width: 1024, Height: 1024}
+	// SizeFull is for a single file shown full screen.
+	SizeFull = Size{Name: "full", Width: 2048, Height: 2048}
+	// Sizes are every size thumbnails are made in.
+	Sizes = []Size{SizeGrid, SizePreview, SizeFull}
+)
+
+// ParseSize returns the size called name, or SizeGrid when name is empty.
+func ParseSize(name string) (Size, error) {
+	if name == "" {
+		return SizeGrid, nil
+	}
+	for _, size := range Sizes {
+		if size.Name == name {
+			return size, nil
+		}
+	}
+	return Size{}, fmt.Errorf("%w: %q", ErrUnknownSize, name)
+}
+
+// Thumbnail is a cached thumbnail, ready to be served.
+type Thumbnail struct {
+	// Path is where it is on disk.
+	Path        string
+	ContentType string
+	ETag        string
+	ModTime     time.Time
+	// Version identifies the version of the file it was made from, for URLs
+	// that can be cached for good.
+	Version string
+}
+
+// format is a way thumbnails are encoded, with the extension they are cached
+// under.
+type format struct {
+	ext         string
+	contentType string
+	encode      func(w io.Writer, img image.Image) error
+}
+
+var formats = []format{
+	{".jpg", "image/jpeg", func(w io.Writer, img image.Image) error {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	}},
+	// Only for thumbnails with transparency, which JPEG can't keep
+	{".png", "image/png", png.Encode},
+}
+
+var (
+	queue = queueutil.NewQueue("generating thumbnails")
+	// slots limits how many thumbnails are made at once, since each one
+	// decodes a full size image.
+	slots = make(chan struct{}, runtime.NumCPU())
+	// generating holds a channel per cache key being generated, closed once
+	// it is done, so that concurrent requests only make it once.
+	generating      = map[string]chan struct{}{}
+	generatingMutex sync.Mutex
+	// cacheDir is GetCacheDir, only created once
+	cacheDir = sync.OnceValue(GetCacheDir)
+)
+
+// GetCacheDir returns the directory that thumbnails are cached in.
+func GetCacheDir() string {
+	cachePath := filepath.Join(fileutil.GetDataDir(), cacheDirectoryName)
+	if err := os.MkdirAll(cachePath, 0755); err != nil {
+		panic(fmt.Sprintf("failed to create thumbnail cache directory: %v", err))
+	}
+	return cachePath
+}
+
+// Version identifies the version of a file described by info.
+func Version(info fs.FileInfo) string {
+	return fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size())
+}
+
+// URL returns the URL of the thumbnail of the file at filePath in the files
+// root, described by info. It changes along with the file, so it can be
+// cached for good.
+func URL(filePath string, info fs.FileInfo, size Size) string {
+	escaped := (&url.URL{Path: "/api/v1/thumbnails/" + strings.TrimPrefix(filepath.ToSlash(filePath), "/")}).EscapedPath()
+	return escaped + "?size=" + url.QueryEscape(size.Name) + "&v=" + url.QueryEscape(Version(info))
+}
+
+// cacheKey identifies the thumbnail of the file at local, as described by
+// info, in size.
+func cacheKey(local string, info fs.FileInfo, size Size) string {
+	sum := sha256.Sum256([]byte(filepath.ToSlash(local) + "\x00" + size.Name + "\x00" + Version(info)))
+	return hex.EncodeToString(sum[:])
+}
+
+func cachePath(key string, ext string) string {
+	return filepath.Join(cacheDir(), key[:2], key+ext)
+}
+
+// lookup returns the cached thumbnail for key, if there is one.
+func lookup(key string) (Thumbnail, bool) {
+	for _, format := range formats {
+		path := cachePath(key, format.ext)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if time.Since(info.ModTime()) > touchInterval {
+			now := time.Now()
+			if err := os.Chtimes(path, now, now); err != nil {
+				fmt.Printf("Error marking thumbnail %s as used: %v\n", path, err)
+			}
+		}
+		return Thumbnail{
+			Path:        path,
+			ContentType: format.contentType,
+			ETag:        `"` + key + `"`,
+			ModTime:     info.ModTime(),
+		}, true
+	}
+	return Thumbnail{}, false
+}
+
+// Get returns the thumbnail of the file at filePath in size, making it if it
+// isn't cached yet. Files that thumbnails can't be made of return
+// imageutil.ErrNoThumbnail.
+func Get(filePath string, size Size) (Thumbnail, error) {
+	root := fileutil.GetFilesRoot()
+	local, err := root.Clean(filePath)
+	if err != nil {
+		return Thumbnail{}, err
+	}
+	info, err := root.Stat(local)
+	if err != nil {
+		return Thumbnail{}, err
+	}
+	if info.IsDir() {
+		return Thumbnail{}, fmt.Errorf("%w: %s", imageutil.ErrNoThumbnail, fileutil.FileTypeFolder)
+	}
+	key := cacheKey(local, info, size)
+	for {
+		if thumbnail, ok := lookup(key); ok {
+			thumbnail.Version = Version(info)
+			return thumbnail, nil
+		}
+		generatingMutex.Lock()
+		done, busy := generating[key]
+		if !busy {
+			done = make(chan struct{})
+			generating[key] = done
+		}
+		generatingMutex.Unlock()
+		if busy {
+			// Look again once the other request is done with it
+			<-done
+			continue
+		}
+		thumbnail, err := generate(local, key, size)
+		generatingMutex.Lock()
+		delete(generating, key)
+		close(done)
+		generatingMutex.Unlock()
+		if err != nil {
+			return Thumbnail{}, err
+		}
+		thumbnail.Version = Version(info)
+		return thumbnail, nil
+	}
+}
+
+// generate makes the thumbnail of the file at local and caches it under key.
+func generate(local string, key string, size Size) (Thumbnail, error) {
+	slots <- struct{}{}
+	defer func() { <-slots }()
+
+	root := fileutil.GetFilesRoot()
+	file, err := root.Open(local)
+	if err != nil {
+		return Thumbnail{}, err
+	}
+	defer file.Close()
+	img, _, err := imageutil.Thumbnail(root.DetectFileType(local), file, size.Width, size.Height)
+	if err != nil {
+		return Thumbnail{}, err
+	}
+	format := formats[0]
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+		format = formats[1]
+	}
+
+	path := cachePath(key, format.ext)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Thumbnail{}, err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+key+".*.tmp")
+	if err != nil {
+		return Thumbnail{}, err
+	}
+	// Only cleans up after a failure, since the file is gone once renamed
+	defer os.Remove(temp.Name())
+	defer temp.Close()
+	if err := format.encode(temp, img); err != nil {
+		return Thumbnail{}, fmt.Errorf("error encoding thumbnail: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return Thumbnail{}, err
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return Thumbnail{}, err
+	}
+	thumbnail, ok := lookup(key)
+	if !ok {
+		return Thumbnail{}, fmt.Errorf("thumbnail of %s went missing", local)
+	}
+	return thumbnail, nil
+}
+
+// Added makes the grid thumbnails of a new file, or of everything in a new
+// folder, in the background, so they are ready by the time they are asked
+// for. A file whose thumbnail can't be made doesn't stop the rest of the
+// folder.
+func Added(filePath string) {
+	queue.Push(func() error {
+		root := fileutil.GetFilesRoot()
+		return root.WalkDir(filePath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || root.DetectFileType(path) != fileutil.FileTypeImage {
+				return nil
+			}
+			if _, err := Get(path, SizeGrid); err != nil && !errors.Is(err, imageutil.ErrNoThumbnail) {
+				fmt.Printf("Error making thumbnail of %s: %v\n", path, err)
+			}
+			return nil
+		})
+	})
+}
+
+// Removed drops the thumbnails of a file that was removed, where info is
+// what it was before. Those of folders are left to be evicted once unused,
+// as are those of files moved to the trash, which are described by when they
+// were deleted rather than modified. They are still there should the files
+// be restored.
+func Removed(filePath string, info fs.FileInfo) {
+	if info == nil || info.IsDir() {
+		return
+	}
+	local, err := fileutil.GetFilesRoot().Clean(filePath)
+	if err != nil {
+		return
+	}
+	for _, size := range Sizes {
+		key := cacheKey(local, info, size)
+		for _, format := range formats {
+			if err := os.Remove(cachePath(key, format.ext)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				fmt.Printf("Error removing thumbnail of %s: %v\n", filePath, err)
+			}
+		}
+	}
+}
+
+// Moved moves the thumbnails of a file, or of everything in a folder, that
+// was moved from oldPath to newPath along with it. Moving keeps modification
+// times, so the old cache keys can be worked out from what is at newPath.
+func Moved(oldPath string, newPath string) {
+	queue.Push(func() error {
+		root := fileutil.GetFilesRoot()
+		oldLocal, err := root.Clean(oldPath)
+		if err != nil {
+			return err
+		}
+		newLocal, err := root.Clean(newPath)
+		if err != nil {
+			return err
+		}
+		return root.WalkDir(newLocal, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(newLocal, filepath.FromSlash(path))
+			if err != nil {
+				return err
+			}
+			for _, size := range Sizes {
+				oldKey := cacheKey(filepath.Join(oldLocal, rel), info, size)
+				newKey := cacheKey(filepath.FromSlash(path), info, size)
+				for _, format := range formats {
+					if err := os.MkdirAll(filepath.Dir(cachePath(newKey, format.ext)), 0755); err != nil {
+						return err
+					}
+					err := os.Rename(cachePath(oldKey, format.ext), cachePath(newKey, format.ext))
+					if err != nil && !errors.Is(err, fs.ErrNotExist) {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// Evict removes thumbnails that haven't been served for a while, including
+// those of files that have since changed or gone away.
+func Evict() error {
+	return filepath.WalkDir(cacheDir(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		// Temporary files are only left behind by crashes
+		unused := time.Since(info.ModTime()) > maxUnusedAge
+		abandoned := strings.HasSuffix(d.Name(), ".tmp") && time.Since(info.ModTime()) > time.Hour
+		if unused || abandoned {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// StartEviction periodically evicts unused thumbnails in the background.
+func StartEviction() {
+	go func() {
+		for {
+			if err := Evict(); err != nil {
+				fmt.Printf("Error evicting thumbnails: %v\n", err)
+			}
+			time.Sleep(evictionInterval)
+		}
+	}()
+}
@@ -0,0 +1,301 @@
+package trash
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/dirsize"
+	"autobutler/pkg/jobs"
+	"autobutler/pkg/util/fileutil"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxAge      = 30 * 24 * time.Hour
+	defaultMaxSizeGB   = 10
+	autoPurgeInterval  = time.Hour
+	maxAgeDaysEnvVar   = "AUTOBUTLER_TRASH_MAX_AGE_DAYS"
+	maxSizeGBEnvVar    = "AUTOBUTLER_TRASH_MAX_SIZE_GB"
+	trashDirectoryName = ".trash"
+)
+
+var (
+	trashRoot     *fileutil.Root
+	trashRootOnce sync.Once
+	// mutex serialises trash operations, so that purges never race a restore
+	// of the same item.
+	mutex sync.Mutex
+)
+
+// GetTrashDir returns the hidden directory that deleted files are moved to.
+func GetTrashDir() string {
+	trashPath := filepath.Join(fileutil.GetDataDir(), trashDirectoryName)
+	if err := os.MkdirAll(trashPath, 0755); err != nil {
+		panic(fmt.Sprintf("failed to create trash directory: %v", err))
+	}
+	return trashPath
+}
+
+func getTrashRoot() *fileutil.Root {
+	trashRootOnce.Do(func() {
+		root, err := fileutil.OpenRoot(GetTrashDir())
+		if err != nil {
+			panic(fmt.Sprintf("failed to open trash root: %v", err))
+		}
+		trashRoot = root
+	})
+	return trashRoot
+}
+
+// MoveToTrash moves a path of the files root into the trash and records where
+// it came from, so it can later be restored.
+func MoveToTrash(filePath string) (*db.TrashItem, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	filesRoot := fileutil.GetFilesRoot()
+	originalPath, err := filesRoot.Clean(filePath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := filesRoot.Lstat(originalPath)
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if info.IsDir() {
+		cachedSize, ok := dirsize.Cached(originalPath)
+		if ok {
+			size = cachedSize
+		} else if size, err = filesRoot.FolderSize(originalPath); err != nil {
+			return nil, err
+		}
+	}
+	deletedAt := time.Now().UTC()
+	trashName, release, err := getTrashRoot().AvailablePath(fmt.Sprintf("%d_%s", deletedAt.UnixNano(), info.Name()))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := fileutil.MoveBetweenRoots(filesRoot, originalPath, getTrashRoot(), trashName); err != nil {
+		return nil, fmt.Errorf("failed to move %s to trash: %w", filePath, err)
+	}
+	item, err := db.DatabaseQueries.CreateTrashItem(context.Background(), db.CreateTrashItemParams{
+		OriginalPath: originalPath,
+		TrashName:    trashName,
+		IsDir:        info.IsDir(),
+		SizeBytes:    size,
+		DeletedAt:    deletedAt,
+	})
+	if err != nil {
+		// Put the file back rather than leaving it in the trash untracked
+		if restoreErr := fileutil.MoveBetweenRoots(getTrashRoot(), trashName, filesRoot, originalPath); restoreErr != nil {
+			return nil, fmt.Errorf("failed to record trash item: %w (and failed to restore it: %v)", err, restoreErr)
+		}
+		return nil, fmt.Errorf("failed to record trash item: %w", err)
+	}
+	return &item, nil
+}
+
+// List returns every item in the trash, most recently deleted first.
+func List() ([]db.TrashItem, error) {
+	items, err := db.DatabaseQueries.ListTrashItems(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash items: %w", err)
+	}
+	return items, nil
+}
+
+// Restore moves a trash item back to its original path. If something else has
+// taken that path in the meantime, the item is restored next to it with the
+// usual "name_(n).ext" suffix. It returns the path the item was restored to.
+func Restore(id int64) (string, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	item, err := db.DatabaseQueries.GetTrashItem(context.Background(), id)
+	if err != nil {
+		return "", fmt.Errorf("trash item %d not found: %w", id, err)
+	}
+	filesRoot := fileutil.GetFilesRoot()
+	if err := filesRoot.MkdirAll(filepath.Dir(item.OriginalPath), 0755); err != nil {
+		return "", err
+	}
+	restorePath, release, err := filesRoot.AvailablePath(item.OriginalPath)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	if err := fileutil.MoveBetweenRoots(getTrashRoot(), item.TrashName, filesRoot, restorePath); err != nil {
+		return "", fmt.Errorf("failed to restore %s: %w", item.OriginalPath, err)
+	}
+	if err := db.DatabaseQueries.DeleteTrashItem(context.Background(), item.ID); err != nil {
+		return "", fmt.Errorf("failed to remove trash item record: %w", err)
+	}
+	return restorePath, nil
+}
+
+// Delete permanently deletes a single trash item.
+func Delete(id int64) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	item, err := db.DatabaseQueries.GetTrashItem(context.Background(), id)
+	if err != nil {
+		return fmt.Errorf("trash item %d not found: %w", id, err)
+	}
+	return deleteItem(item)
+}
+
+// Empty permanently deletes everything in the trash, stopping early when ctx
+// is canceled.
+func Empty(ctx context.Context, progress *jobs.Progress) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	items, err := List()
+	if err != nil {
+		return err
+	}
+	var totalBytes int64
+	for _, item := range items {
+		totalBytes += item.SizeBytes
+	}
+	progress.SetTotals(int64(len(items)), totalBytes)
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := deleteItem(item); err != nil {
+			return err
+		}
+		progress.AddItems(1)
+		progress.AddBytes(item.SizeBytes)
+	}
+	return nil
+}
+
+// Purge permanently deletes items older than maxAge, and then the oldest items
+// until the trash holds no more than maxBytes.
+func Purge(maxAge time.Duration, maxBytes int64) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	expired, err := db.DatabaseQueries.ListTrashItemsDeletedBefore(context.Background(), time.Now().UTC().Add(-maxAge))
+	if err != nil {
+		return fmt.Errorf("failed to list expired trash items: %w", err)
+	}
+	for _, item := range expired {
+		if err := deleteItem(item); err != nil {
+			return err
+		}
+	}
+
+	items, err := List()
+	if err != nil {
+		return err
+	}
+	var totalBytes int64
+	for _, item := range items {
+		totalBytes += item.SizeBytes
+	}
+	// Items are listed newest first, so evict from the back.
+	for i := len(items) - 1; i >= 0 && totalBytes > maxBytes; i-- {
+		if err := deleteItem(items[i]); err != nil {
+			return err
+		}
+		totalBytes -= items[i].SizeBytes
+	}
+	return nil
+}
+
+// StartAutoPurge periodically purges the trash in the background, using the
+// limits configured through the environment.
+func StartAutoPurge() {
+	maxAge, maxBytes := getPurgeLimits()
+	go func() {
+		for {
+			if err := Purge(maxAge, maxBytes); err != nil {
+				fmt.Printf("Error purging trash: %v\n", err)
+			}
+			time.Sleep(autoPurgeInterval)
+		}
+	}()
+}
+
+func getPurgeLimits() (time.Duration, int64) {
+	maxAge := defaultMaxAge
+	if days, err := strconv.Atoi(os.Getenv(maxAgeDaysEnvVar)); err == nil && days > 0 {
+		maxAge = time.Duration(days) * 24 * time.Hour
+	}
+	maxSizeGB := float64(defaultMaxSizeGB)
+	if gb, err := strconv.ParseFloat(os.Getenv(maxSizeGBEnvVar), 64); err == nil && gb > 0 {
+		maxSizeGB = gb
+	}
+	return maxAge, int64(fileutil.GBToBytes(maxSizeGB))
+}
+
+func deleteItem(item db.TrashItem) error {
+	if err := getTrashRoot().RemoveAll(item.TrashName); err != nil {
+		return fmt.Errorf("failed to delete %s from trash: %w", item.OriginalPath, err)
+	}
+	if err := db.DatabaseQueries.DeleteTrashItem(context.Background(), item.ID); err != nil {
+		return fmt.Errorf("failed to remove trash item record: %w", err)
+	}
+	return nil
+}
+
+// ItemInfo exposes a trash item as an fs.FileInfo, so that it can be rendered
+// by the file explorer views.
+type ItemInfo struct {
+	Item db.TrashItem
+}
+
+func (i ItemInfo) Name() string {
+	return filepath.Base(i.Item.OriginalPath)
+}
+func (i ItemInfo) Size() int64 {
+	return i.Item.SizeBytes
+}
+func (i ItemInfo) Mode() fs.FileMode {
+	if i.Item.IsDir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+func (i ItemInfo) ModTime() time.Time {
+	return i.Item.DeletedAt
+}
+func (i ItemInfo) IsDir() bool {
+	return i.Item.IsDir
+}
+func (i ItemInfo) Sys() any {
+	return i.Item
+}
+
+// ListFileInfos returns every item in the trash as an fs.FileInfo.
+func ListFileInfos() ([]fs.FileInfo, error) {
+	items, err := List()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]fs.FileInfo, len(items))
+	for i, item := range items {
+		files[i] = ItemInfo{Item: item}
+	}
+	return files, nil
+}
+
+// ItemID returns the ID of the trash item behind a file returned by
+// ListFileInfos, or 0 if it is not a trash item.
+func ItemID(file fs.FileInfo) int64 {
+	if item, ok := file.Sys().(db.TrashItem); ok {
+		return item.ID
+	}
+	return 0
+}
@@ -0,0 +1,370 @@
+package uploads
+
+import (
+	"autobutler/pkg/db"
+	"autobutler/pkg/util/fileutil"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultExpiry          = 24 * time.Hour
+	expiryCleanupInterval  = time.Hour
+	expiryHoursEnvVar      = "AUTOBUTLER_UPLOAD_EXPIRY_HOURS"
+	stagingDirectoryName   = ".uploads"
+	stagingFilePermissions = 0644
+)
+
+var (
+	// ErrNotFound is returned for uploads that never existed, have expired or
+	// have already been completed or terminated.
+	ErrNotFound = errors.New("upload not found")
+	// ErrOffsetMismatch is returned when a chunk does not start where the
+	// staged data ends.
+	ErrOffsetMismatch = errors.New("upload offset does not match")
+	// ErrChecksumMismatch is returned when a chunk does not match the checksum
+	// sent along with it. The chunk is discarded.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrExceedsLength is returned when a chunk would grow the upload past its
+	// declared length.
+	ErrExceedsLength = errors.New("upload exceeds declared length")
+	// ErrUnsupportedChecksum is returned for checksum algorithms other than
+	// the ones listed in ChecksumAlgorithms.
+	ErrUnsupportedChecksum = errors.New("unsupported checksum algorithm")
+	// ErrInvalidDestination is returned when an upload is created with an
+	// empty file name, or a root directory that isn't a directory.
+	ErrInvalidDestination = errors.New("invalid upload destination")
+)
+
+// ChecksumAlgorithms lists the checksum algorithms that chunks can be
+// verified with.
+var ChecksumAlgorithms = []string{"md5", "sha1", "sha256"}
+
+var (
+	stagingRoot     *fileutil.Root
+	stagingRootOnce sync.Once
+	// uploadLocks holds a *sync.Mutex per upload ID, so that chunks of the
+	// same upload are never written concurrently.
+	uploadLocks sync.Map
+)
+
+// Checksum is the expected checksum of a single chunk.
+type Checksum struct {
+	Algorithm string
+	Sum       []byte
+}
+
+// GetStagingDir returns the hidden directory that partial uploads are staged
+// in until they are complete.
+func GetStagingDir() string {
+	stagingPath := filepath.Join(fileutil.GetDataDir(), stagingDirectoryName)
+	if err := os.MkdirAll(stagingPath, 0755); err != nil {
+		panic(fmt.Sprintf("failed to create upload staging directory: %v", err))
+	}
+	return stagingPath
+}
+
+func getStagingRoot() *fileutil.Root {
+	stagingRootOnce.Do(func() {
+		root, err := fileutil.OpenRoot(GetStagingDir())
+		if err != nil {
+			panic(fmt.Sprintf("failed to open upload staging root: %v", err))
+		}
+		stagingRoot = root
+	})
+	return stagingRoot
+}
+
+// Create starts a new upload of sizeBytes bytes, which will be saved as
+// fileName inside rootDir of the files root once complete.
+func Create(rootDir string, fileName string, sizeBytes int64, metadata string) (*db.Upload, error) {
+	filesRoot := fileutil.GetFilesRoot()
+	rootDir, err := filesRoot.Clean(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	fileName = filepath.Base(filepath.Clean("/" + fileName))
+	if fileName == "/" || fileName == "." {
+		return nil, fmt.Errorf("%w: missing file name", ErrInvalidDestination)
+	}
+	if info, err := filesRoot.Stat(rootDir); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s is not a directory", ErrInvalidDestination, rootDir)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	file, err := getStagingRoot().OpenFile(id, os.O_WRONLY|os.O_CREATE|os.O_EXCL, stagingFilePermissions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	upload, err := db.DatabaseQueries.CreateUpload(context.Background(), db.CreateUploadParams{
+		ID:        id,
+		FileName:  fileName,
+		RootDir:   rootDir,
+		SizeBytes: sizeBytes,
+		Metadata:  metadata,
+		CreatedAt: now,
+		ExpiresAt: now.Add(getExpiry()),
+	})
+	if err != nil {
+		getStagingRoot().Remove(id)
+		return nil, fmt.Errorf("failed to record upload: %w", err)
+	}
+	return &upload, nil
+}
+
+// Get returns an upload along with the number of bytes received so far.
+func Get(id string) (*db.Upload, int64, error) {
+	upload, err := get(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset, err := stagedBytes(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return upload, offset, nil
+}
+
+// Append writes a chunk read from r to the upload, which must start at
+// offset. If checksum is not nil, the chunk is verified against it and
+// discarded when it doesn't match. Once the last byte has been received the
+// upload is moved into place, and completedPath is the path it was saved to
+// relative to the files root.
+func Append(id string, offset int64, r io.Reader, checksum *Checksum) (newOffset int64, completedPath string, err error) {
+	defer lockUpload(id)()
+
+	upload, err := get(id)
+	if err != nil {
+		return 0, "", err
+	}
+	var hasher hash.Hash
+	if checksum != nil {
+		if hasher, err = newHash(checksum.Algorithm); err != nil {
+			return 0, "", err
+		}
+	}
+
+	file, err := getStagingRoot().OpenFile(id, os.O_WRONLY, stagingFilePermissions)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to open staging file: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+	if info.Size() != offset {
+		return info.Size(), "", ErrOffsetMismatch
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, "", err
+	}
+
+	// Read one byte past the declared length, so that oversized chunks can
+	// be told apart from ones that end exactly on it.
+	remaining := upload.SizeBytes - offset
+	reader := io.LimitReader(r, remaining+1)
+	if hasher != nil {
+		reader = io.TeeReader(reader, hasher)
+	}
+	written, copyErr := io.Copy(file, reader)
+	newOffset = offset + written
+	switch {
+	case written > remaining:
+		copyErr = ErrExceedsLength
+	case copyErr == nil && hasher != nil && !bytes.Equal(hasher.Sum(nil), checksum.Sum):
+		copyErr = ErrChecksumMismatch
+	}
+	if copyErr != nil && (hasher != nil || errors.Is(copyErr, ErrExceedsLength)) {
+		// Data that can't be verified has to be sent again.
+		if err := file.Truncate(offset); err != nil {
+			return offset, "", err
+		}
+		return offset, "", copyErr
+	}
+	if err := file.Sync(); err != nil {
+		return offset, "", err
+	}
+	if err := db.DatabaseQueries.UpdateUploadExpiry(context.Background(), db.UpdateUploadExpiryParams{
+		ExpiresAt: time.Now().UTC().Add(getExpiry()),
+		ID:        id,
+	}); err != nil {
+		return newOffset, "", fmt.Errorf("failed to extend upload expiry: %w", err)
+	}
+	// A client that drops the connection keeps whatever has arrived, so it
+	// can resume from there.
+	if copyErr != nil {
+		return newOffset, "", copyErr
+	}
+	if newOffset < upload.SizeBytes {
+		return newOffset, "", nil
+	}
+	if err := file.Close(); err != nil {
+		return newOffset, "", err
+	}
+	completedPath, err = finish(upload)
+	return newOffset, completedPath, err
+}
+
+// Terminate abandons an upload, deleting everything received so far.
+func Terminate(id string) error {
+	defer lockUpload(id)()
+
+	upload, err := get(id)
+	if err != nil {
+		return err
+	}
+	return deleteUpload(*upload)
+}
+
+// ExpiresAt returns when an upload will be discarded if no more data arrives.
+func ExpiresAt(id string) (time.Time, error) {
+	upload, err := get(id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return upload.ExpiresAt, nil
+}
+
+// DeleteExpired discards every upload that has passed its expiry time.
+func DeleteExpired() error {
+	expired, err := db.DatabaseQueries.ListUploadsExpiredBefore(context.Background(), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to list expired uploads: %w", err)
+	}
+	for _, upload := range expired {
+		unlock := lockUpload(upload.ID)
+		err := deleteUpload(upload)
+		unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartExpiryCleanup periodically discards expired uploads in the background.
+func StartExpiryCleanup() {
+	go func() {
+		for {
+			if err := DeleteExpired(); err != nil {
+				fmt.Printf("Error deleting expired uploads: %v\n", err)
+			}
+			time.Sleep(expiryCleanupInterval)
+		}
+	}()
+}
+
+// lockUpload locks a single upload, returning the function that unlocks it.
+func lockUpload(id string) func() {
+	lock, _ := uploadLocks.LoadOrStore(id, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	return lock.(*sync.Mutex).Unlock
+}
+
+func get(id string) (*db.Upload, error) {
+	upload, err := db.DatabaseQueries.GetUpload(context.Background(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload %s: %w", id, err)
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return &upload, nil
+}
+
+// finish moves a complete upload into the files root, next to any existing
+// file of the same name rather than over it.
+func finish(upload *db.Upload) (string, error) {
+	filesRoot := fileutil.GetFilesRoot()
+	newFilePath, release, err := filesRoot.AvailablePath(filepath.Join(upload.RootDir, upload.FileName))
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	if err := fileutil.MoveBetweenRoots(getStagingRoot(), upload.ID, filesRoot, newFilePath); err != nil {
+		return "", fmt.Errorf("failed to move upload into place: %w", err)
+	}
+	if err := db.DatabaseQueries.DeleteUpload(context.Background(), upload.ID); err != nil {
+		return "", fmt.Errorf("failed to remove upload record: %w", err)
+	}
+	uploadLocks.Delete(upload.ID)
+	return newFilePath, nil
+}
+
+func deleteUpload(upload db.Upload) error {
+	if err := getStagingRoot().Remove(upload.ID); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete staged upload %s: %w", upload.ID, err)
+	}
+	if err := db.DatabaseQueries.DeleteUpload(context.Background(), upload.ID); err != nil {
+		return fmt.Errorf("failed to remove upload record: %w", err)
+	}
+	uploadLocks.Delete(upload.ID)
+	return nil
+}
+
+func stagedBytes(id string) (int64, error) {
+	info, err := getStagingRoot().Stat(id)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChecksum, algorithm)
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate upload ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func getExpiry() time.Duration {
+	if hours, err := strconv.Atoi(os.Getenv(expiryHoursEnvVar)); err == nil && hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultExpiry
+}
@@ -28,22 +28,31 @@ type RecursiveBookInfo struct {
 	RelPath  string
 }
 
-// FindAllBooksRecursively finds all book files (PDF and EPUB) in a directory and its subdirectories
+// FindAllBooksRecursively finds all book files (PDF and EPUB) in a directory of the files root and its subdirectories
 func FindAllBooksRecursively(rootDir string) ([]RecursiveBookInfo, error) {
 	books := make([]RecursiveBookInfo, 0)
 
-	err := filepath.Walk(rootDir, func(path string, info fs.FileInfo, err error) error {
+	root := fileutil.GetFilesRoot()
+	base, err := root.Clean(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	err = root.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() {
+		if d.IsDir() {
 			return nil
 		}
 
-		fileType := fileutil.DetermineFileTypeFromPath(info.Name())
+		fileType := fileutil.DetermineFileTypeFromPath(d.Name())
 		if fileType == fileutil.FileTypePDF || fileType == fileutil.FileTypeEpub {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
 			// Get relative path from rootDir
-			relPath, err := filepath.Rel(rootDir, path)
+			relPath, err := filepath.Rel(base, filepath.FromSlash(path))
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,271 @@
+package fileutil
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"mime"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type FileType string
+
+const (
+	FileTypeArchive     FileType = "archive"
+	FileTypeAudio       FileType = "audio"
+	FileTypeCode        FileType = "code"
+	FileTypeDocx        FileType = "docx"
+	FileTypeEpub        FileType = "epub"
+	FileTypeFolder      FileType = "folder"
+	FileTypeImage       FileType = "image"
+	FileTypeMarkdown    FileType = "markdown"
+	FileTypePDF         FileType = "pdf"
+	FileTypeSlideshow   FileType = "slideshow"
+	FileTypeSpreadsheet FileType = "spreadsheet"
+	FileTypeVideo       FileType = "video"
+	// FileTypeGeneric is text that isn't anything more specific.
+	FileTypeGeneric FileType = "generic"
+	// FileTypeBinary is content that isn't text, and isn't anything more
+	// specific either.
+	FileTypeBinary FileType = "binary"
+	// FileTypeSpacer stands in for the empty rows of the file explorer.
+	FileTypeSpacer FileType = "spacer"
+)
+
+// FileTypeInfo describes how to recognise a type of file. Files are matched
+// by extension first, since formats like docx and epub are zip files
+// underneath, and by their content otherwise.
+type FileTypeInfo struct {
+	Type FileType
+	// Extensions are lower case, with the leading dot.
+	Extensions []string
+	// MimeTypes are matched against the sniffed type of the content. Ones
+	// ending in "/", like "image/", match every subtype.
+	MimeTypes []string
+	// Signatures are magic bytes the content starts with, for formats that
+	// sniffing doesn't know.
+	Signatures []Signature
+	// Text is set for types that are text, and can be edited as such.
+	Text bool
+}
+
+// Signature is a run of magic bytes found at Offset in a file.
+type Signature struct {
+	Offset int
+	Bytes  string
+}
+
+func (s Signature) matches(head []byte) bool {
+	return len(head) >= s.Offset+len(s.Bytes) && string(head[s.Offset:s.Offset+len(s.Bytes)]) == s.Bytes
+}
+
+var (
+	fileTypesMutex sync.RWMutex
+	fileTypes      []FileTypeInfo
+	byExtension    = map[string]FileType{}
+)
+
+func init() {
+	for _, info := range []FileTypeInfo{
+		{Type: FileTypePDF, Extensions: []string{".pdf"}, MimeTypes: []string{"application/pdf"}},
+		{Type: FileTypeSlideshow, Extensions: []string{".pptx", ".ppt", ".odp", ".key"}},
+		{
+			Type:       FileTypeImage,
+			Extensions: []string{".png", ".jpg", ".jpeg", ".gif", ".svg", ".heic", ".heif", ".webp", ".bmp", ".tiff", ".tif", ".avif"},
+			MimeTypes:  []string{"image/"},
+			Signatures: []Signature{{4, "ftypheic"}, {4, "ftypheix"}, {4, "ftypmif1"}, {4, "ftypavif"}, {0, "II*\x00"}, {0, "MM\x00*"}},
+		},
+		{
+			Type:       FileTypeVideo,
+			Extensions: []string{".mp4", ".m4v", ".webm", ".ogg", ".ogv", ".avi", ".mov", ".mkv"},
+			MimeTypes:  []string{"video/", "application/ogg"},
+			Signatures: []Signature{{4, "ftypqt"}},
+		},
+		{
+			Type:       FileTypeAudio,
+			Extensions: []string{".mp3", ".wav", ".flac", ".m4a", ".aac", ".oga", ".opus", ".weba", ".aiff", ".aif", ".mid", ".midi"},
+			MimeTypes:  []string{"audio/"},
+			Signatures: []Signature{{0, "fLaC"}, {4, "ftypM4A"}},
+		},
+		{Type: FileTypeEpub, Extensions: []string{".epub"}},
+		{Type: FileTypeDocx, Extensions: []string{".docx"}},
+		{Type: FileTypeSpreadsheet, Extensions: []string{".xlsx", ".xls", ".ods"}},
+		// Delimited text is still a table, but can be edited as text as well
+		{Type: FileTypeSpreadsheet, Extensions: []string{".csv", ".tsv"}, Text: true},
+		{
+			Type:       FileTypeArchive,
+			Extensions: []string{".zip", ".rar", ".tar", ".gz", ".tgz", ".zst", ".tzst", ".7z"},
+			MimeTypes:  []string{"application/zip", "application/x-gzip", "application/x-rar-compressed"},
+			Signatures: []Signature{{0, "7z\xbc\xaf\x27\x1c"}, {0, "\x28\xb5\x2f\xfd"}, {257, "ustar"}},
+		},
+		{Type: FileTypeMarkdown, Extensions: []string{".md", ".markdown", ".mdown", ".mkd"}, Text: true},
+		{
+			Type: FileTypeCode,
+			Extensions: []string{
+				".go", ".js", ".jsx", ".mjs", ".ts", ".tsx", ".py", ".rb", ".php", ".java", ".kt", ".scala",
+				".c", ".h", ".cc", ".cpp", ".hpp", ".cs", ".rs", ".swift", ".lua", ".pl", ".r", ".sh", ".bash",
+				".zsh", ".fish", ".html", ".htm", ".css", ".scss", ".sass", ".less", ".json", ".xml", ".yaml",
+				".yml", ".toml", ".ini", ".sql", ".templ",
+			},
+			MimeTypes: []string{"text/html", "text/xml", "application/json"},
+			Text:      true,
+		},
+		{Type: FileTypeGeneric, Extensions: []string{".txt", ".log", ".text", ".conf", ".env"}, Text: true},
+	} {
+		RegisterFileType(info)
+	}
+}
+
+// RegisterFileType teaches file type detection a type, or more ways of
+// recognising one. Extensions registered later take precedence.
+func RegisterFileType(info FileTypeInfo) {
+	fileTypesMutex.Lock()
+	defer fileTypesMutex.Unlock()
+	fileTypes = append(fileTypes, info)
+	for _, ext := range info.Extensions {
+		byExtension[strings.ToLower(ext)] = info.Type
+	}
+}
+
+// FileTypes returns every type files can have, by name.
+func FileTypes() []FileType {
+	fileTypesMutex.RLock()
+	defer fileTypesMutex.RUnlock()
+	types := []FileType{FileTypeFolder, FileTypeGeneric, FileTypeBinary}
+	for _, info := range fileTypes {
+		if !slices.Contains(types, info.Type) {
+			types = append(types, info.Type)
+		}
+	}
+	slices.Sort(types)
+	return types
+}
+
+// IsTextFile reports whether the file at name can be edited as text, going
+// by its extension.
+func IsTextFile(name string) bool {
+	fileTypesMutex.RLock()
+	defer fileTypesMutex.RUnlock()
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, info := range fileTypes {
+		if info.Text && slices.Contains(info.Extensions, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileTypeFromExtension returns the type of files with the extension of name,
+// if it is a known one.
+func FileTypeFromExtension(name string) (FileType, bool) {
+	fileTypesMutex.RLock()
+	defer fileTypesMutex.RUnlock()
+	fileType, ok := byExtension[strings.ToLower(filepath.Ext(name))]
+	return fileType, ok
+}
+
+// SniffFileType returns the type of a file from its name and up to its first
+// 512 bytes as head. Content that nothing matches is told apart as text or
+// binary.
+func SniffFileType(name string, head []byte) FileType {
+	if fileType, ok := FileTypeFromExtension(name); ok {
+		return fileType
+	}
+	mimeType, _, _ := mime.ParseMediaType(SniffMimeType(name, head))
+	fileTypesMutex.RLock()
+	defer fileTypesMutex.RUnlock()
+	for _, info := range fileTypes {
+		for _, signature := range info.Signatures {
+			if signature.matches(head) {
+				return info.Type
+			}
+		}
+	}
+	for _, info := range fileTypes {
+		for _, pattern := range info.MimeTypes {
+			if mimeType == pattern || (strings.HasSuffix(pattern, "/") && strings.HasPrefix(mimeType, pattern)) {
+				return info.Type
+			}
+		}
+	}
+	if looksLikeText(head) {
+		return FileTypeGeneric
+	}
+	return FileTypeBinary
+}
+
+// looksLikeText reports whether head is the start of text: valid UTF-8,
+// apart from a rune cut off at the end, with no NUL bytes and hardly any
+// other control characters.
+func looksLikeText(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return false
+	}
+	valid := false
+	for cut := 0; cut < utf8.UTFMax && cut <= len(head) && !valid; cut++ {
+		valid = utf8.Valid(head[:len(head)-cut])
+	}
+	if !valid {
+		return false
+	}
+	control := 0
+	for _, b := range head {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' && b != 0x1b {
+			control++
+		}
+	}
+	return control*100 <= len(head)
+}
+
+// DetectFileType returns the type of the file at name in the root, sniffing
+// its content when its extension isn't known.
+func (r *Root) DetectFileType(name string) FileType {
+	if fileType, ok := FileTypeFromExtension(name); ok {
+		return fileType
+	}
+	file, err := r.Open(name)
+	if err != nil {
+		return FileTypeGeneric
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return FileTypeGeneric
+	}
+	if info.IsDir() {
+		return FileTypeFolder
+	}
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return FileTypeGeneric
+	}
+	return SniffFileType(name, head[:n])
+}
+
+// DetermineFileTypeFromPath returns the type of the file at filePath in the
+// files root. Paths ending in "/" are folders.
+func DetermineFileTypeFromPath(filePath string) FileType {
+	if strings.HasSuffix(filePath, "/") {
+		return FileTypeFolder
+	}
+	return GetFilesRoot().DetectFileType(filePath)
+}
+
+// DetermineFileType returns the type of file, which is in rootDir of the
+// files root.
+func DetermineFileType(rootDir string, file fs.FileInfo) FileType {
+	if file == nil {
+		return FileTypeSpacer
+	}
+	if file.IsDir() {
+		return FileTypeFolder
+	}
+	// Files that can't be opened, like those in the trash, are typed by
+	// their extension alone
+	return GetFilesRoot().DetectFileType(filepath.Join(rootDir, file.Name()))
+}
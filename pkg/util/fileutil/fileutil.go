@@ -6,16 +6,15 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
-	"slices"
-	"strings"
 	"time"
 
 	"golang.org/x/sys/unix"
 )
 
 type CustomFileInfo struct {
-	name string
-	size int64
+	name        string
+	size        int64
+	sizePending bool
 }
 
 func (f CustomFileInfo) Name() string {
@@ -40,20 +39,17 @@ func NewCustomFileInfo(name string, size int64) fs.FileInfo {
 	return CustomFileInfo{name: name, size: size}
 }
 
-type FileType string
+// NewPendingFileInfo describes a file whose size is still being calculated.
+func NewPendingFileInfo(name string) fs.FileInfo {
+	return CustomFileInfo{name: name, sizePending: true}
+}
 
-const (
-	FileTypeDocx      FileType = "docx"
-	FileTypeEpub      FileType = "epub"
-	FileTypeFolder    FileType = "folder"
-	FileTypeGeneric   FileType = "generic"
-	FileTypeImage     FileType = "image"
-	FileTypePDF       FileType = "pdf"
-	FileTypeSlideshow FileType = "slideshow"
-	FileTypeVideo     FileType = "video"
-	FileTypeSpacer    FileType = "spacer"
-	FileTypeArchive   FileType = "archive"
-)
+// SizeKnown reports whether file.Size() is meaningful, which it isn't for
+// folders whose size is still being calculated.
+func SizeKnown(file fs.FileInfo) bool {
+	custom, ok := file.(CustomFileInfo)
+	return !ok || !custom.sizePending
+}
 
 func BytesToKB(size uint64) float64 {
 	return float64(size) / 1024
@@ -87,54 +83,6 @@ func TBToBytes(size float64) uint64 {
 	return uint64(GBToBytes(size) * 1024)
 }
 
-func DetermineFileTypeFromPath(filePath string) FileType {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	switch ext {
-	case ".pdf":
-		return FileTypePDF
-	case ".pptx", ".ppt":
-		return FileTypeSlideshow
-	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".heic", ".heif", ".webp", ".bmp", ".tiff", ".tif", ".avif":
-		return FileTypeImage
-	case ".mp4", ".m4v", ".webm", ".ogg", ".avi", ".mov":
-		return FileTypeVideo
-	case ".epub":
-		return FileTypeEpub
-	case ".docx":
-		return FileTypeDocx
-	case ".zip", ".rar", ".tar", ".gz", ".7z":
-		return FileTypeArchive
-	case "/":
-		return FileTypeFolder
-	default:
-		stat, err := os.Stat(filePath)
-		if err == nil && stat != nil {
-			if stat.IsDir() {
-				return FileTypeFolder
-			}
-		}
-		return FileTypeGeneric
-	}
-}
-
-func DetermineFileType(rootDir string, file fs.FileInfo) FileType {
-	if file == nil {
-		return FileTypeSpacer
-	}
-	if file.IsDir() {
-		return FileTypeFolder
-	}
-	filesDir := GetFilesDir()
-	stat, err := os.Stat(filepath.Join(filesDir, rootDir, file.Name()))
-	if err != nil || stat == nil {
-		return FileTypeGeneric // If we can't stat the file, treat it as generic
-	}
-	if stat.IsDir() {
-		return FileTypeFolder
-	}
-	return DetermineFileTypeFromPath(file.Name())
-}
-
 func SizeBytesToString(size_bytes int64) string {
 	if size_bytes < 1024 {
 		return fmt.Sprintf("%d B", size_bytes)
@@ -149,56 +97,6 @@ func SizeBytesToString(size_bytes int64) string {
 	}
 }
 
-func GetFolderSize(dir string) (int64, error) {
-	var size int64
-	err := filepath.Walk(dir, func(_ string, info fs.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			size += info.Size()
-		}
-		return nil
-	})
-	if err != nil {
-		return 0, fmt.Errorf("error calculating folder size for %s: %w", dir, err)
-	}
-	return size, nil
-}
-
-func StatFilesInDir(dir string) ([]fs.FileInfo, error) {
-	entries, err := os.ReadDir(dir)
-	files := make([]fs.FileInfo, len(entries))
-	if err != nil {
-		return nil, fmt.Errorf("error reading the directory %s: %w", dir, err)
-	}
-	for i, entry := range entries {
-		if entry.IsDir() {
-			folderSize, err := GetFolderSize(filepath.Join(dir, entry.Name()))
-			if err != nil {
-				return nil, fmt.Errorf("error getting size for folder %s: %w", entry.Name(), err)
-			}
-			files[i] = NewCustomFileInfo(entry.Name()+"/", folderSize)
-		} else {
-			info, err := entry.Info()
-			if err != nil {
-				return nil, fmt.Errorf("error getting info for file %s: %w", entry.Name(), err)
-			}
-			files[i] = info
-		}
-	}
-	// Sort files by directory first, then by name
-	slices.SortFunc(files, func(a, b fs.FileInfo) int {
-		if a.IsDir() && !b.IsDir() {
-			return -1 // a is a directory, b is a file
-		} else if !a.IsDir() && b.IsDir() {
-			return 1 // a is a file, b is a directory
-		}
-		return strings.Compare(a.Name(), b.Name())
-	})
-	return files, nil
-}
-
 func GetAvailableSpaceInBytes(fileDir string) uint64 {
 	return getAvailableSpaceInBytes(fileDir)
 }
@@ -0,0 +1,49 @@
+package fileutil
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// sniffLength is the number of bytes http.DetectContentType considers.
+const sniffLength = 512
+
+// DetectMimeType determines the MIME type of content by sniffing its first
+// bytes, falling back to the extension of name when sniffing only yields a
+// generic type. Formats such as docx and epub are zip files underneath, so
+// their extension is more specific than their content. content is rewound
+// to the start afterwards.
+func DetectMimeType(name string, content io.ReadSeeker) (string, error) {
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return SniffMimeType(name, head[:n]), nil
+}
+
+// SniffMimeType is DetectMimeType for content that can't be rewound, given
+// up to its first 512 bytes as head.
+func SniffMimeType(name string, head []byte) string {
+	sniffed := http.DetectContentType(head)
+	if byExtension := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); byExtension != "" && isGenericMimeType(sniffed) {
+		return byExtension
+	}
+	return sniffed
+}
+
+func isGenericMimeType(mimeType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	switch mediaType {
+	case "application/octet-stream", "text/plain", "application/zip", "text/xml", "application/xml":
+		return true
+	default:
+		return false
+	}
+}
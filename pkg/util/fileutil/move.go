@@ -0,0 +1,244 @@
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+var (
+	reservedMutex sync.Mutex
+	// reserved holds the paths on disk handed out by AvailablePath that
+	// haven't been released yet, which may not exist so far.
+	reserved = map[string]bool{}
+)
+
+// AvailablePath returns name if nothing exists there yet, or otherwise the
+// first free "name_(n).ext" variant next to it. The path is kept from other
+// callers until release is called, once whatever goes there has been
+// created or has failed to be, so that two of them never pick the same one.
+func (r *Root) AvailablePath(name string) (local string, release func(), err error) {
+	local, err = r.Clean(name)
+	if err != nil {
+		return "", nil, err
+	}
+	reservedMutex.Lock()
+	defer reservedMutex.Unlock()
+	taken := func(candidate string) (bool, error) {
+		if reserved[filepath.Join(r.dir, candidate)] {
+			return true, nil
+		}
+		_, err := r.Lstat(candidate)
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	dir, fileName := filepath.Split(local)
+	ext := filepath.Ext(fileName)
+	base := fileName[:len(fileName)-len(ext)]
+	for i := 1; ; i++ {
+		isTaken, err := taken(local)
+		if err != nil {
+			return "", nil, err
+		}
+		if !isTaken {
+			break
+		}
+		local = filepath.Join(dir, fmt.Sprintf("%s_(%d)%s", base, i, ext))
+	}
+	key := filepath.Join(r.dir, local)
+	reserved[key] = true
+	var once sync.Once
+	return local, func() {
+		once.Do(func() {
+			reservedMutex.Lock()
+			defer reservedMutex.Unlock()
+			delete(reserved, key)
+		})
+	}, nil
+}
+
+// MoveBetweenRoots moves srcName in src to dstName in dst. When the roots
+// live on different devices the tree is copied, preserving permissions and
+// modification times, and the source is removed afterwards.
+func MoveBetweenRoots(src *Root, srcName string, dst *Root, dstName string) error {
+	return MoveBetweenRootsContext(context.Background(), src, srcName, dst, dstName, nil)
+}
+
+// MoveBetweenRootsContext is MoveBetweenRoots for moves that may have to copy
+// a lot. A copy stops when ctx is canceled, and copied is called with the
+// number of bytes written as it goes, unless it is nil.
+func MoveBetweenRootsContext(ctx context.Context, src *Root, srcName string, dst *Root, dstName string, copied func(int64)) error {
+	srcLocal, err := src.cleanNonRoot("rename", srcName)
+	if err != nil {
+		return err
+	}
+	dstLocal, err := dst.cleanNonRoot("rename", dstName)
+	if err != nil {
+		return err
+	}
+	if _, err := src.Lstat(srcLocal); err != nil {
+		return err
+	}
+	// Only resolve the parents, so that a symlink is moved rather than the
+	// file it points to.
+	srcParent, err := src.Abs(filepath.Dir(srcLocal))
+	if err != nil {
+		return err
+	}
+	dstParent, err := dst.Abs(filepath.Dir(dstLocal))
+	if err != nil {
+		return err
+	}
+	err = os.Rename(filepath.Join(srcParent, filepath.Base(srcLocal)), filepath.Join(dstParent, filepath.Base(dstLocal)))
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := CopyBetweenRootsContext(ctx, src, srcLocal, dst, dstLocal, copied); err != nil {
+		// Don't leave half a copy behind while the source is still whole
+		if removeErr := dst.RemoveAll(dstLocal); removeErr != nil {
+			return fmt.Errorf("%w (and failed to remove the partial copy: %v)", err, removeErr)
+		}
+		return err
+	}
+	return src.RemoveAll(srcLocal)
+}
+
+// CopyBetweenRoots copies srcName in src to dstName in dst, preserving
+// permissions and modification times. Symlinks are recreated as links rather
+// than followed, except for ones escaping src, which are skipped.
+func CopyBetweenRoots(src *Root, srcName string, dst *Root, dstName string) error {
+	return CopyBetweenRootsContext(context.Background(), src, srcName, dst, dstName, nil)
+}
+
+// CopyBetweenRootsContext is CopyBetweenRoots stopping when ctx is canceled,
+// which leaves a partial copy behind. copied is called with the number of
+// bytes written as it goes, unless it is nil.
+func CopyBetweenRootsContext(ctx context.Context, src *Root, srcName string, dst *Root, dstName string, copied func(int64)) error {
+	srcLocal, err := src.Clean(srcName)
+	if err != nil {
+		return err
+	}
+	dstLocal, err := dst.Clean(dstName)
+	if err != nil {
+		return err
+	}
+	// Walk without following the top level entry, so a symlink is copied as
+	// a link.
+	info, err := src.Lstat(srcLocal)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyEntry(ctx, src, srcLocal, dst, dstLocal, info, copied)
+	}
+	var dirs []string
+	err = src.WalkDir(srcLocal, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcLocal, filepath.FromSlash(path))
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dstLocal, rel)
+		info, err := src.Lstat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return copyEntry(ctx, src, path, dst, target, info, copied)
+	})
+	if err != nil {
+		return err
+	}
+	// Directory mtimes change while they are filled, so restore them last.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		info, err := src.Lstat(dirs[i])
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcLocal, filepath.FromSlash(dirs[i]))
+		if err != nil {
+			return err
+		}
+		if err := dst.root.Chtimes(filepath.Join(dstLocal, rel), info.ModTime(), info.ModTime()); err != nil {
+			return dst.wrapError(dstName, err)
+		}
+	}
+	return nil
+}
+
+func copyEntry(ctx context.Context, src *Root, srcLocal string, dst *Root, dstLocal string, info fs.FileInfo, copied func(int64)) error {
+	switch {
+	case info.IsDir():
+		if err := dst.MkdirAll(dstLocal, info.Mode().Perm()); err != nil {
+			return err
+		}
+		return dst.wrapError(dstLocal, dst.root.Chmod(dstLocal, info.Mode().Perm()))
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := src.root.Readlink(srcLocal)
+		if err != nil {
+			return src.wrapError(srcLocal, err)
+		}
+		return dst.wrapError(dstLocal, dst.root.Symlink(target, dstLocal))
+	case info.Mode().IsRegular():
+		in, err := src.Open(srcLocal)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := dst.OpenFile(dstLocal, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		var reader io.Reader = in
+		if ctx.Done() != nil || copied != nil {
+			// Only wrap the file when needed, since that rules out copying
+			// within the kernel
+			reader = &progressReader{ctx: ctx, reader: in, copied: copied}
+		}
+		if _, err := io.Copy(out, reader); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		return dst.wrapError(dstLocal, dst.root.Chtimes(dstLocal, info.ModTime(), info.ModTime()))
+	default:
+		// Devices, sockets and pipes have no business in the files area.
+		return nil
+	}
+}
+
+// progressReader stops reading once ctx is canceled, and reports every read
+// to copied.
+type progressReader struct {
+	ctx    context.Context
+	reader io.Reader
+	copied func(int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 && r.copied != nil {
+		r.copied(int64(n))
+	}
+	return n, err
+}
@@ -0,0 +1,96 @@
+package fileutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotRegular is returned when replacing the content of something that
+// isn't a regular file, like a folder.
+var ErrNotRegular = errors.New("not a regular file")
+
+// ETag builds a strong ETag from a file's size and modification time, which
+// change whenever its content is written.
+func ETag(info fs.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
+
+// ReplaceFile atomically replaces the content of the existing file name with
+// content. The new content is written to a temporary file next to it, synced,
+// and renamed over it, so readers see either the old or the new content and
+// never a mix. The file keeps its permissions, and symlinks keep pointing at
+// it. It returns the replaced file's info.
+func (r *Root) ReplaceFile(name string, content io.Reader) (fs.FileInfo, error) {
+	local, err := r.cleanNonRoot("replace", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := r.Stat(local)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, &fs.PathError{Op: "replace", Path: name, Err: ErrNotRegular}
+	}
+	// Replace whatever a symlink points to, rather than the symlink itself
+	target, err := r.Abs(local)
+	if err != nil {
+		return nil, err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	// Only cleans up after a failure, since the file is gone once renamed
+	defer os.Remove(temp.Name())
+	defer temp.Close()
+	if _, err := io.Copy(temp, content); err != nil {
+		return nil, err
+	}
+	if err := temp.Chmod(info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	if err := temp.Sync(); err != nil {
+		return nil, err
+	}
+	if err := temp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(temp.Name(), target); err != nil {
+		return nil, err
+	}
+	// Make the rename itself durable
+	if dir, err := os.Open(filepath.Dir(target)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return info, nil
+}
+
+// LineEnding returns the line ending text uses, going by its first line: "\r\n"
+// or "\n", or "" when it is a single line.
+func LineEnding(text []byte) string {
+	i := bytes.IndexByte(text, '\n')
+	switch {
+	case i < 0:
+		return ""
+	case i > 0 && text[i-1] == '\r':
+		return "\r\n"
+	default:
+		return "\n"
+	}
+}
+
+// WithLineEnding returns text with every line ending changed to ending.
+func WithLineEnding(text []byte, ending string) []byte {
+	text = bytes.ReplaceAll(text, []byte("\r\n"), []byte("\n"))
+	if ending == "\n" {
+		return text
+	}
+	return bytes.ReplaceAll(text, []byte("\n"), []byte(ending))
+}
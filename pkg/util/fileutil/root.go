@@ -0,0 +1,440 @@
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrEscapesRoot is matched by every error caused by a path that resolves
+	// outside of a Root, whether lexically (via "..") or through a symlink.
+	ErrEscapesRoot = errors.New("path escapes root")
+	// ErrRootPath is returned when an operation would remove or replace the
+	// root directory itself.
+	ErrRootPath = errors.New("operation not permitted on root directory")
+)
+
+// EscapeError reports a path that resolved outside of a Root.
+type EscapeError struct {
+	Path string
+	// Symlink is true when the path itself was local, but a symlink along it
+	// pointed outside of the root.
+	Symlink bool
+}
+
+func (e *EscapeError) Error() string {
+	if e.Symlink {
+		return fmt.Sprintf("%s: symlink resolves outside of root", e.Path)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, ErrEscapesRoot)
+}
+
+func (e *EscapeError) Is(target error) bool {
+	return target == ErrEscapesRoot
+}
+
+// Root is a sandboxed view of a directory tree, built on os.Root. Every path
+// handed to a Root is interpreted relative to it, and a leading "/" refers to
+// the root itself, so request paths such as "/photos/cat.png" can be passed
+// through as-is.
+//
+// Symlinks are followed as long as they resolve to a location inside the
+// root. Symlinks that point outside of it still show up in directory listings,
+// but opening or stating through them fails with an *EscapeError, walks and
+// zips skip them, and removing or renaming them acts on the link itself.
+type Root struct {
+	dir  string
+	root *os.Root
+}
+
+var (
+	filesRoot     *Root
+	filesRootOnce sync.Once
+)
+
+// OpenRoot opens dir as a Root. The caller is responsible for closing it.
+func OpenRoot(dir string) (*Root, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open root %s: %w", dir, err)
+	}
+	return &Root{dir: dir, root: root}, nil
+}
+
+// GetFilesRoot returns the process-wide Root over GetFilesDir.
+func GetFilesRoot() *Root {
+	filesRootOnce.Do(func() {
+		root, err := OpenRoot(GetFilesDir())
+		if err != nil {
+			panic(fmt.Sprintf("failed to open files root: %v", err))
+		}
+		filesRoot = root
+	})
+	return filesRoot
+}
+
+func (r *Root) Close() error {
+	return r.root.Close()
+}
+
+// Dir returns the directory on disk that the root was opened on.
+func (r *Root) Dir() string {
+	return r.dir
+}
+
+// Clean converts a user supplied path into a cleaned path relative to the
+// root, returning an *EscapeError if it lexically leaves the root. The root
+// itself is returned as ".".
+func (r *Root) Clean(name string) (string, error) {
+	local := strings.TrimLeft(filepath.FromSlash(name), string(filepath.Separator))
+	if local == "" {
+		return ".", nil
+	}
+	if !filepath.IsLocal(local) {
+		return "", &EscapeError{Path: name}
+	}
+	return filepath.Clean(local), nil
+}
+
+// CleanSlash is Clean with the result separated by forward slashes, as paths
+// relative to the root are kept in the database.
+func (r *Root) CleanSlash(name string) (string, error) {
+	local, err := r.Clean(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(local), nil
+}
+
+// Join joins path elements and cleans the result relative to the root.
+func (r *Root) Join(elem ...string) (string, error) {
+	return r.Clean(filepath.Join(elem...))
+}
+
+// Abs returns the absolute path on disk for name with every symlink along it
+// resolved. It is meant for APIs that only accept file names; prefer the
+// other Root methods wherever possible.
+func (r *Root) Abs(name string) (string, error) {
+	local, err := r.Clean(name)
+	if err != nil {
+		return "", err
+	}
+	// Let os.Root enforce the symlink policy before resolving the path.
+	if _, err := r.root.Stat(local); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", r.wrapError(name, err)
+	}
+	realDir, err := filepath.EvalSymlinks(r.dir)
+	if err != nil {
+		return "", err
+	}
+	fullPath, err := filepath.EvalSymlinks(filepath.Join(r.dir, local))
+	if errors.Is(err, fs.ErrNotExist) {
+		return filepath.Join(realDir, local), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(realDir, fullPath); err != nil || !filepath.IsLocal(rel) {
+		return "", &EscapeError{Path: name, Symlink: true}
+	}
+	return fullPath, nil
+}
+
+func (r *Root) Open(name string) (*os.File, error) {
+	local, err := r.Clean(name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := r.root.Open(local)
+	return file, r.wrapError(name, err)
+}
+
+func (r *Root) Create(name string) (*os.File, error) {
+	return r.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+}
+
+func (r *Root) OpenFile(name string, flag int, perm fs.FileMode) (*os.File, error) {
+	local, err := r.Clean(name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := r.root.OpenFile(local, flag, perm)
+	return file, r.wrapError(name, err)
+}
+
+func (r *Root) ReadFile(name string) ([]byte, error) {
+	local, err := r.Clean(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.root.ReadFile(local)
+	return data, r.wrapError(name, err)
+}
+
+func (r *Root) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	local, err := r.Clean(name)
+	if err != nil {
+		return err
+	}
+	return r.wrapError(name, r.root.WriteFile(local, data, perm))
+}
+
+func (r *Root) Stat(name string) (fs.FileInfo, error) {
+	local, err := r.Clean(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := r.root.Stat(local)
+	return info, r.wrapError(name, err)
+}
+
+func (r *Root) Lstat(name string) (fs.FileInfo, error) {
+	local, err := r.Clean(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := r.root.Lstat(local)
+	return info, r.wrapError(name, err)
+}
+
+// ReadDir reads the named directory, returning its entries sorted by name.
+func (r *Root) ReadDir(name string) ([]fs.DirEntry, error) {
+	dir, err := r.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+	entries, err := dir.ReadDir(-1)
+	if err != nil {
+		return nil, r.wrapError(name, err)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+func (r *Root) Mkdir(name string, perm fs.FileMode) error {
+	local, err := r.Clean(name)
+	if err != nil {
+		return err
+	}
+	return r.wrapError(name, r.root.Mkdir(local, perm))
+}
+
+func (r *Root) MkdirAll(name string, perm fs.FileMode) error {
+	local, err := r.Clean(name)
+	if err != nil {
+		return err
+	}
+	if local == "." {
+		return nil
+	}
+	return r.wrapError(name, r.root.MkdirAll(local, perm))
+}
+
+func (r *Root) Remove(name string) error {
+	local, err := r.cleanNonRoot("remove", name)
+	if err != nil {
+		return err
+	}
+	return r.wrapError(name, r.root.Remove(local))
+}
+
+// RemoveAll removes name and any children it contains. Symlinks are removed
+// rather than followed.
+func (r *Root) RemoveAll(name string) error {
+	local, err := r.cleanNonRoot("removeall", name)
+	if err != nil {
+		return err
+	}
+	return r.wrapError(name, r.root.RemoveAll(local))
+}
+
+func (r *Root) Rename(oldName, newName string) error {
+	oldLocal, err := r.cleanNonRoot("rename", oldName)
+	if err != nil {
+		return err
+	}
+	newLocal, err := r.cleanNonRoot("rename", newName)
+	if err != nil {
+		return err
+	}
+	return r.wrapError(oldName, r.root.Rename(oldLocal, newLocal))
+}
+
+// WalkDir walks the tree rooted at name like fs.WalkDir. Paths handed to fn
+// are slash separated and relative to the root. Symlinked directories are not
+// descended into, and symlinks that escape the root are skipped.
+func (r *Root) WalkDir(name string, fn fs.WalkDirFunc) error {
+	local, err := r.Clean(name)
+	if err != nil {
+		return err
+	}
+	return fs.WalkDir(r.root.FS(), filepath.ToSlash(local), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type()&fs.ModeSymlink != 0 {
+			if _, statErr := r.Stat(path); errors.Is(statErr, ErrEscapesRoot) {
+				return nil
+			}
+		}
+		return fn(path, d, err)
+	})
+}
+
+// FS returns a read-only fs.FS over the root, which enforces the same
+// symlink policy as the root itself.
+func (r *Root) FS() fs.FS {
+	return r.root.FS()
+}
+
+// FolderSize returns the total size of every regular file below name.
+func (r *Root) FolderSize(name string) (int64, error) {
+	var size int64
+	err := r.WalkDir(name, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error calculating folder size for %s: %w", name, err)
+	}
+	return size, nil
+}
+
+// CountFiles returns how many files there are at or below name, counting
+// anything that isn't a folder.
+func (r *Root) CountFiles(name string) (int64, error) {
+	var count int64
+	err := r.WalkDir(name, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error counting files in %s: %w", name, err)
+	}
+	return count, nil
+}
+
+// AvailableSpace returns the free space on the device that name is on. When
+// name doesn't exist yet, the nearest folder above it that does is used, as
+// that's where it would be created.
+func (r *Root) AvailableSpace(name string) (uint64, error) {
+	local, err := r.Clean(name)
+	if err != nil {
+		return 0, err
+	}
+	for local != "." {
+		if info, err := r.Stat(local); err == nil && info.IsDir() {
+			break
+		}
+		local = filepath.Dir(local)
+	}
+	fullPath, err := r.Abs(local)
+	if err != nil {
+		return 0, err
+	}
+	return GetAvailableSpaceInBytes(fullPath), nil
+}
+
+// StatFilesInDir lists the named directory with folders first. The size of
+// each folder is looked up with folderSize, which reports false for sizes that
+// aren't known yet.
+func (r *Root) StatFilesInDir(name string, folderSize func(dirPath string) (int64, bool)) ([]fs.FileInfo, error) {
+	entries, err := r.ReadDir(name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the directory %s: %w", name, err)
+	}
+	files := make([]fs.FileInfo, len(entries))
+	for i, entry := range entries {
+		if entry.IsDir() {
+			if size, ok := folderSize(filepath.Join(name, entry.Name())); ok {
+				files[i] = NewCustomFileInfo(entry.Name()+"/", size)
+			} else {
+				files[i] = NewPendingFileInfo(entry.Name() + "/")
+			}
+		} else {
+			info, err := entry.Info()
+			if err != nil {
+				return nil, fmt.Errorf("error getting info for file %s: %w", entry.Name(), err)
+			}
+			files[i] = info
+		}
+	}
+	// Sort files by directory first, then by name
+	slices.SortFunc(files, func(a, b fs.FileInfo) int {
+		if a.IsDir() && !b.IsDir() {
+			return -1 // a is a directory, b is a file
+		} else if !a.IsDir() && b.IsDir() {
+			return 1 // a is a file, b is a directory
+		}
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return files, nil
+}
+
+// StatPaths describes files and folders from all over the root for the file
+// explorer, in the order given. As they can be anywhere, their names are
+// their paths. Ones that are gone are left out.
+func (r *Root) StatPaths(paths []string, folderSize func(dirPath string) (int64, bool)) []fs.FileInfo {
+	files := make([]fs.FileInfo, 0, len(paths))
+	for _, name := range paths {
+		info, err := r.Stat(name)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, NewCustomFileInfo(name, info.Size()))
+		} else if size, ok := folderSize(name); ok {
+			files = append(files, NewCustomFileInfo(name+"/", size))
+		} else {
+			files = append(files, NewPendingFileInfo(name+"/"))
+		}
+	}
+	return files
+}
+
+func (r *Root) cleanNonRoot(op string, name string) (string, error) {
+	local, err := r.Clean(name)
+	if err != nil {
+		return "", err
+	}
+	if local == "." {
+		return "", &fs.PathError{Op: op, Path: name, Err: ErrRootPath}
+	}
+	return local, nil
+}
+
+// wrapError converts the escape errors reported by os.Root into an
+// *EscapeError. Since paths are checked lexically before reaching os.Root,
+// any escape it reports must have come from a symlink.
+func (r *Root) wrapError(name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pathErr *fs.PathError
+	// os.Root does not export its escape error, so match on its message.
+	if errors.As(err, &pathErr) && pathErr.Err.Error() == "path escapes from parent" {
+		return &EscapeError{Path: name, Symlink: true}
+	}
+	return err
+}
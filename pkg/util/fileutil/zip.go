@@ -0,0 +1,250 @@
+package fileutil
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ZipCompression decides how the files in a zip archive are stored.
+type ZipCompression string
+
+const (
+	// ZipCompressionAuto deflates everything except files whose format is
+	// already compressed, like photos, videos and archives.
+	ZipCompressionAuto    ZipCompression = "auto"
+	ZipCompressionStore   ZipCompression = "store"
+	ZipCompressionDeflate ZipCompression = "deflate"
+)
+
+// ZipManifestName is the file listing every entry that could not be read,
+// added to the end of an archive when there are any.
+const ZipManifestName = "MANIFEST-ERRORS.txt"
+
+var ErrUnknownCompression = errors.New("unknown compression")
+
+// errOutsideFolder is listed for symlinks in a folder that lead out of it.
+var errOutsideFolder = errors.New("symlink resolves outside of the folder")
+
+// compressedExtensions are formats that gain next to nothing from being
+// deflated again.
+var compressedExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".heic": true, ".avif": true,
+	".mp4": true, ".mkv": true, ".mov": true, ".webm": true, ".avi": true, ".m4v": true,
+	".mp3": true, ".aac": true, ".ogg": true, ".opus": true, ".flac": true, ".m4a": true,
+	".zip": true, ".gz": true, ".tgz": true, ".bz2": true, ".xz": true, ".zst": true, ".tzst": true, ".7z": true, ".rar": true,
+	".docx": true, ".xlsx": true, ".pptx": true, ".epub": true,
+}
+
+// ParseZipCompression parses a compression name, defaulting to
+// ZipCompressionAuto when it is empty.
+func ParseZipCompression(name string) (ZipCompression, error) {
+	switch compression := ZipCompression(name); compression {
+	case "":
+		return ZipCompressionAuto, nil
+	case ZipCompressionAuto, ZipCompressionStore, ZipCompressionDeflate:
+		return compression, nil
+	default:
+		return "", fmt.Errorf("%q: %w", name, ErrUnknownCompression)
+	}
+}
+
+// method returns the zip method for a file called name.
+func (c ZipCompression) method(name string) uint16 {
+	switch c {
+	case ZipCompressionStore:
+		return zip.Store
+	case ZipCompressionDeflate:
+		return zip.Deflate
+	}
+	if compressedExtensions[strings.ToLower(filepath.Ext(name))] {
+		return zip.Store
+	}
+	return zip.Deflate
+}
+
+// WriteZip streams a zip archive of names to w, each under its own base name,
+// with folders added recursively. Symlinks in a folder are only added if they
+// resolve inside it, so that a shared folder gives away nothing else. Nothing is buffered, and archives over 4 GB
+// are written as ZIP64. Entries that can't be read are left out and listed in
+// ZipManifestName instead, so only a failure to write aborts the archive.
+func (r *Root) WriteZip(w io.Writer, names []string, compression ZipCompression) error {
+	zipWriter := zip.NewWriter(w)
+	archive := &zipArchive{root: r, writer: zipWriter, compression: compression, used: map[string]bool{}}
+	for _, name := range names {
+		if err := archive.add(name); err != nil {
+			return err
+		}
+	}
+	if err := archive.writeManifest(); err != nil {
+		return err
+	}
+	return zipWriter.Close()
+}
+
+type zipArchive struct {
+	root        *Root
+	writer      *zip.Writer
+	compression ZipCompression
+	// used holds the top level names taken so far, so that files with the
+	// same name from different folders don't overwrite each other.
+	used     map[string]bool
+	failures []string
+}
+
+// add writes name and everything below it. Only errors writing the archive
+// are returned.
+func (a *zipArchive) add(name string) error {
+	local, err := a.root.Clean(name)
+	if err != nil {
+		a.fail(name, err)
+		return nil
+	}
+	prefix := a.topLevelName(filepath.Base(local))
+	realDir, err := a.root.Abs(local)
+	if err != nil {
+		a.fail(name, err)
+		return nil
+	}
+	return a.root.WalkDir(local, func(walkPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			a.fail(walkPath, err)
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 && !a.resolvesInside(walkPath, realDir) {
+			a.fail(walkPath, errOutsideFolder)
+			return nil
+		}
+		rel, err := filepath.Rel(local, filepath.FromSlash(walkPath))
+		if err != nil {
+			return err
+		}
+		entryName := path.Join(prefix, filepath.ToSlash(rel))
+		return a.addEntry(walkPath, entryName)
+	})
+}
+
+// addEntry writes a single file or folder. Symlinks are added as what they
+// point to.
+func (a *zipArchive) addEntry(local string, entryName string) error {
+	info, err := a.root.Stat(local)
+	if err != nil {
+		a.fail(local, err)
+		return nil
+	}
+	if info.IsDir() {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = entryName + "/"
+		_, err = a.writer.CreateHeader(header)
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	file, err := a.root.Open(local)
+	if err != nil {
+		a.fail(local, err)
+		return nil
+	}
+	defer file.Close()
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = entryName
+	header.Method = a.compression.method(entryName)
+	writer, err := a.writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, &readErrorReader{reader: file}); err != nil {
+		var readErr *readError
+		if !errors.As(err, &readErr) {
+			return err
+		}
+		// The entry has been started, so all that can be done is to flag it
+		// as incomplete.
+		a.fail(local, fmt.Errorf("truncated: %w", readErr.err))
+	}
+	return nil
+}
+
+// resolvesInside reports whether the symlink at local resolves to realDir or
+// somewhere below it.
+func (a *zipArchive) resolvesInside(local string, realDir string) bool {
+	target, err := a.root.Abs(local)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(realDir, target)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// topLevelName returns name, or the first free "name_(n).ext" variant if an
+// earlier selection already used it.
+func (a *zipArchive) topLevelName(name string) string {
+	if name == "." {
+		name = "files"
+	}
+	candidate := name
+	ext := filepath.Ext(name)
+	for i := 1; a.used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s_(%d)%s", name[:len(name)-len(ext)], i, ext)
+	}
+	a.used[candidate] = true
+	return candidate
+}
+
+func (a *zipArchive) fail(name string, err error) {
+	a.failures = append(a.failures, fmt.Sprintf("/%s: %v", strings.TrimPrefix(filepath.ToSlash(name), "/"), err))
+}
+
+func (a *zipArchive) writeManifest() error {
+	if len(a.failures) == 0 {
+		return nil
+	}
+	writer, err := a.writer.CreateHeader(&zip.FileHeader{
+		Name:     a.topLevelName(ZipManifestName),
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(writer, "These entries could not be read and are missing or incomplete in this archive:\n\n%s\n", strings.Join(a.failures, "\n"))
+	return err
+}
+
+// readError marks an error from reading a source file, as opposed to writing
+// the archive.
+type readError struct {
+	err error
+}
+
+func (e *readError) Error() string {
+	return e.err.Error()
+}
+
+type readErrorReader struct {
+	reader io.Reader
+}
+
+func (r *readErrorReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF {
+		err = &readError{err: err}
+	}
+	return n, err
+}
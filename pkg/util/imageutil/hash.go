@@ -0,0 +1,102 @@
+package imageutil
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"slices"
+
+	"github.com/KononK/resize"
+)
+
+// Perceptual hashes sum up what an image looks like in 64 bits, so that
+// copies that were resized or recompressed hash alike, unlike their bytes.
+// The more bits two hashes differ in, the less alike the images are.
+
+const phashSize = 32
+
+// dctCosines[u][x] is the cosine of the DCT-II for frequency u at x.
+var dctCosines = func() [phashSize][phashSize]float64 {
+	var cosines [phashSize][phashSize]float64
+	for u := range phashSize {
+		for x := range phashSize {
+			cosines[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * phashSize))
+		}
+	}
+	return cosines
+}()
+
+// DHash is the difference hash of img: whether each pixel of a 9x8
+// grayscale copy is brighter than the one to its right.
+func DHash(img image.Image) uint64 {
+	gray := grayscale(img, 9, 8)
+	var hash uint64
+	for y := range 8 {
+		for x := range 8 {
+			hash <<= 1
+			if gray[y][x] > gray[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// PHash is the perceptual hash of img: whether each of the 64 lowest
+// frequencies of a 32x32 grayscale copy is above their median.
+func PHash(img image.Image) uint64 {
+	gray := grayscale(img, phashSize, phashSize)
+
+	// The 2D DCT is a 1D DCT of the rows, and then of the columns, of which
+	// only the lowest 8 frequencies are kept
+	var rows [phashSize][8]float64
+	for y := range phashSize {
+		for u := range 8 {
+			for x := range phashSize {
+				rows[y][u] += gray[y][x] * dctCosines[u][x]
+			}
+		}
+	}
+	var lows [64]float64
+	for v := range 8 {
+		for u := range 8 {
+			for y := range phashSize {
+				lows[v*8+u] += rows[y][u] * dctCosines[v][y]
+			}
+		}
+	}
+
+	// The average brightness at [0] would outweigh the rest
+	sorted := slices.Clone(lows[1:])
+	slices.Sort(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	var hash uint64
+	for _, value := range lows {
+		hash <<= 1
+		if value > median {
+			hash |= 1
+		}
+	}
+	return hash
+}
+
+// HashDistance is the number of bits two hashes differ in.
+func HashDistance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// grayscale scales img to width by height, ignoring its aspect ratio, and
+// returns the luma of each pixel by row.
+func grayscale(img image.Image, width int, height int) [][]float64 {
+	small := resize.Resize(uint(width), uint(height), img, resize.Bilinear)
+	bounds := small.Bounds()
+	gray := make([][]float64, height)
+	for y := range height {
+		gray[y] = make([]float64, width)
+		for x := range width {
+			r, g, b, _ := small.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			gray[y][x] = 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+		}
+	}
+	return gray
+}
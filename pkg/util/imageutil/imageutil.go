@@ -6,7 +6,6 @@ import (
 	"image"
 	"io"
 	"io/fs"
-	"os"
 	"path/filepath"
 
 	"github.com/KononK/resize"
@@ -20,8 +19,7 @@ func FilterPhotoFiles(files []fs.FileInfo) []fs.FileInfo {
 		if file.IsDir() {
 			continue
 		}
-		fileType := fileutil.DetermineFileTypeFromPath(file.Name())
-		if fileType == fileutil.FileTypeImage {
+		if fileType, _ := fileutil.FileTypeFromExtension(file.Name()); fileType == fileutil.FileTypeImage {
 			photoFiles = append(photoFiles, file)
 		}
 	}
@@ -34,22 +32,30 @@ type RecursivePhotoInfo struct {
 	RelPath  string
 }
 
-// FindAllPhotosRecursively finds all photo files in a directory and its subdirectories
+// FindAllPhotosRecursively finds all photo files in a directory of the files root and its subdirectories
 func FindAllPhotosRecursively(rootDir string) ([]RecursivePhotoInfo, error) {
 	photos := make([]RecursivePhotoInfo, 0)
 
-	err := filepath.Walk(rootDir, func(path string, info fs.FileInfo, err error) error {
+	root := fileutil.GetFilesRoot()
+	base, err := root.Clean(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	err = root.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() {
+		if d.IsDir() {
 			return nil
 		}
 
-		fileType := fileutil.DetermineFileTypeFromPath(info.Name())
-		if fileType == fileutil.FileTypeImage {
+		if root.DetectFileType(path) == fileutil.FileTypeImage {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
 			// Get relative path from rootDir
-			relPath, err := filepath.Rel(rootDir, path)
+			relPath, err := filepath.Rel(base, filepath.FromSlash(path))
 			if err != nil {
 				return err
 			}
@@ -68,21 +74,27 @@ func FindAllPhotosRecursively(rootDir string) ([]RecursivePhotoInfo, error) {
 	return photos, nil
 }
 
-func ImageToThumbnail(filePath string, width, height uint) (image.Image, string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, "", fmt.Errorf("error opening image file %s: %w", filePath, err)
-	}
-	defer file.Close()
-
+// DecodeImage decodes an image and corrects its EXIF orientation, so that it
+// is the way up it is meant to be seen
+func DecodeImage(file io.ReadSeeker) (image.Image, string, error) {
 	img, format, err := image.Decode(file)
 	if err != nil {
-		return nil, "", fmt.Errorf("error decoding image file %s: %w", filePath, err)
+		return nil, "", fmt.Errorf("error decoding image: %w", err)
 	}
 
 	img, _ = CorrectImageOrientation(img, file)
+	return img, format, nil
+}
+
+// ImageToThumbnail decodes an image, corrects its EXIF orientation and scales
+// it down to fit within width by height, keeping its aspect ratio
+func ImageToThumbnail(file io.ReadSeeker, width, height uint) (image.Image, string, error) {
+	img, format, err := DecodeImage(file)
+	if err != nil {
+		return nil, "", err
+	}
 
-	thumbnail := resize.Resize(width, height, img, resize.Lanczos3)
+	thumbnail := resize.Thumbnail(width, height, img, resize.Lanczos3)
 	return thumbnail, format, nil
 }
 
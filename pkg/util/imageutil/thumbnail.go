@@ -0,0 +1,54 @@
+package imageutil
+
+import (
+	"autobutler/pkg/util/fileutil"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"sync"
+)
+
+// ErrNoThumbnail is returned for files that thumbnails can't be made of.
+var ErrNoThumbnail = errors.New("no thumbnail for this type of file")
+
+// Thumbnailer makes a thumbnail of file, fitting within width by height. It
+// returns the format of the source, such as "png", alongside it.
+type Thumbnailer func(file io.ReadSeeker, width, height uint) (image.Image, string, error)
+
+var (
+	thumbnailersMutex sync.RWMutex
+	thumbnailers      = map[fileutil.FileType]Thumbnailer{}
+)
+
+func init() {
+	RegisterThumbnailer(fileutil.FileTypeImage, ImageToThumbnail)
+}
+
+// RegisterThumbnailer sets how thumbnails are made of files of fileType,
+// replacing any earlier registration.
+func RegisterThumbnailer(fileType fileutil.FileType, thumbnailer Thumbnailer) {
+	thumbnailersMutex.Lock()
+	defer thumbnailersMutex.Unlock()
+	thumbnailers[fileType] = thumbnailer
+}
+
+// Thumbnail makes a thumbnail of file, of fileType, with the thumbnailer
+// registered for it.
+func Thumbnail(fileType fileutil.FileType, file io.ReadSeeker, width, height uint) (image.Image, string, error) {
+	thumbnailersMutex.RLock()
+	thumbnailer, ok := thumbnailers[fileType]
+	thumbnailersMutex.RUnlock()
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s", ErrNoThumbnail, fileType)
+	}
+	thumbnail, format, err := thumbnailer(file, width, height)
+	// Images in formats that can't be decoded, like HEIC, have none either
+	if errors.Is(err, image.ErrFormat) {
+		return nil, "", fmt.Errorf("%w: %w", ErrNoThumbnail, err)
+	}
+	return thumbnail, format, err
+}
@@ -0,0 +1,56 @@
+package queueutil
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Queue runs jobs in the background one at a time, in the order they were
+// pushed. Failed jobs are logged and skipped.
+type Queue struct {
+	action string
+	mutex  sync.Mutex
+	jobs   []func() error
+	wake   chan struct{}
+	once   sync.Once
+}
+
+// NewQueue creates a queue whose failures are logged as "Error <action>".
+func NewQueue(action string) *Queue {
+	return &Queue{
+		action: action,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Push adds a job to the end of the queue, starting the worker if needed.
+func (q *Queue) Push(job func() error) {
+	q.once.Do(func() {
+		go q.work()
+	})
+	q.mutex.Lock()
+	q.jobs = append(q.jobs, job)
+	q.mutex.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) work() {
+	for range q.wake {
+		for {
+			q.mutex.Lock()
+			if len(q.jobs) == 0 {
+				q.mutex.Unlock()
+				break
+			}
+			job := q.jobs[0]
+			q.jobs = q.jobs[1:]
+			q.mutex.Unlock()
+			if err := job(); err != nil {
+				fmt.Printf("Error %s: %v\n", q.action, err)
+			}
+		}
+	}
+}
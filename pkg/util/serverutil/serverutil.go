@@ -4,6 +4,7 @@ import (
 	"autobutler/pkg/api"
 	"autobutler/pkg/util/stringutil"
 	"fmt"
+	"html"
 	"net/http"
 	"path/filepath"
 
@@ -14,29 +15,48 @@ import (
 func wrapApiRoute(handler func(c *gin.Context) *api.Response) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		resp := handler(c)
-		if resp.Data == nil && resp.Error == nil {
-			c.Status(resp.StatusCode)
+		if resp.Data == nil && resp.Error == nil && resp.Component == nil {
+			// Handlers that streamed their own response have already sent a status
+			if !c.Writer.Written() {
+				c.Status(resp.StatusCode)
+			}
 			return
 		}
-		switch resp.ContentType {
-		case api.ContentTypeHTML:
-			if resp.Error != nil {
-				c.String(resp.StatusCode, resp.Error.Error())
-			} else {
-				c.String(resp.StatusCode, "%v", resp.Data)
-			}
-		case api.ContentTypeJSON:
-			if resp.Error != nil {
-				c.JSON(resp.StatusCode, gin.H{"error": resp.Error.Error()})
-			} else {
+		if resp.ContentType == api.ContentTypeJSON || WantsJSON(c) {
+			switch {
+			case resp.Error != nil:
+				c.JSON(resp.StatusCode, resp.Envelope())
+			case resp.Data != nil:
 				c.JSON(resp.StatusCode, resp.Data)
+			default:
+				c.Status(resp.StatusCode)
+			}
+			return
+		}
+		switch {
+		case resp.Error != nil:
+			c.String(resp.StatusCode, `<span class="text-red-500">%s</span>`, html.EscapeString(resp.Error.Error()))
+		case resp.Component != nil:
+			c.Header("Content-Type", "text/html; charset=utf-8")
+			c.Status(resp.StatusCode)
+			if err := resp.Component.Render(c.Request.Context(), c.Writer); err != nil {
+				fmt.Printf("Error rendering %s: %v\n", c.Request.URL.Path, err)
+				if !c.Writer.Written() {
+					c.String(http.StatusInternalServerError, `<span class="text-red-500">Failed to render: %s</span>`, html.EscapeString(err.Error()))
+				}
 			}
 		default:
-			c.String(http.StatusInternalServerError, "Unsupported content type")
+			c.String(resp.StatusCode, "%v", resp.Data)
 		}
 	}
 }
 
+// WantsJSON reports whether the client asked for JSON rather than HTML in its
+// Accept header. HTMX and browsers get HTML.
+func WantsJSON(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+}
+
 func ApiRoute(router *gin.RouterGroup, method string, route string, handler func(c *gin.Context) *api.Response) gin.IRoutes {
 	route = stringutil.TrimLeading(route, '/')
 	wrapped := wrapApiRoute(handler)
@@ -58,6 +78,18 @@ func ApiRoute(router *gin.RouterGroup, method string, route string, handler func
 		{
 			return router.DELETE(route, wrapped)
 		}
+	case "HEAD":
+		{
+			return router.HEAD(route, wrapped)
+		}
+	case "PATCH":
+		{
+			return router.PATCH(route, wrapped)
+		}
+	case "OPTIONS":
+		{
+			return router.OPTIONS(route, wrapped)
+		}
 	default:
 		{
 			panic(fmt.Sprintf("Unsupported HTTP method: %s", method))
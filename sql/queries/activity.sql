@@ -0,0 +1,49 @@
+-- name: CreateActivity :one
+INSERT INTO
+    activity (action, path, old_path, created_at)
+VALUES
+    (?, ?, ?, ?) RETURNING *;
+
+-- name: GetLatestActivity :one
+SELECT
+    *
+FROM
+    activity
+ORDER BY
+    id DESC
+LIMIT
+    1;
+
+-- name: ListActivity :many
+SELECT
+    *
+FROM
+    activity
+WHERE
+    id < ?
+ORDER BY
+    id DESC
+LIMIT
+    ?;
+
+-- name: ListRecentPaths :many
+SELECT
+    path,
+    CAST(MAX(id) AS INTEGER) AS last_id
+FROM
+    activity
+WHERE
+    action IN ('opened', 'added', 'modified', 'moved')
+GROUP BY
+    path
+HAVING
+    MAX(id) < sqlc.arg (before)
+ORDER BY
+    last_id DESC
+LIMIT
+    sqlc.arg (limit);
+
+-- name: PruneActivity :exec
+DELETE FROM activity
+WHERE
+    id <= ?;
@@ -0,0 +1,167 @@
+-- name: CreateAlbum :one
+INSERT INTO
+    albums (name, description, created_at, updated_at)
+VALUES
+    (?, ?, ?, ?) RETURNING *;
+
+-- name: GetAlbum :one
+SELECT
+    *
+FROM
+    albums
+WHERE
+    id = ?
+LIMIT
+    1;
+
+-- name: ListAlbums :many
+SELECT
+    albums.id,
+    albums.name,
+    albums.description,
+    albums.cover_path,
+    albums.created_at,
+    albums.updated_at,
+    COUNT(album_photos.path) AS photo_count
+FROM
+    albums
+    LEFT JOIN album_photos ON album_photos.album_id = albums.id
+GROUP BY
+    albums.id
+ORDER BY
+    albums.updated_at DESC,
+    albums.id DESC;
+
+-- name: UpdateAlbum :one
+UPDATE albums
+SET
+    name = ?,
+    description = ?,
+    cover_path = ?,
+    updated_at = ?
+WHERE
+    id = ? RETURNING *;
+
+-- name: TouchAlbum :exec
+UPDATE albums
+SET
+    updated_at = ?
+WHERE
+    id = ?;
+
+-- name: DeleteAlbum :execrows
+DELETE FROM albums
+WHERE
+    id = ?;
+
+-- name: CountAlbumPhotos :one
+SELECT
+    COUNT(*)
+FROM
+    album_photos
+WHERE
+    album_id = ?;
+
+-- name: GetFirstAlbumPhoto :one
+SELECT
+    path
+FROM
+    album_photos
+WHERE
+    album_id = ?
+ORDER BY
+    position
+LIMIT
+    1;
+
+-- name: AddAlbumPhoto :exec
+INSERT INTO
+    album_photos (album_id, path, position, added_at)
+VALUES
+    (
+        sqlc.arg (album_id),
+        sqlc.arg (path),
+        (
+            SELECT
+                COALESCE(MAX(position), -1) + 1
+            FROM
+                album_photos
+            WHERE
+                album_id = sqlc.arg (album_id)
+        ),
+        sqlc.arg (added_at)
+    ) ON CONFLICT DO NOTHING;
+
+-- name: RemoveAlbumPhoto :exec
+DELETE FROM album_photos
+WHERE
+    album_id = ?
+    AND path = ?;
+
+-- name: SetAlbumPhotoPosition :exec
+UPDATE album_photos
+SET
+    position = ?
+WHERE
+    album_id = ?
+    AND path = ?;
+
+-- name: ListAlbumPaths :many
+SELECT
+    path
+FROM
+    album_photos
+WHERE
+    album_id = ?
+ORDER BY
+    position;
+
+-- name: ListAlbumPhotos :many
+SELECT
+    sqlc.embed(photos),
+    album_photos.position
+FROM
+    album_photos
+    JOIN photos ON photos.path = album_photos.path
+WHERE
+    album_photos.album_id = sqlc.arg (album_id)
+    AND album_photos.position > sqlc.arg (after_position)
+ORDER BY
+    album_photos.position
+LIMIT
+    sqlc.arg (limit);
+
+-- name: RenameAlbumPhotos :exec
+UPDATE album_photos
+SET
+    path = sqlc.arg (new_path) || substr(path, length(sqlc.arg (old_path)) + 1)
+WHERE
+    path = sqlc.arg (old_path)
+    OR path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
+
+-- name: RenameAlbumCovers :exec
+UPDATE albums
+SET
+    cover_path = sqlc.arg (new_path) || substr(cover_path, length(sqlc.arg (old_path)) + 1)
+WHERE
+    cover_path = sqlc.arg (old_path)
+    OR cover_path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
+
+-- name: DeleteAlbumPhotosUnder :exec
+DELETE FROM album_photos
+WHERE
+    path = sqlc.arg (path)
+    OR path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
+
+-- name: ClearAlbumCoversUnder :exec
+UPDATE albums
+SET
+    cover_path = ''
+WHERE
+    cover_path = sqlc.arg (path)
+    OR cover_path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
+
+-- name: DeleteAlbumPhotosForAlbum :exec
+DELETE FROM album_photos
+WHERE
+    album_id = ?;
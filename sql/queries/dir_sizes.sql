@@ -0,0 +1,52 @@
+-- name: UpsertDirSize :exec
+INSERT INTO
+    dir_sizes (path, size_bytes, mod_time)
+VALUES
+    (?, ?, ?) ON CONFLICT (path) DO
+UPDATE
+SET
+    size_bytes = excluded.size_bytes,
+    mod_time = excluded.mod_time;
+
+-- name: GetDirSize :one
+SELECT
+    *
+FROM
+    dir_sizes
+WHERE
+    path = ?
+LIMIT
+    1;
+
+-- name: AddDirSizeDelta :exec
+UPDATE dir_sizes
+SET
+    size_bytes = size_bytes + sqlc.arg (delta)
+WHERE
+    path = sqlc.arg (path);
+
+-- name: UpdateDirSizeModTime :exec
+UPDATE dir_sizes
+SET
+    mod_time = ?
+WHERE
+    path = ?;
+
+-- name: RenameDirSizes :exec
+UPDATE dir_sizes
+SET
+    path = sqlc.arg (new_path) || substr(path, length(sqlc.arg (old_path)) + 1)
+WHERE
+    path = sqlc.arg (old_path)
+    OR path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
+
+-- name: DeleteDirSize :exec
+DELETE FROM dir_sizes
+WHERE
+    path = ?;
+
+-- name: DeleteDirSizesUnder :exec
+DELETE FROM dir_sizes
+WHERE
+    path = sqlc.arg (path)
+    OR path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
@@ -0,0 +1,53 @@
+-- name: UpsertIndexedFile :one
+INSERT INTO
+    indexed_files (path, name, file_type, size_bytes, mod_time)
+VALUES
+    (?, ?, ?, ?, ?) ON CONFLICT (path) DO
+UPDATE
+SET
+    name = excluded.name,
+    file_type = excluded.file_type,
+    size_bytes = excluded.size_bytes,
+    mod_time = excluded.mod_time RETURNING *;
+
+-- name: GetIndexedFile :one
+SELECT
+    *
+FROM
+    indexed_files
+WHERE
+    path = ?
+LIMIT
+    1;
+
+-- name: ListIndexedFiles :many
+SELECT
+    *
+FROM
+    indexed_files
+ORDER BY
+    path;
+
+-- name: ListIndexedFilesUnder :many
+SELECT
+    *
+FROM
+    indexed_files
+WHERE
+    path = sqlc.arg (path)
+    OR path LIKE sqlc.arg (prefix_pattern) ESCAPE '\'
+ORDER BY
+    path;
+
+-- name: UpdateIndexedFilePath :exec
+UPDATE indexed_files
+SET
+    path = ?,
+    name = ?
+WHERE
+    id = ?;
+
+-- name: DeleteIndexedFile :exec
+DELETE FROM indexed_files
+WHERE
+    id = ?;
@@ -0,0 +1,71 @@
+-- name: CreateJob :one
+INSERT INTO
+    jobs (kind, description, status, items_total, created_at)
+VALUES
+    (?, ?, 'queued', ?, ?) RETURNING *;
+
+-- name: GetJob :one
+SELECT
+    *
+FROM
+    jobs
+WHERE
+    id = ?
+LIMIT
+    1;
+
+-- name: ListJobs :many
+SELECT
+    *
+FROM
+    jobs
+ORDER BY
+    id DESC
+LIMIT
+    ?;
+
+-- name: StartJob :exec
+UPDATE jobs
+SET
+    status = 'running',
+    started_at = ?
+WHERE
+    id = ?;
+
+-- name: UpdateJobProgress :exec
+UPDATE jobs
+SET
+    items_done = ?,
+    items_total = ?,
+    bytes_done = ?,
+    bytes_total = ?
+WHERE
+    id = ?;
+
+-- name: FinishJob :exec
+UPDATE jobs
+SET
+    status = ?,
+    items_done = ?,
+    items_total = ?,
+    bytes_done = ?,
+    bytes_total = ?,
+    result = ?,
+    error = ?,
+    finished_at = ?
+WHERE
+    id = ?;
+
+-- name: FailUnfinishedJobs :exec
+UPDATE jobs
+SET
+    status = 'failed',
+    error = ?,
+    finished_at = ?
+WHERE
+    finished_at IS NULL;
+
+-- name: DeleteJobsFinishedBefore :exec
+DELETE FROM jobs
+WHERE
+    finished_at < ?;
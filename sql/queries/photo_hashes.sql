@@ -0,0 +1,93 @@
+-- name: ListUnhashedPhotos :many
+SELECT
+    photos.id,
+    photos.path,
+    photos.size_bytes,
+    photos.mod_time
+FROM
+    photos
+    LEFT JOIN photo_hashes ON photo_hashes.photo_id = photos.id
+WHERE
+    photo_hashes.photo_id IS NULL
+    OR photo_hashes.size_bytes != photos.size_bytes
+    OR photo_hashes.mod_time != photos.mod_time
+ORDER BY
+    photos.id;
+
+-- name: UpsertPhotoHash :exec
+INSERT INTO
+    photo_hashes (
+        photo_id,
+        size_bytes,
+        mod_time,
+        content_hash,
+        dhash,
+        phash,
+        hashed_at
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?) ON CONFLICT (photo_id) DO
+UPDATE
+SET
+    size_bytes = excluded.size_bytes,
+    mod_time = excluded.mod_time,
+    content_hash = excluded.content_hash,
+    dhash = excluded.dhash,
+    phash = excluded.phash,
+    hashed_at = excluded.hashed_at;
+
+-- name: DeleteOrphanPhotoHashes :exec
+DELETE FROM photo_hashes
+WHERE
+    photo_id NOT IN (
+        SELECT
+            id
+        FROM
+            photos
+    );
+
+-- name: ListPhotoHashes :many
+SELECT
+    photo_id,
+    content_hash,
+    dhash,
+    phash,
+    cluster_id
+FROM
+    photo_hashes
+ORDER BY
+    photo_id;
+
+-- name: SetPhotoCluster :exec
+UPDATE photo_hashes
+SET
+    cluster_id = ?
+WHERE
+    photo_id = ?;
+
+-- name: ListDuplicatePhotos :many
+SELECT
+    sqlc.embed(photos),
+    photo_hashes.content_hash,
+    photo_hashes.cluster_id
+FROM
+    photo_hashes
+    JOIN photos ON photos.id = photo_hashes.photo_id
+WHERE
+    photo_hashes.cluster_id IS NOT NULL
+ORDER BY
+    photo_hashes.cluster_id,
+    photos.id;
+
+-- name: ListDuplicateCluster :many
+SELECT
+    sqlc.embed(photos),
+    photo_hashes.content_hash,
+    photo_hashes.cluster_id
+FROM
+    photo_hashes
+    JOIN photos ON photos.id = photo_hashes.photo_id
+WHERE
+    photo_hashes.cluster_id = ?
+ORDER BY
+    photos.id;
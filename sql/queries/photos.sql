@@ -0,0 +1,119 @@
+-- name: CountPhotos :one
+SELECT
+    COUNT(*)
+FROM
+    photos
+WHERE
+    path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
+
+-- name: DeletePhoto :exec
+DELETE FROM photos
+WHERE
+    id = ?;
+
+-- name: DeletePhotosUnder :exec
+DELETE FROM photos
+WHERE
+    path = sqlc.arg (path)
+    OR path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
+
+-- name: ListPhotoTimeline :many
+SELECT
+    CAST(substr(taken_local, 1, sqlc.arg (period_length)) AS TEXT) AS period,
+    COUNT(*) AS photo_count,
+    CAST(MAX(taken_local) AS TEXT) AS latest_taken,
+    path AS cover_path
+FROM
+    photos
+WHERE
+    path LIKE sqlc.arg (prefix_pattern) ESCAPE '\'
+    AND taken_local >= sqlc.arg (taken_from)
+    AND taken_local < sqlc.arg (taken_until)
+GROUP BY
+    period
+ORDER BY
+    period DESC;
+
+-- name: ListPhotoVersionsUnder :many
+SELECT
+    id,
+    path,
+    size_bytes,
+    mod_time
+FROM
+    photos
+WHERE
+    path = sqlc.arg (path)
+    OR path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
+
+-- name: ListPhotos :many
+SELECT
+    *
+FROM
+    photos
+WHERE
+    path LIKE sqlc.arg (prefix_pattern) ESCAPE '\'
+    AND taken_local >= sqlc.arg (taken_from)
+    AND taken_local < sqlc.arg (taken_until)
+    AND (
+        taken_local < sqlc.arg (before_taken)
+        OR (
+            taken_local = sqlc.arg (before_taken)
+            AND id < sqlc.arg (before_id)
+        )
+    )
+ORDER BY
+    taken_local DESC,
+    id DESC
+LIMIT
+    sqlc.arg (limit);
+
+-- name: RenamePhotos :exec
+UPDATE photos
+SET
+    path = sqlc.arg (new_path) || substr(path, length(sqlc.arg (old_path)) + 1)
+WHERE
+    path = sqlc.arg (old_path)
+    OR path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
+
+-- name: UpsertPhoto :exec
+INSERT INTO
+    photos (
+        path,
+        size_bytes,
+        mod_time,
+        taken_local,
+        taken_offset,
+        taken_source,
+        camera_make,
+        camera_model,
+        lens_model,
+        exposure_time,
+        f_number,
+        iso,
+        focal_length,
+        width,
+        height,
+        latitude,
+        longitude
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (path) DO
+UPDATE
+SET
+    size_bytes = excluded.size_bytes,
+    mod_time = excluded.mod_time,
+    taken_local = excluded.taken_local,
+    taken_offset = excluded.taken_offset,
+    taken_source = excluded.taken_source,
+    camera_make = excluded.camera_make,
+    camera_model = excluded.camera_model,
+    lens_model = excluded.lens_model,
+    exposure_time = excluded.exposure_time,
+    f_number = excluded.f_number,
+    iso = excluded.iso,
+    focal_length = excluded.focal_length,
+    width = excluded.width,
+    height = excluded.height,
+    latitude = excluded.latitude,
+    longitude = excluded.longitude;
@@ -0,0 +1,38 @@
+-- name: DeleteQuota :execrows
+DELETE FROM quotas
+WHERE
+    folder = ?;
+
+-- name: GetQuota :one
+SELECT
+    *
+FROM
+    quotas
+WHERE
+    folder = ?
+LIMIT
+    1;
+
+-- name: ListQuotas :many
+SELECT
+    *
+FROM
+    quotas
+ORDER BY
+    folder;
+
+-- name: RenameQuota :exec
+UPDATE quotas
+SET
+    folder = sqlc.arg (new_folder)
+WHERE
+    folder = sqlc.arg (old_folder);
+
+-- name: SetQuota :one
+INSERT INTO
+    quotas (folder, max_bytes, max_files, created_at)
+VALUES
+    (?, ?, ?, ?) ON CONFLICT (folder) DO UPDATE
+SET
+    max_bytes = excluded.max_bytes,
+    max_files = excluded.max_files RETURNING *;
@@ -0,0 +1,51 @@
+-- name: CreateShare :one
+INSERT INTO
+    shares (
+        id,
+        path,
+        password_hash,
+        max_downloads,
+        created_at,
+        expires_at
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: GetShare :one
+SELECT
+    *
+FROM
+    shares
+WHERE
+    id = ?
+LIMIT
+    1;
+
+-- name: ListShares :many
+SELECT
+    *
+FROM
+    shares
+ORDER BY
+    created_at DESC;
+
+-- name: CountShareDownload :execrows
+UPDATE shares
+SET
+    downloads = downloads + 1
+WHERE
+    id = ?
+    AND (
+        max_downloads = 0
+        OR downloads < max_downloads
+    );
+
+-- name: DeleteShare :execrows
+DELETE FROM shares
+WHERE
+    id = ?;
+
+-- name: DeleteExpiredShares :exec
+DELETE FROM shares
+WHERE
+    expires_at < ?;
@@ -0,0 +1,49 @@
+-- name: AddStar :exec
+INSERT INTO
+    stars (path, created_at)
+VALUES
+    (?, ?) ON CONFLICT DO NOTHING;
+
+-- name: CountStar :one
+SELECT
+    COUNT(*)
+FROM
+    stars
+WHERE
+    path = ?;
+
+-- name: DeleteStarsUnder :exec
+DELETE FROM stars
+WHERE
+    path = sqlc.arg (path)
+    OR path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
+
+-- name: ListStarredPaths :many
+SELECT
+    path
+FROM
+    stars
+ORDER BY
+    path;
+
+-- name: ListStarredPathsInDir :many
+SELECT
+    path
+FROM
+    stars
+WHERE
+    path LIKE sqlc.arg (prefix_pattern) ESCAPE '\'
+    AND path NOT LIKE sqlc.arg (nested_pattern) ESCAPE '\';
+
+-- name: RemoveStar :exec
+DELETE FROM stars
+WHERE
+    path = ?;
+
+-- name: RenameStars :exec
+UPDATE stars
+SET
+    path = sqlc.arg (new_path) || substr(path, length(sqlc.arg (old_path)) + 1)
+WHERE
+    path = sqlc.arg (old_path)
+    OR path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
@@ -0,0 +1,121 @@
+-- name: CreateTag :one
+INSERT INTO
+    tags (name, color, created_at)
+VALUES
+    (?, ?, ?) RETURNING *;
+
+-- name: GetTag :one
+SELECT
+    *
+FROM
+    tags
+WHERE
+    id = ?
+LIMIT
+    1;
+
+-- name: GetTagByName :one
+SELECT
+    *
+FROM
+    tags
+WHERE
+    name = ?
+LIMIT
+    1;
+
+-- name: ListTags :many
+SELECT
+    tags.id,
+    tags.name,
+    tags.color,
+    tags.created_at,
+    COUNT(file_tags.path) AS file_count
+FROM
+    tags
+    LEFT JOIN file_tags ON file_tags.tag_id = tags.id
+GROUP BY
+    tags.id
+ORDER BY
+    tags.name;
+
+-- name: UpdateTag :one
+UPDATE tags
+SET
+    name = ?,
+    color = ?
+WHERE
+    id = ? RETURNING *;
+
+-- name: DeleteTag :execrows
+DELETE FROM tags
+WHERE
+    id = ?;
+
+-- name: AddFileTag :exec
+INSERT INTO
+    file_tags (path, tag_id)
+VALUES
+    (?, ?) ON CONFLICT DO NOTHING;
+
+-- name: RemoveFileTag :exec
+DELETE FROM file_tags
+WHERE
+    path = ?
+    AND tag_id = ?;
+
+-- name: ListTagsForPath :many
+SELECT
+    tags.*
+FROM
+    tags
+    JOIN file_tags ON file_tags.tag_id = tags.id
+WHERE
+    file_tags.path = ?
+ORDER BY
+    tags.name;
+
+-- name: ListFileTagsInDir :many
+SELECT
+    file_tags.path,
+    tags.id,
+    tags.name,
+    tags.color,
+    tags.created_at
+FROM
+    file_tags
+    JOIN tags ON tags.id = file_tags.tag_id
+WHERE
+    file_tags.path LIKE sqlc.arg (prefix_pattern) ESCAPE '\'
+    AND file_tags.path NOT LIKE sqlc.arg (nested_pattern) ESCAPE '\'
+ORDER BY
+    tags.name;
+
+-- name: ListTaggedPaths :many
+SELECT
+    path
+FROM
+    file_tags
+WHERE
+    tag_id = ?
+ORDER BY
+    path;
+
+-- name: RenameFileTags :exec
+UPDATE file_tags
+SET
+    path = sqlc.arg (new_path) || substr(path, length(sqlc.arg (old_path)) + 1)
+WHERE
+    path = sqlc.arg (old_path)
+    OR path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
+
+-- name: DeleteFileTagsUnder :exec
+DELETE FROM file_tags
+WHERE
+    path = sqlc.arg (path)
+    OR path LIKE sqlc.arg (prefix_pattern) ESCAPE '\';
+
+-- name: DeleteFileTagsForTag :exec
+DELETE FROM file_tags
+WHERE
+    tag_id = ?;
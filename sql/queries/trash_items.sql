@@ -0,0 +1,44 @@
+-- name: CreateTrashItem :one
+INSERT INTO
+    trash_items (
+        original_path,
+        trash_name,
+        is_dir,
+        size_bytes,
+        deleted_at
+    )
+VALUES
+    (?, ?, ?, ?, ?) RETURNING *;
+
+-- name: GetTrashItem :one
+SELECT
+    *
+FROM
+    trash_items
+WHERE
+    id = ?
+LIMIT
+    1;
+
+-- name: ListTrashItems :many
+SELECT
+    *
+FROM
+    trash_items
+ORDER BY
+    deleted_at DESC;
+
+-- name: ListTrashItemsDeletedBefore :many
+SELECT
+    *
+FROM
+    trash_items
+WHERE
+    deleted_at < ?
+ORDER BY
+    deleted_at;
+
+-- name: DeleteTrashItem :exec
+DELETE FROM trash_items
+WHERE
+    id = ?;
@@ -0,0 +1,45 @@
+-- name: CreateUpload :one
+INSERT INTO
+    uploads (
+        id,
+        file_name,
+        root_dir,
+        size_bytes,
+        metadata,
+        created_at,
+        expires_at
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: GetUpload :one
+SELECT
+    *
+FROM
+    uploads
+WHERE
+    id = ?
+LIMIT
+    1;
+
+-- name: ListUploadsExpiredBefore :many
+SELECT
+    *
+FROM
+    uploads
+WHERE
+    expires_at < ?
+ORDER BY
+    expires_at;
+
+-- name: UpdateUploadExpiry :exec
+UPDATE uploads
+SET
+    expires_at = ?
+WHERE
+    id = ?;
+
+-- name: DeleteUpload :exec
+DELETE FROM uploads
+WHERE
+    id = ?;
@@ -0,0 +1,114 @@
+import { test, expect, APIRequestContext } from '@playwright/test';
+import { upload } from './helpers';
+
+async function starredPaths(request: APIRequestContext) {
+    const body = await (await request.get('/api/v1/stars')).json();
+    return body.files.map((file: { path: string }) => file.path);
+}
+
+async function latestEvents(request: APIRequestContext, limit: number) {
+    const body = await (await request.get(`/api/v1/activity?limit=${limit}`)).json();
+    return body.events.map((event: { action: string; path: string }) => ({
+        action: event.action,
+        path: event.path,
+    }));
+}
+
+test.describe('Stars and activity', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `activity-${Date.now()}`;
+        base = `/${name}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: name } });
+        await upload(request, base, 'note.txt', 'hello');
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('stars files and follows them', async ({ request }) => {
+        const starred = await request.post('/api/v1/stars', {
+            data: { paths: [`${base}/note.txt`] },
+        });
+        expect(starred.status()).toBe(204);
+        expect(await starredPaths(request)).toContain(`${base}/note.txt`);
+
+        await request.put(`/api/v1/files${base}/note.txt`, {
+            form: { newFilePath: `${base}/moved.txt` },
+        });
+        const paths = await starredPaths(request);
+        expect(paths).toContain(`${base}/moved.txt`);
+        expect(paths).not.toContain(`${base}/note.txt`);
+
+        const unstarred = await request.delete(`/api/v1/stars?filePaths=${base}/moved.txt`);
+        expect(unstarred.status()).toBe(204);
+        expect(await starredPaths(request)).not.toContain(`${base}/moved.txt`);
+    });
+
+    test('keeps the stars of folders named the same but for case', async ({ request }) => {
+        for (const folderName of ['Photos', 'photos']) {
+            await request.post(`/api/v1/folder/files${base}`, { form: { folderName } });
+            await upload(request, `${base}/${folderName}`, 'a.txt', folderName);
+        }
+        await request.post('/api/v1/stars', {
+            data: { paths: [`${base}/Photos/a.txt`, `${base}/photos/a.txt`] },
+        });
+
+        await request.delete(`/api/v1/files?rootDir=${base}&filePaths=Photos`);
+        await expect.poll(() => starredPaths(request)).not.toContain(`${base}/Photos/a.txt`);
+        expect(await starredPaths(request)).toContain(`${base}/photos/a.txt`);
+    });
+
+    test('records opens, moves and deletes', async ({ request }) => {
+        await request.get(`/components/files/viewer/files${base}/note.txt`);
+        await request.put(`/api/v1/files${base}/note.txt`, {
+            form: { newFilePath: `${base}/moved.txt` },
+        });
+        await request.delete(`/api/v1/files?rootDir=${base}&filePaths=moved.txt`);
+
+        expect(await latestEvents(request, 3)).toEqual([
+            { action: 'deleted', path: `${base}/moved.txt` },
+            { action: 'moved', path: `${base}/moved.txt` },
+            { action: 'opened', path: `${base}/note.txt` },
+        ]);
+        const older = await request.get('/api/v1/activity?before=1');
+        expect((await older.json()).events).toEqual([]);
+        expect((await request.get('/api/v1/activity?limit=nope')).status()).toBe(400);
+    });
+
+    test('lists recent files', async ({ request }) => {
+        await upload(request, base, 'other.txt', 'world');
+        await request.get(`/components/files/viewer/files${base}/note.txt`);
+        const body = await (await request.get('/api/v1/recent?limit=2')).json();
+        expect(body.files.map((file: { path: string }) => file.path)).toEqual([
+            `${base}/note.txt`,
+            `${base}/other.txt`,
+        ]);
+    });
+
+    test('stars from the context menu and shows the starred folder', async ({ page }) => {
+        await page.goto(`/files${base}`);
+        const fileRow = page.locator('tr.file-table-row[data-name="note.txt"]');
+        await fileRow.locator('.context-menu-trigger').click();
+        await fileRow.locator('.context-menu-item:has-text("Star")').dispatchEvent('click');
+        await expect(fileRow.locator('.file-star')).toBeVisible();
+
+        await page.goto('/starred');
+        await expect(page.locator('.file-explorer-title')).toHaveText('Starred');
+        await expect(
+            page.locator(`tr.file-table-row[data-name="${base.slice(1)}/note.txt"]`)
+        ).toBeVisible();
+    });
+
+    test('shows the activity feed and recent files on the home page', async ({ page }) => {
+        await page.goto('/activity');
+        await expect(
+            page.locator('.activity-feed-item', { hasText: `${base.slice(1)}/note.txt` }).first()
+        ).toBeVisible();
+
+        await page.goto('/');
+        await expect(page.locator('.recent-files-item').first()).toBeVisible();
+    });
+});
@@ -0,0 +1,191 @@
+import { test, expect, APIRequestContext } from '@playwright/test';
+import fs from 'fs';
+
+async function albumPhotos(request: APIRequestContext, id: number) {
+    const response = await request.get(`/api/v1/albums/${id}/photos`);
+    expect(response.ok()).toBeTruthy();
+    return (await response.json()).photos.map((p: { path: string }) => p.path);
+}
+
+test.describe('Albums', () => {
+    let base: string;
+    let dir: string;
+    let albumId: number;
+
+    test.beforeEach(async ({ request }) => {
+        dir = `albums-${Date.now()}`;
+        base = `/${dir}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: dir } });
+        const form = new FormData();
+        for (const name of ['a.jpg', 'b.jpg', 'c.jpg']) {
+            const photo = fs.readFileSync('./tests/e2e/data/exif-photo.jpg');
+            form.append('files', new Blob([photo], { type: 'image/jpeg' }), name);
+        }
+        form.append('files', new Blob(['not a photo'], { type: 'text/plain' }), 'notes.txt');
+        await request.post(`/api/v1/files${base}`, { multipart: form });
+
+        const response = await request.post('/api/v1/albums', {
+            data: { name: `Album ${dir}`, description: 'A day out' },
+        });
+        expect(response.status()).toBe(201);
+        albumId = (await response.json()).id;
+        const added = await request.post(`/api/v1/albums/${albumId}/photos`, {
+            data: { paths: [`${base}/a.jpg`, `${base}/b.jpg`, `${base}/c.jpg`] },
+        });
+        expect(added.status()).toBe(204);
+        // Photos show up once the catalogue has read them
+        await expect.poll(async () => (await albumPhotos(request, albumId)).length).toBe(3);
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/albums/${albumId}`);
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${dir}`);
+    });
+
+    test('lists photos in the order they were added', async ({ request }) => {
+        expect(await albumPhotos(request, albumId)).toEqual([
+            `${dir}/a.jpg`,
+            `${dir}/b.jpg`,
+            `${dir}/c.jpg`,
+        ]);
+
+        const album = await (await request.get(`/api/v1/albums/${albumId}`)).json();
+        expect(album.photoCount).toBe(3);
+        expect(album.description).toBe('A day out');
+        // Without a chosen cover, the first photo stands for the album
+        expect(album.coverPath).toBe(`${dir}/a.jpg`);
+    });
+
+    test('only takes photos', async ({ request }) => {
+        const response = await request.post(`/api/v1/albums/${albumId}/photos`, {
+            data: { paths: [`${base}/notes.txt`] },
+        });
+        expect(response.status()).toBe(400);
+    });
+
+    test('reorders photos and chooses a cover', async ({ request }) => {
+        const reordered = await request.put(`/api/v1/albums/${albumId}/order`, {
+            data: { paths: [`${base}/c.jpg`] },
+        });
+        expect(reordered.status()).toBe(204);
+        expect(await albumPhotos(request, albumId)).toEqual([
+            `${dir}/c.jpg`,
+            `${dir}/a.jpg`,
+            `${dir}/b.jpg`,
+        ]);
+
+        const updated = await request.patch(`/api/v1/albums/${albumId}`, {
+            data: { coverPath: `${base}/b.jpg` },
+        });
+        expect((await updated.json()).coverPath).toBe(`${dir}/b.jpg`);
+
+        const outside = await request.patch(`/api/v1/albums/${albumId}`, {
+            data: { coverPath: `${base}/notes.txt` },
+        });
+        expect(outside.status()).toBe(400);
+    });
+
+    test('pages through photos with a cursor', async ({ request }) => {
+        const seen: string[] = [];
+        let cursor = '';
+        do {
+            const response = await request.get(
+                `/api/v1/albums/${albumId}/photos?limit=2&cursor=${cursor}`
+            );
+            const body = await response.json();
+            seen.push(...body.photos.map((p: { name: string }) => p.name));
+            cursor = body.nextCursor ?? '';
+        } while (cursor);
+        expect(seen).toEqual(['a.jpg', 'b.jpg', 'c.jpg']);
+    });
+
+    test('follows photos that are moved or deleted', async ({ request }) => {
+        await request.patch(`/api/v1/albums/${albumId}`, {
+            data: { coverPath: `${base}/c.jpg` },
+        });
+        await request.put(`/api/v1/files${base}/a.jpg`, {
+            form: { newFilePath: `${base}/renamed.jpg` },
+        });
+        await request.delete(`/api/v1/files?rootDir=${base}&filePaths=c.jpg`);
+
+        await expect
+            .poll(async () => albumPhotos(request, albumId))
+            .toEqual([`${dir}/renamed.jpg`, `${dir}/b.jpg`]);
+        const album = await (await request.get(`/api/v1/albums/${albumId}`)).json();
+        expect(album.photoCount).toBe(2);
+        // The deleted cover gives way to the first photo
+        expect(album.coverPath).toBe(`${dir}/renamed.jpg`);
+    });
+
+    test('leaves folders named the same but for case alone', async ({ request }) => {
+        const form = new FormData();
+        const photo = fs.readFileSync('./tests/e2e/data/exif-photo.jpg');
+        for (const folderName of ['Trip', 'trip']) {
+            form.append('files', new Blob([photo], { type: 'image/jpeg' }), 'd.jpg');
+            form.append('paths', `${folderName}/d.jpg`);
+        }
+        await request.post(`/api/v1/files${base}`, { multipart: form });
+        await request.post(`/api/v1/albums/${albumId}/photos`, {
+            data: { paths: [`${base}/Trip/d.jpg`, `${base}/trip/d.jpg`] },
+        });
+        await expect.poll(async () => (await albumPhotos(request, albumId)).length).toBe(5);
+
+        await request.put(`/api/v1/files${base}/Trip`, {
+            form: { newFilePath: `${base}/Moved` },
+        });
+        await expect
+            .poll(async () => (await albumPhotos(request, albumId)).slice(3))
+            .toEqual([`${dir}/Moved/d.jpg`, `${dir}/trip/d.jpg`]);
+    });
+
+    test('rejects an order with photos outside the album', async ({ request }) => {
+        const reordered = await request.put(`/api/v1/albums/${albumId}/order`, {
+            data: { paths: [`${base}/c.jpg`, `${base}/notes.txt`] },
+        });
+        expect(reordered.status()).toBe(400);
+        expect(await albumPhotos(request, albumId)).toEqual([
+            `${dir}/a.jpg`,
+            `${dir}/b.jpg`,
+            `${dir}/c.jpg`,
+        ]);
+    });
+
+    test('downloads as a zip named after the album', async ({ request }) => {
+        const response = await request.get(`/api/v1/albums/${albumId}/zip`);
+        expect(response.ok()).toBeTruthy();
+        expect(response.headers()['content-type']).toBe('application/zip');
+        expect(response.headers()['content-disposition']).toContain(`Album ${dir}.zip`);
+        const body = await response.body();
+        // Zip archives start with a local file header
+        expect(body.subarray(0, 4).toString('hex')).toBe('504b0304');
+    });
+
+    test('deleting an album keeps its photos', async ({ request }) => {
+        const deleted = await request.delete(`/api/v1/albums/${albumId}`);
+        expect(deleted.status()).toBe(204);
+        expect((await request.get(`/api/v1/albums/${albumId}`)).status()).toBe(404);
+        expect((await request.get(`/api/v1/files${base}/a.jpg`)).ok()).toBeTruthy();
+    });
+
+    test('shows the album page with its photos', async ({ page }) => {
+        await page.goto('/albums');
+        const card = page.locator(`.album-card[data-album-id="${albumId}"]`);
+        await expect(card).toContainText(`Album ${dir}`);
+        await expect(card).toContainText('3 photos');
+
+        await card.click();
+        await expect(page).toHaveURL(`/albums/${albumId}`);
+        await expect(page.locator('.photos-title')).toHaveText(`Album ${dir}`);
+        await expect(page.locator('.album-description')).toHaveText('A day out');
+        await expect(page.locator('.photo-grid-item')).toHaveCount(3);
+        await expect(page.locator('.album-download')).toHaveAttribute(
+            'href',
+            `/api/v1/albums/${albumId}/zip`
+        );
+    });
+
+    test('unknown albums are not found', async ({ page }) => {
+        const response = await page.goto('/albums/999999999');
+        expect(response?.status()).toBe(404);
+    });
+});
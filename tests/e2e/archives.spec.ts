@@ -0,0 +1,126 @@
+import { test, expect, APIRequestContext } from '@playwright/test';
+import { upload } from './helpers';
+
+/**
+ * Build an uncompressed tarball by hand, so that entries can be given names no
+ * archiver would produce
+ */
+function buildTar(files: Record<string, string>): Buffer {
+    const blocks: Buffer[] = [];
+    for (const [name, text] of Object.entries(files)) {
+        const content = Buffer.from(text);
+        const header = Buffer.alloc(512);
+        header.write(name, 0, 100);
+        header.write('0000644\0', 100);
+        header.write('0000000\0', 108);
+        header.write('0000000\0', 116);
+        header.write(content.length.toString(8).padStart(11, '0') + '\0', 124);
+        header.write(Math.floor(Date.now() / 1000).toString(8).padStart(11, '0') + '\0', 136);
+        header.write('        ', 148);
+        header.write('0', 156);
+        header.write('ustar\0' + '00', 257);
+        const checksum = header.reduce((sum, byte) => sum + byte, 0);
+        header.write(checksum.toString(8).padStart(6, '0') + '\0 ', 148);
+        const padding = Buffer.alloc((512 - (content.length % 512)) % 512);
+        blocks.push(header, content, padding);
+    }
+    blocks.push(Buffer.alloc(1024));
+    return Buffer.concat(blocks);
+}
+
+test.describe('Archives', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `archives-${Date.now()}`;
+        base = `/${name}`;
+        const created = await request.post('/api/v1/folder/files/', { form: { folderName: name } });
+        expect(created.ok()).toBeTruthy();
+        await upload(
+            request,
+            base,
+            'docs.tar',
+            buildTar({ 'docs/a.txt': 'hello', 'docs/sub/b.txt': 'world' })
+        );
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('lists entries without extracting', async ({ request }) => {
+        const response = await request.get(`/api/v1/archive/files${base}/docs.tar`);
+        expect(response.status()).toBe(200);
+        const { entries } = await response.json();
+        expect(entries.map((entry: { name: string }) => entry.name)).toEqual([
+            'docs/a.txt',
+            'docs/sub/b.txt',
+        ]);
+        expect(entries[0]).toMatchObject({ isDir: false, sizeBytes: 5 });
+
+        const missing = await request.head(`/api/v1/files${base}/docs`);
+        expect(missing.status()).toBe(404);
+    });
+
+    test('downloads a single entry', async ({ request }) => {
+        const response = await request.get(
+            `/api/v1/archive/files${base}/docs.tar?entry=docs/sub/b.txt`
+        );
+        expect(response.status()).toBe(200);
+        expect(response.headers()['content-disposition']).toContain('attachment');
+        expect(await response.text()).toBe('world');
+
+        const unknown = await request.get(`/api/v1/archive/files${base}/docs.tar?entry=nope`);
+        expect(unknown.status()).toBe(404);
+    });
+
+    test('extracts everything or a selection', async ({ request }) => {
+        const all = await request.post(`/api/v1/extract/files${base}/docs.tar`, { data: {} });
+        expect(all.status()).toBe(200);
+        const body = await all.json();
+        expect(body.job).toMatchObject({ kind: 'extract', status: 'succeeded', itemsDone: 2 });
+        expect(body.results).toEqual([`${base}/docs`]);
+        const extracted = await request.get(`/api/v1/files${base}/docs/docs/a.txt`);
+        expect(await extracted.text()).toBe('hello');
+
+        const selected = await request.post(`/api/v1/extract/files${base}/docs.tar`, {
+            data: { entries: ['docs/sub'], destination: `${base}/picked` },
+        });
+        expect(selected.status()).toBe(200);
+        const picked = await request.get(`/api/v1/files${base}/picked/docs/sub/b.txt`);
+        expect(await picked.text()).toBe('world');
+        const skipped = await request.head(`/api/v1/files${base}/picked/docs/a.txt`);
+        expect(skipped.status()).toBe(404);
+    });
+
+    test('refuses entries escaping the destination', async ({ request }) => {
+        await upload(request, base, 'evil.tar', buildTar({ '../evil.txt': 'x', 'ok.txt': 'y' }));
+
+        const response = await request.post(`/api/v1/extract/files${base}/evil.tar`, { data: {} });
+        expect(response.status()).toBe(422);
+        const escaped = await request.head('/api/v1/files/evil.txt');
+        expect(escaped.status()).toBe(404);
+        const nothing = await request.head(`/api/v1/files${base}/evil`);
+        expect(nothing.status()).toBe(404);
+    });
+
+    test('extracts from the archive viewer', async ({ page, request }) => {
+        await page.goto(`/files${base}`);
+        const fileRow = page.locator('tr.file-table-row[data-name="docs.tar"]');
+        await fileRow.locator('.file-table-cell--clickable').dblclick();
+
+        const viewer = page.locator('.file-viewer-archive');
+        await expect(viewer).toBeVisible();
+        await expect(viewer.locator('.file-viewer-archive-entry')).toHaveCount(2);
+        await viewer.locator('input[name="entry"][value="docs/a.txt"]').check();
+        await viewer.locator('input[name="destination"]').fill(`${base}/from-viewer`);
+        await viewer.getByRole('button', { name: 'Extract selected' }).click();
+
+        await expect
+            .poll(async () => {
+                const extracted = await request.get(`/api/v1/files${base}/from-viewer/docs/a.txt`);
+                return extracted.ok() ? extracted.text() : '';
+            })
+            .toBe('hello');
+    });
+});
@@ -0,0 +1,65 @@
+import { test, expect } from '@playwright/test';
+
+test.describe('File Downloads', () => {
+    test.beforeAll(async ({ browser }) => {
+        // Make sure there is a known file to download
+        const page = await browser.newPage();
+        await page.goto('/files');
+        await page.locator('input[type="file"]').setInputFiles('./tests/e2e/data/test-image.png');
+        await expect(page.locator('tr.file-table-row[data-name="test-image.png"]').first()).toBeVisible({
+            timeout: 10000,
+        });
+        await page.close();
+    });
+
+    test('sniffs the content type and sends validators', async ({ request }) => {
+        const response = await request.get('/api/v1/files/test-image.png');
+        expect(response.status()).toBe(200);
+
+        const headers = response.headers();
+        expect(headers['content-type']).toBe('image/png');
+        expect(headers['etag']).toMatch(/^"[0-9a-f]+-[0-9a-f]+"$/);
+        expect(headers['last-modified']).toBeTruthy();
+        expect(headers['accept-ranges']).toBe('bytes');
+        expect(headers['content-disposition']).toContain('test-image.png');
+    });
+
+    test('answers If-None-Match with 304 Not Modified', async ({ request }) => {
+        const first = await request.get('/api/v1/files/test-image.png');
+        const etag = first.headers()['etag'];
+
+        const second = await request.get('/api/v1/files/test-image.png', {
+            headers: { 'If-None-Match': etag },
+        });
+        expect(second.status()).toBe(304);
+    });
+
+    test('serves single and multi-range requests', async ({ request }) => {
+        const single = await request.get('/api/v1/files/test-image.png', {
+            headers: { Range: 'bytes=0-7' },
+        });
+        expect(single.status()).toBe(206);
+        expect(single.headers()['content-range']).toMatch(/^bytes 0-7\/\d+$/);
+        expect((await single.body()).length).toBe(8);
+
+        const multi = await request.get('/api/v1/files/test-image.png', {
+            headers: { Range: 'bytes=0-3,8-11' },
+        });
+        expect(multi.status()).toBe(206);
+        expect(multi.headers()['content-type']).toContain('multipart/byteranges');
+    });
+
+    test('ignores the range when If-Range does not match', async ({ request }) => {
+        const response = await request.get('/api/v1/files/test-image.png', {
+            headers: { Range: 'bytes=0-7', 'If-Range': '"stale"' },
+        });
+        expect(response.status()).toBe(200);
+    });
+
+    test('streams folders as zip attachments', async ({ request }) => {
+        const response = await request.head('/api/v1/files/');
+        expect(response.status()).toBe(200);
+        expect(response.headers()['content-type']).toBe('application/zip');
+        expect(response.headers()['content-disposition']).toMatch(/^attachment; filename=.*\.zip$/);
+    });
+});
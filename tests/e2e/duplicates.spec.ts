@@ -0,0 +1,152 @@
+import { test, expect, APIRequestContext } from '@playwright/test';
+import fs from 'fs';
+
+type Cluster = {
+    id: number;
+    kind: string;
+    reclaimableBytes: number;
+    photos: { path: string; best: boolean; width: number; height: number }[];
+};
+
+// findCluster returns the cluster holding the photo at path, once the scanner
+// has found it
+async function findCluster(request: APIRequestContext, path: string) {
+    let found: Cluster | undefined;
+    await expect
+        .poll(async () => {
+            const response = await request.get('/api/v1/duplicates');
+            const clusters: Cluster[] = (await response.json()).clusters;
+            found = clusters.find((c) => c.photos.some((p) => p.path === path));
+            return found?.photos.length ?? 0;
+        })
+        .toBeGreaterThan(0);
+    return found!;
+}
+
+test.describe('Duplicates', () => {
+    let base: string;
+    let dir: string;
+
+    test.beforeEach(async ({ request }) => {
+        dir = `duplicates-${Date.now()}`;
+        base = `/${dir}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: dir } });
+        // Bytes after the end of a JPEG don't change the picture, so this
+        // makes the photos unique to the test without changing how they look
+        const photo = Buffer.concat([
+            fs.readFileSync('./tests/e2e/data/exif-photo.jpg'),
+            Buffer.from(dir),
+        ]);
+        const form = new FormData();
+        form.append('files', new Blob([photo], { type: 'image/jpeg' }), 'original.jpg');
+        form.append('files', new Blob([photo], { type: 'image/jpeg' }), 'copy.jpg');
+        await request.post(`/api/v1/files${base}`, { multipart: form });
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${dir}`);
+    });
+
+    test('clusters exact copies', async ({ request }) => {
+        const cluster = await findCluster(request, `${dir}/original.jpg`);
+        expect(cluster.kind).toBe('exact');
+        expect(cluster.photos.map((p) => p.path).sort()).toEqual([
+            `${dir}/copy.jpg`,
+            `${dir}/original.jpg`,
+        ]);
+        expect(cluster.photos.filter((p) => p.best)).toHaveLength(1);
+        expect(cluster.photos[0].best).toBeTruthy();
+        expect(cluster.photos[0].width).toBeGreaterThan(0);
+        expect(cluster.reclaimableBytes).toBeGreaterThan(0);
+
+        const response = await request.get(`/api/v1/duplicates/${cluster.id}`);
+        expect(response.ok()).toBeTruthy();
+        expect((await response.json()).id).toBe(cluster.id);
+    });
+
+    test('clusters copies that look alike', async ({ request }) => {
+        // The same picture with different bytes, like a recompressed copy.
+        // The photos above are too flat to be compared by how they look.
+        const form = new FormData();
+        for (const name of ['picture.jpg', 'recompressed.jpg']) {
+            const photo = Buffer.concat([
+                fs.readFileSync('./tests/e2e/data/test-image.jpg'),
+                Buffer.from(`${dir}/${name}`),
+            ]);
+            form.append('files', new Blob([photo], { type: 'image/jpeg' }), name);
+        }
+        await request.post(`/api/v1/files${base}`, { multipart: form });
+
+        const cluster = await findCluster(request, `${dir}/picture.jpg`);
+        expect(cluster.kind).toBe('similar');
+        expect(cluster.photos.map((p) => p.path).sort()).toEqual([
+            `${dir}/picture.jpg`,
+            `${dir}/recompressed.jpg`,
+        ]);
+    });
+
+    test('keeps one copy and trashes the rest', async ({ request }) => {
+        const cluster = await findCluster(request, `${dir}/original.jpg`);
+        const response = await request.post(`/api/v1/duplicates/${cluster.id}/resolve`, {
+            data: { keep: `${base}/original.jpg`, paths: cluster.photos.map((p) => p.path) },
+        });
+        expect(response.ok()).toBeTruthy();
+
+        expect((await request.get(`/api/v1/files${base}/original.jpg`)).ok()).toBeTruthy();
+        expect((await request.get(`/api/v1/files${base}/copy.jpg`)).status()).toBe(404);
+        await expect
+            .poll(async () => (await request.get(`/api/v1/duplicates/${cluster.id}`)).status())
+            .toBe(404);
+    });
+
+    test('only keeps photos of the cluster', async ({ request }) => {
+        const cluster = await findCluster(request, `${dir}/original.jpg`);
+        const response = await request.post(`/api/v1/duplicates/${cluster.id}/resolve`, {
+            data: { keep: `${base}/elsewhere.jpg`, paths: cluster.photos.map((p) => p.path) },
+        });
+        expect(response.status()).toBe(400);
+        expect((await request.get(`/api/v1/files${base}/copy.jpg`)).ok()).toBeTruthy();
+    });
+
+    test('leaves clusters that changed since they were reviewed', async ({ request }) => {
+        const reviewed = await findCluster(request, `${dir}/original.jpg`);
+        const form = new FormData();
+        const photo = Buffer.concat([
+            fs.readFileSync('./tests/e2e/data/exif-photo.jpg'),
+            Buffer.from(dir),
+        ]);
+        form.append('files', new Blob([photo], { type: 'image/jpeg' }), 'another.jpg');
+        await request.post(`/api/v1/files${base}`, { multipart: form });
+        await expect
+            .poll(async () => (await findCluster(request, `${dir}/another.jpg`)).photos.length)
+            .toBe(3);
+
+        const response = await request.post(`/api/v1/duplicates/${reviewed.id}/resolve`, {
+            data: { keep: `${base}/original.jpg`, paths: reviewed.photos.map((p) => p.path) },
+        });
+        expect(response.status()).toBe(409);
+        expect((await request.get(`/api/v1/files${base}/copy.jpg`)).ok()).toBeTruthy();
+        expect((await request.get(`/api/v1/files${base}/another.jpg`)).ok()).toBeTruthy();
+    });
+
+    test('scans on request', async ({ request }) => {
+        const response = await request.post('/api/v1/duplicates/scan');
+        expect(response.status()).toBe(202);
+    });
+
+    test('reviews duplicates on the duplicates page', async ({ page, request }) => {
+        const cluster = await findCluster(request, `${dir}/original.jpg`);
+        await page.goto('/duplicates');
+        const section = page.locator(`.duplicates-cluster[data-cluster-id="${cluster.id}"]`);
+        await expect(section.locator('.duplicates-copy')).toHaveCount(2);
+        await expect(section.locator('.duplicates-best')).toHaveCount(1);
+        await expect(section).toContainText('Exact copies');
+
+        page.on('dialog', (dialog) => dialog.accept());
+        await section
+            .locator(`.duplicates-copy[data-path="${dir}/original.jpg"] .duplicates-keep`)
+            .click();
+        await expect(section).toHaveCount(0);
+        expect((await request.get(`/api/v1/files${base}/copy.jpg`)).status()).toBe(404);
+    });
+});
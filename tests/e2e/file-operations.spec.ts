@@ -0,0 +1,130 @@
+import { test, expect, APIRequestContext } from '@playwright/test';
+import { upload } from './helpers';
+
+type OperationResult = {
+    source: string;
+    destination?: string;
+    status: 'moved' | 'copied' | 'skipped' | 'failed';
+    error?: string;
+};
+
+async function newFolder(request: APIRequestContext, parent: string, folderName: string) {
+    const response = await request.post(`/api/v1/folder/files${parent}`, {
+        form: { folderName },
+    });
+    expect(response.ok()).toBeTruthy();
+}
+
+async function runOperation(
+    request: APIRequestContext,
+    action: string,
+    data: object
+): Promise<OperationResult[]> {
+    const response = await request.post(`/api/v1/${action}/files`, { data });
+    expect(response.status()).toBe(200);
+    return (await response.json()).results;
+}
+
+test.describe('File Operations', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `ops-${Date.now()}`;
+        base = `/${name}`;
+        await newFolder(request, '/', name);
+        await newFolder(request, base, 'from');
+        await newFolder(request, base, 'to');
+        await upload(request, `${base}/from`, 'note.txt', 'new');
+        await upload(request, `${base}/to`, 'note.txt', 'old');
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('applies the collision policy when copying', async ({ request }) => {
+        const sources = [`${base}/from/note.txt`];
+        const destination = `${base}/to`;
+
+        const skipped = await runOperation(request, 'copy', {
+            sources,
+            destination,
+            onCollision: 'skip',
+        });
+        expect(skipped[0].status).toBe('skipped');
+
+        const kept = await runOperation(request, 'copy', {
+            sources,
+            destination,
+            onCollision: 'keep-both',
+        });
+        expect(kept[0]).toMatchObject({
+            status: 'copied',
+            destination: `${base}/to/note_(1).txt`,
+        });
+
+        const overwritten = await runOperation(request, 'copy', {
+            sources,
+            destination,
+            onCollision: 'overwrite',
+        });
+        expect(overwritten[0].status).toBe('copied');
+        const content = await request.get(`/api/v1/files${base}/to/note.txt`);
+        expect(await content.text()).toBe('new');
+
+        // The source is left in place
+        const source = await request.get(`/api/v1/files${base}/from/note.txt`);
+        expect(source.status()).toBe(200);
+    });
+
+    test('reports every item of a batch move', async ({ request }) => {
+        await upload(request, `${base}/from`, 'other.txt', 'other');
+
+        const results = await runOperation(request, 'move', {
+            sources: [`${base}/from/other.txt`, `${base}/from/missing.txt`, `${base}/from`],
+            destination: `${base}/from`,
+        });
+        expect(results.map((result) => result.status)).toEqual(['skipped', 'failed', 'failed']);
+
+        const moved = await runOperation(request, 'move', {
+            sources: [`${base}/from/other.txt`, `${base}/from/missing.txt`],
+            destination: `${base}/to`,
+        });
+        expect(moved[0]).toMatchObject({ status: 'moved', destination: `${base}/to/other.txt` });
+        expect(moved[1].status).toBe('failed');
+        expect(moved[1].error).toBeTruthy();
+
+        const gone = await request.get(`/api/v1/files${base}/from/other.txt`);
+        expect(gone.status()).toBe(404);
+    });
+
+    test('duplicates a folder next to itself', async ({ request }) => {
+        const results = await runOperation(request, 'duplicate', { sources: [`${base}/from`] });
+        expect(results[0]).toMatchObject({ status: 'copied', destination: `${base}/from_(1)` });
+
+        const copy = await request.get(`/api/v1/files${base}/from_(1)/note.txt`);
+        expect(await copy.text()).toBe('new');
+    });
+
+    test('rejects an unknown collision policy', async ({ request }) => {
+        const response = await request.post('/api/v1/copy/files', {
+            data: {
+                sources: [`${base}/from/note.txt`],
+                destination: `${base}/to`,
+                onCollision: 'merge',
+            },
+        });
+        expect(response.status()).toBe(400);
+    });
+
+    test('duplicates a file from the context menu', async ({ page }) => {
+        await page.goto(`/files${base}/from`);
+        const row = page.locator('tr.file-table-row[data-name="note.txt"]');
+        await row.locator('.context-menu-trigger').click();
+        await row.locator('.context-menu-item:has-text("Duplicate")').dispatchEvent('click');
+
+        await expect(page.locator('tr.file-table-row[data-name="note_(1).txt"]')).toBeVisible({
+            timeout: 10000,
+        });
+    });
+});
@@ -0,0 +1,73 @@
+import { test, expect } from '@playwright/test';
+import { upload } from './helpers';
+
+const json = { Accept: 'application/json' };
+
+// A 1x1 PNG, for checking content is sniffed without an extension
+const png = Buffer.from(
+    'iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mP8z8BQDwAEhQGAhKmMIQAAAABJRU5ErkJggg==',
+    'base64'
+);
+
+test.describe('File types', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `file-types-${Date.now()}`;
+        base = `/${name}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: name } });
+        await upload(request, base, 'notes', Buffer.from('just some notes\n'));
+        await upload(request, base, 'blob', Buffer.from([0, 1, 2, 3, 255, 254, 0, 7]));
+        await upload(request, base, 'picture', png);
+        await upload(request, base, 'table.csv', Buffer.from('name,age\nann,3\n'));
+        await upload(request, base, 'song.mp3', Buffer.from('ID3\x03\x00'));
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('detects types from content when there is no extension', async ({ request }) => {
+        const listing = await (await request.get(`/api/v1/files${base}`, { headers: json })).json();
+        const types = Object.fromEntries(
+            listing.entries.map((entry: { name: string; fileType: string }) => [
+                entry.name,
+                entry.fileType,
+            ])
+        );
+        expect(types).toMatchObject({
+            notes: 'generic',
+            blob: 'binary',
+            picture: 'image',
+            'table.csv': 'spreadsheet',
+            'song.mp3': 'audio',
+        });
+    });
+
+    test('opens each type in its viewer', async ({ request }) => {
+        const viewer = async (name: string) =>
+            (await request.get(`/components/files/viewer/files${base}/${name}`)).text();
+        expect(await viewer('notes')).toContain('ab-text-editor');
+        expect(await viewer('blob')).toContain('cannot yet be viewed');
+        expect(await viewer('picture')).toContain('data:image/png;base64');
+        expect(await viewer('table.csv')).toContain('<td>ann</td>');
+        expect(await viewer('song.mp3')).toContain('<audio');
+    });
+
+    test('only makes thumbnails of images', async ({ request }) => {
+        const thumbnail = await request.get(`/api/v1/thumbnails${base}/picture`);
+        expect(thumbnail.status()).toBe(200);
+        expect(thumbnail.headers()['content-type']).toBe('image/png');
+
+        const notes = await request.get(`/api/v1/thumbnails${base}/notes`, { headers: json });
+        expect(notes.status()).toBe(415);
+        expect((await notes.json()).error.code).toBe('unsupported_media_type');
+    });
+
+    test('shows icons for the new types', async ({ page }) => {
+        await page.goto(`/files${base}`);
+        await expect(page.locator('.icon--audio')).toHaveCount(1);
+        await expect(page.locator('.icon--spreadsheet')).toHaveCount(1);
+        await expect(page.locator('.icon--image')).toHaveCount(1);
+    });
+});
@@ -0,0 +1,80 @@
+import { test, expect } from '@playwright/test';
+
+test.describe('Folder Sizes', () => {
+    test('shows folder sizes once calculated and keeps them current', async ({ page, request }) => {
+        const folderName = `size-folder-${Date.now()}`;
+
+        const created = await request.post('/api/v1/folder/files/', { form: { folderName } });
+        expect(created.ok()).toBeTruthy();
+
+        const uploaded = await request.post(`/api/v1/files/${folderName}`, {
+            multipart: {
+                files: {
+                    name: 'data.bin',
+                    mimeType: 'application/octet-stream',
+                    buffer: Buffer.alloc(2048, 1),
+                },
+            },
+        });
+        expect(uploaded.ok()).toBeTruthy();
+
+        // The size shows up without reloading, replacing the placeholder if needed
+        await page.goto('/files');
+        const folderRow = page.locator(`tr.file-table-row[data-name="${folderName}/"]`);
+        await expect(folderRow.locator('.file-table-size')).toHaveText('2.0 KB', {
+            timeout: 10000,
+        });
+
+        // Uploading more into the folder updates its size
+        const more = await request.post(`/api/v1/files/${folderName}`, {
+            multipart: {
+                files: {
+                    name: 'more.bin',
+                    mimeType: 'application/octet-stream',
+                    buffer: Buffer.alloc(2048, 2),
+                },
+            },
+        });
+        expect(more.ok()).toBeTruthy();
+        await expect
+            .poll(
+                async () => {
+                    const response = await request.get(`/components/files/size/${folderName}`);
+                    return (await response.text()).trim();
+                },
+                { timeout: 10000 }
+            )
+            .toBe('4.0 KB');
+
+        // Clean up
+        const deleted = await request.delete(`/api/v1/files?rootDir=/&filePaths=${folderName}`);
+        expect(deleted.ok()).toBeTruthy();
+    });
+
+    test('keeps the sizes of folders named the same but for case apart', async ({ request }) => {
+        const folderName = `size-case-${Date.now()}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName } });
+        const form = new FormData();
+        form.append('files', new Blob([Buffer.alloc(1000, 1)]), 'x.bin');
+        form.append('paths', 'A/x.bin');
+        form.append('files', new Blob([Buffer.alloc(3000, 1)]), 'x.bin');
+        form.append('paths', 'a/x.bin');
+        await request.post(`/api/v1/files/${folderName}`, { multipart: form });
+
+        const sizes = async () => {
+            const response = await request.get(`/api/v1/files/${folderName}`, {
+                headers: { Accept: 'application/json' },
+            });
+            const entries: { name: string; sizeBytes: number }[] = (await response.json()).entries;
+            return Object.fromEntries(entries.map((entry) => [entry.name, entry.sizeBytes]));
+        };
+        await expect.poll(sizes).toEqual({ A: 1000, a: 3000 });
+
+        await request.put(`/api/v1/files/${folderName}/A`, {
+            form: { newFilePath: `/${folderName}/B` },
+        });
+        await expect.poll(sizes).toEqual({ B: 1000, a: 3000 });
+
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${folderName}`);
+    });
+});
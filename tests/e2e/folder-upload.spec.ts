@@ -0,0 +1,113 @@
+import { test, expect } from '@playwright/test';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+
+const json = { Accept: 'application/json' };
+
+function file(name: string, text: string) {
+    return { name, mimeType: 'text/plain', buffer: Buffer.from(text) };
+}
+
+test.describe('Folder upload', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `folder-upload-${Date.now()}`;
+        base = `/${name}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: name } });
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('keeps the folder structure and sums up what was made', async ({ request }) => {
+        const form = new FormData();
+        form.append('files', new Blob(['a']), 'a.txt');
+        form.append('paths', 'album/a.txt');
+        form.append('files', new Blob(['bb']), 'b.txt');
+        form.append('paths', 'album/sub/b.txt');
+        const uploaded = await request.post(`/api/v1/files${base}`, {
+            headers: json,
+            multipart: form,
+        });
+        expect(uploaded.status()).toBe(201);
+        const body = await uploaded.json();
+        expect(body.files.map((f: { path: string }) => f.path)).toEqual([
+            `${base}/album/a.txt`,
+            `${base}/album/sub/b.txt`,
+        ]);
+        expect(body.folders.map((f: { path: string }) => f.path)).toEqual([
+            `${base}/album`,
+            `${base}/album/sub`,
+        ]);
+
+        // Uploading into existing folders merges, and renames colliding files
+        const again = new FormData();
+        again.append('files', new Blob(['a']), 'a.txt');
+        again.append('paths', 'album/a.txt');
+        const merged = await (
+            await request.post(`/api/v1/files${base}`, { headers: json, multipart: again })
+        ).json();
+        expect(merged.folders).toEqual([]);
+        expect(merged.files[0].path).toBe(`${base}/album/a_(1).txt`);
+    });
+
+    test('refuses paths outside the folder', async ({ request }) => {
+        const form = new FormData();
+        form.append('files', new Blob(['x']), 'x.txt');
+        form.append('paths', '../x.txt');
+        const escaped = await request.post(`/api/v1/files${base}`, {
+            headers: json,
+            multipart: form,
+        });
+        expect(escaped.status()).toBe(400);
+        expect((await request.head(`/api/v1/files${base}-x.txt`)).status()).toBe(404);
+    });
+
+    test('refuses paths that are the folder itself', async ({ request }) => {
+        for (const folderPath of ['.', 'sub/..']) {
+            const form = new FormData();
+            form.append('files', new Blob(['x']), 'x.txt');
+            form.append('paths', folderPath);
+            const response = await request.post(`/api/v1/files${base}`, {
+                headers: json,
+                multipart: form,
+            });
+            expect(response.status()).toBe(400);
+        }
+        expect((await request.head(`/api/v1/files${base}/x.txt`)).status()).toBe(404);
+    });
+
+    test('still uploads plain files into the folder', async ({ request }) => {
+        const uploaded = await request.post(`/api/v1/files${base}`, {
+            headers: json,
+            multipart: { files: file('plain.txt', 'hello') },
+        });
+        expect(uploaded.status()).toBe(201);
+        expect((await uploaded.json()).files[0].path).toBe(`${base}/plain.txt`);
+    });
+
+    test('uploads a folder picked from the context menu', async ({ page, request }) => {
+        const dir = fs.mkdtempSync(path.join(os.tmpdir(), 'folder-upload-'));
+        const picked = path.join(dir, 'trip');
+        fs.mkdirSync(path.join(picked, 'day1'), { recursive: true });
+        fs.writeFileSync(path.join(picked, 'notes.txt'), 'notes');
+        fs.writeFileSync(path.join(picked, 'day1', 'log.txt'), 'log');
+        try {
+            await page.goto(`/files${base}`);
+            await page.locator('#file-explorer-selectable').click({ button: 'right' });
+            const chooser = page.waitForEvent('filechooser');
+            await page.locator('.context-menu-item:has-text("Upload Folder")').click();
+            await (await chooser).setFiles(picked);
+
+            const log = `/api/v1/files${base}/trip/day1/log.txt`;
+            await expect.poll(async () => (await request.head(log)).status()).toBe(200);
+            const notes = await request.get(`/api/v1/files${base}/trip/notes.txt`);
+            expect(await notes.text()).toBe('notes');
+        } finally {
+            fs.rmSync(dir, { recursive: true, force: true });
+        }
+    });
+});
@@ -0,0 +1,30 @@
+import { expect, APIRequestContext } from '@playwright/test';
+
+// postFile uploads a single file into dir, returning the response. Text is
+// sent as text/plain and anything else as application/octet-stream.
+export function postFile(
+    request: APIRequestContext,
+    dir: string,
+    name: string,
+    content: string | Buffer
+) {
+    const file =
+        typeof content === 'string'
+            ? { name, mimeType: 'text/plain', buffer: Buffer.from(content) }
+            : { name, mimeType: 'application/octet-stream', buffer: content };
+    return request.post(`/api/v1/files${dir}`, {
+        headers: { Accept: 'application/json' },
+        multipart: { files: file },
+    });
+}
+
+// upload uploads a single file into dir, failing the test if it's refused.
+export async function upload(
+    request: APIRequestContext,
+    dir: string,
+    name: string,
+    content: string | Buffer
+) {
+    const response = await postFile(request, dir, name, content);
+    expect(response.ok()).toBeTruthy();
+}
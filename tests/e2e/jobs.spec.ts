@@ -0,0 +1,94 @@
+import { test, expect } from '@playwright/test';
+
+test.describe('Jobs', () => {
+    let folderName: string;
+
+    test.beforeEach(async ({ request }) => {
+        folderName = `jobs-${Date.now()}`;
+        const created = await request.post('/api/v1/folder/files/', { form: { folderName } });
+        expect(created.ok()).toBeTruthy();
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${folderName}`);
+    });
+
+    test('records file operations as jobs', async ({ request }) => {
+        const response = await request.post('/api/v1/duplicate/files', {
+            data: { sources: [`/${folderName}`] },
+        });
+        expect(response.status()).toBe(200);
+        const body = await response.json();
+        expect(body.job).toMatchObject({
+            kind: 'duplicate',
+            status: 'succeeded',
+            itemsDone: 1,
+            itemsTotal: 1,
+        });
+        expect(body.results[0].status).toBe('copied');
+
+        const job = await request.get(`/api/v1/jobs/${body.job.id}`);
+        expect(job.status()).toBe(200);
+        expect((await job.json()).result[0].destination).toBe(`/${folderName}_(1)`);
+
+        const list = await request.get('/api/v1/jobs?limit=5');
+        const ids = (await list.json()).jobs.map((listed: { id: number }) => listed.id);
+        expect(ids).toContain(body.job.id);
+
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${folderName}_(1)`);
+    });
+
+    test('fails a job when any item fails', async ({ request }) => {
+        const response = await request.post('/api/v1/copy/files', {
+            data: { sources: [`/${folderName}/missing.txt`], destination: `/${folderName}` },
+        });
+        const body = await response.json();
+        expect(body.job.status).toBe('failed');
+        expect(body.job.error).toContain('1 of 1');
+        expect(body.results[0].status).toBe('failed');
+    });
+
+    test('refuses to cancel finished or unknown jobs', async ({ request }) => {
+        const response = await request.post('/api/v1/duplicate/files', {
+            data: { sources: [`/${folderName}`] },
+        });
+        const { job } = await response.json();
+
+        const finished = await request.post(`/api/v1/jobs/${job.id}/cancel`);
+        expect(finished.status()).toBe(409);
+
+        const unknown = await request.post('/api/v1/jobs/999999999/cancel');
+        expect(unknown.status()).toBe(404);
+
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${folderName}_(1)`);
+    });
+
+    test('streams job progress', async ({ page, request }) => {
+        await page.goto('/files');
+        const received = page.evaluate(
+            (name) =>
+                new Promise<{ status: string; description: string }>((resolve) => {
+                    const source = new EventSource('/api/v1/events/jobs');
+                    source.addEventListener('job', (event) => {
+                        const job = JSON.parse((event as MessageEvent).data);
+                        if (job.description.includes(name) && job.finishedAt) {
+                            source.close();
+                            resolve(job);
+                        }
+                    });
+                    source.addEventListener('open', () => {
+                        document.body.dataset.jobsOpen = 'true';
+                    });
+                }),
+            folderName
+        );
+        await expect(page.locator('body[data-jobs-open="true"]')).toHaveCount(1);
+
+        await request.post('/api/v1/duplicate/files', { data: { sources: [`/${folderName}`] } });
+
+        const job = await received;
+        expect(job.status).toBe('succeeded');
+
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${folderName}_(1)`);
+    });
+});
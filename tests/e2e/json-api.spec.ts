@@ -0,0 +1,76 @@
+import { test, expect } from '@playwright/test';
+
+const json = { Accept: 'application/json' };
+
+test.describe('JSON API', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `json-${Date.now()}`;
+        base = `/${name}`;
+        const created = await request.post('/api/v1/folder/files/', {
+            form: { folderName: name },
+            headers: json,
+        });
+        expect(created.status()).toBe(201);
+        expect(await created.json()).toMatchObject({ path: base, isDir: true, fileType: 'folder' });
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('describes files and folders', async ({ request }) => {
+        const uploaded = await request.post(`/api/v1/files${base}`, {
+            multipart: {
+                files: { name: 'note.txt', mimeType: 'text/plain', buffer: Buffer.from('hello') },
+            },
+            headers: json,
+        });
+        expect(uploaded.status()).toBe(201);
+        expect((await uploaded.json()).files[0]).toMatchObject({
+            path: `${base}/note.txt`,
+            sizeBytes: 5,
+        });
+
+        const folder = await (await request.get(`/api/v1/files${base}`, { headers: json })).json();
+        expect(folder.entries).toEqual([
+            expect.objectContaining({ name: 'note.txt', isDir: false, fileType: 'generic' }),
+        ]);
+        // Without asking for JSON, files are still downloaded
+        expect(await (await request.get(`/api/v1/files${base}/note.txt`)).text()).toBe('hello');
+    });
+
+    test('answers errors with an envelope', async ({ request }) => {
+        const missing = await request.get(`/api/v1/files${base}/missing.txt`, { headers: json });
+        expect(missing.status()).toBe(404);
+        expect((await missing.json()).error).toMatchObject({ code: 'not_found' });
+
+        const unknown = await request.get('/api/v1/nothing-here', { headers: json });
+        expect(unknown.status()).toBe(404);
+        expect((await unknown.json()).error.code).toBe('not_found');
+
+        const html = await request.get(`/api/v1/files${base}/missing.txt`);
+        expect(html.headers()['content-type'] ?? '').not.toContain('application/json');
+    });
+
+    test('creates and lists calendar events', async ({ request }) => {
+        const created = await request.post('/api/v1/calendar/events', {
+            form: { year: '2031', month: '3', day: '14', title: 'Pi day', startTime: '09:00' },
+            headers: json,
+        });
+        expect(created.status()).toBe(201);
+        const event = await created.json();
+        expect(event).toMatchObject({ title: 'Pi day', startTime: '2031-03-14T09:00:00Z' });
+
+        const month = await (
+            await request.get('/api/v1/calendar/month?year=2031&month=3', { headers: json })
+        ).json();
+        expect(month.events.map((e: { id: number }) => e.id)).toContain(event.id);
+
+        const deleted = await request.delete(`/api/v1/calendar/events/${event.id}`, {
+            headers: json,
+        });
+        expect(deleted.status()).toBe(204);
+    });
+});
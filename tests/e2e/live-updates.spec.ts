@@ -0,0 +1,60 @@
+import { test, expect } from '@playwright/test';
+
+test.describe('Live Updates', () => {
+    test('streams change events for mutations made through the API', async ({ page, request }) => {
+        const folderName = `live-event-${Date.now()}`;
+        await page.goto('/files');
+
+        const received = page.evaluate(
+            (name) =>
+                new Promise<{ dir: string; paths: string[] }>((resolve) => {
+                    const source = new EventSource('/api/v1/events/files');
+                    source.addEventListener('fs-change', (event) => {
+                        const change = JSON.parse((event as MessageEvent).data);
+                        if (change.paths.includes(`/${name}`)) {
+                            source.close();
+                            resolve(change);
+                        }
+                    });
+                    source.addEventListener('open', () => {
+                        document.body.dataset.eventsOpen = 'true';
+                    });
+                }),
+            folderName
+        );
+        await expect(page.locator('body[data-events-open="true"]')).toHaveCount(1);
+
+        const created = await request.post('/api/v1/folder/files/', { form: { folderName } });
+        expect(created.ok()).toBeTruthy();
+
+        const change = await received;
+        expect(change.dir).toBe('/');
+        expect(change.paths).toContain(`/${folderName}`);
+
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${folderName}`);
+    });
+
+    test('re-renders the explorer when another client changes the folder', async ({
+        page,
+        request,
+    }) => {
+        const folderName = `live-folder-${Date.now()}`;
+        await page.goto('/files');
+        await expect(page.locator('#file-explorer')).toBeVisible();
+        await page.waitForFunction(() => {
+            const source = (window as unknown as { fileEventSource?: EventSource }).fileEventSource;
+            return source?.readyState === EventSource.OPEN;
+        });
+
+        // Made by "someone else", without any interaction on this page
+        const created = await request.post('/api/v1/folder/files/', { form: { folderName } });
+        expect(created.ok()).toBeTruthy();
+
+        const folderRow = page.locator(`tr.file-table-row[data-name="${folderName}/"]`);
+        await expect(folderRow).toBeVisible({ timeout: 10000 });
+
+        const deleted = await request.delete(`/api/v1/files?rootDir=/&filePaths=${folderName}`);
+        expect(deleted.ok()).toBeTruthy();
+        await expect(folderRow).toHaveCount(0, { timeout: 10000 });
+    });
+});
@@ -0,0 +1,133 @@
+import { test, expect, APIRequestContext } from '@playwright/test';
+import fs from 'fs';
+
+// exif-photo.jpg was taken on 2023-07-14 at 21:30:05 in UTC+9, near Tokyo
+async function photosIn(request: APIRequestContext, dir: string, query = '') {
+    const response = await request.get(`/api/v1/photos?rootDir=${dir}${query}`);
+    expect(response.ok()).toBeTruthy();
+    return (await response.json()).photos;
+}
+
+test.describe('Photo catalogue', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `photos-${Date.now()}`;
+        base = `/${name}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: name } });
+        await request.post(`/api/v1/files${base}`, {
+            multipart: {
+                files: {
+                    name: 'tokyo.jpg',
+                    mimeType: 'image/jpeg',
+                    buffer: fs.readFileSync('./tests/e2e/data/exif-photo.jpg'),
+                },
+            },
+        });
+        await expect.poll(async () => (await photosIn(request, base)).length).toBe(1);
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('reads EXIF metadata', async ({ request }) => {
+        const [photo] = await photosIn(request, base);
+        expect(photo.path).toBe(`${base.slice(1)}/tokyo.jpg`);
+        expect(photo.takenAt).toBe('2023-07-14T21:30:05+09:00');
+        expect(photo.takenAtSource).toBe('exif');
+        expect(photo.cameraMake).toBe('Google');
+        expect(photo.cameraModel).toBe('Pixel 7');
+        expect(photo.exposureTime).toBe('1/125');
+        expect(photo.fNumber).toBe(1.8);
+        expect(photo.iso).toBe(400);
+        // The photo is 40x30, turned on its side
+        expect(photo.width).toBe(30);
+        expect(photo.height).toBe(40);
+        expect(photo.latitude).toBeCloseTo(35.658, 3);
+        expect(photo.longitude).toBeCloseTo(139.7, 3);
+    });
+
+    test('filters by the date taken', async ({ request }) => {
+        expect(await photosIn(request, base, '&from=2023-07-14&to=2023-07-14')).toHaveLength(1);
+        expect(await photosIn(request, base, '&to=2023-06')).toHaveLength(0);
+        expect(await photosIn(request, base, '&from=2023-07-15')).toHaveLength(0);
+
+        const invalid = await request.get('/api/v1/photos?from=2023-13');
+        expect(invalid.status()).toBe(400);
+    });
+
+    test('pages through photos with a cursor', async ({ request }) => {
+        const form = new FormData();
+        for (const name of ['a.jpg', 'b.jpg']) {
+            const photo = fs.readFileSync('./tests/e2e/data/exif-photo.jpg');
+            form.append('files', new Blob([photo], { type: 'image/jpeg' }), name);
+        }
+        await request.post(`/api/v1/files${base}`, { multipart: form });
+        await expect.poll(async () => (await photosIn(request, base)).length).toBe(3);
+
+        const seen: string[] = [];
+        let cursor = '';
+        do {
+            const response = await request.get(
+                `/api/v1/photos?rootDir=${base}&limit=2&cursor=${cursor}`
+            );
+            const body = await response.json();
+            seen.push(...body.photos.map((p: { name: string }) => p.name));
+            cursor = body.nextCursor ?? '';
+        } while (cursor);
+        expect(seen.sort()).toEqual(['a.jpg', 'b.jpg', 'tokyo.jpg']);
+
+        const invalid = await request.get('/api/v1/photos?cursor=nope');
+        expect(invalid.status()).toBe(400);
+    });
+
+    test('groups the timeline by year, month and day', async ({ request }) => {
+        for (const [group, period] of [
+            ['year', '2023'],
+            ['month', '2023-07'],
+            ['day', '2023-07-14'],
+        ]) {
+            const response = await request.get(
+                `/api/v1/photos/timeline?rootDir=${base}&group=${group}`
+            );
+            const body = await response.json();
+            expect(body.group).toBe(group);
+            expect(body.periods).toEqual([
+                { period, count: 1, coverPath: `${base.slice(1)}/tokyo.jpg` },
+            ]);
+        }
+
+        const invalid = await request.get('/api/v1/photos/timeline?group=week');
+        expect(invalid.status()).toBe(400);
+    });
+
+    test('follows photos that are moved and deleted', async ({ request }) => {
+        await request.put(`/api/v1/files${base}/tokyo.jpg`, {
+            form: { newFilePath: `${base}/trip/tokyo.jpg` },
+        });
+        await expect
+            .poll(async () => (await photosIn(request, base)).map((p: { path: string }) => p.path))
+            .toEqual([`${base.slice(1)}/trip/tokyo.jpg`]);
+
+        await request.delete(`/api/v1/files?rootDir=${base}&filePaths=trip`);
+        await expect.poll(async () => (await photosIn(request, base)).length).toBe(0);
+    });
+
+    test('lists folders named the same but for case apart', async ({ request }) => {
+        const form = new FormData();
+        const photo = fs.readFileSync('./tests/e2e/data/exif-photo.jpg');
+        for (const folderName of ['Trip', 'trip']) {
+            form.append('files', new Blob([photo], { type: 'image/jpeg' }), 'x.jpg');
+            form.append('paths', `${folderName}/x.jpg`);
+        }
+        await request.post(`/api/v1/files${base}`, { multipart: form });
+        await expect.poll(async () => (await photosIn(request, base)).length).toBe(3);
+
+        const upper = await photosIn(request, `${base}/Trip`);
+        expect(upper.map((p: { path: string }) => p.path)).toEqual([`${base.slice(1)}/Trip/x.jpg`]);
+
+        await request.delete(`/api/v1/files?rootDir=${base}&filePaths=Trip`);
+        await expect.poll(async () => (await photosIn(request, `${base}/trip`)).length).toBe(1);
+    });
+});
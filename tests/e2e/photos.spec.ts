@@ -1,4 +1,5 @@
 import { test, expect } from '@playwright/test';
+import fs from 'fs';
 
 test.describe('Photos Page', () => {
     test('loads photos page successfully', async ({ page }) => {
@@ -234,3 +235,50 @@ test.describe('Photos Page - Photo Upload', () => {
         expect(initialCount).toMatch(/\d+\s+photos?/i);
     });
 });
+
+test.describe('Photos Page - Pagination', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `photo-pages-${Date.now()}`;
+        base = `/${name}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: name } });
+        const png = fs.readFileSync('./tests/e2e/data/test-image.png');
+        const form = new FormData();
+        for (let i = 0; i < 60; i++) {
+            form.append('files', new Blob([png], { type: 'image/png' }), `photo-${i}.png`);
+        }
+        const response = await request.post(`/api/v1/files${base}`, { multipart: form });
+        expect(response.ok()).toBeTruthy();
+        await expect
+            .poll(async () => {
+                const body = await (await request.get(`/api/v1/photos?rootDir=${base}`)).json();
+                return body.photos.length;
+            })
+            .toBe(60);
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('shows only the photos of the folder in the URL', async ({ page }) => {
+        await page.goto(`/photos${base}`);
+
+        await expect(page.locator('.photos-count')).toHaveText('60 photos');
+        await expect(page.locator('.photo-grid-date').first()).toBeVisible();
+        const images = page.locator('img.photo-grid-image');
+        expect(await images.count()).toBe(50);
+
+        // The next page stays within the folder
+        await page.locator('.photo-grid-loader').scrollIntoViewIfNeeded();
+        await expect(images).toHaveCount(60);
+        await expect(page.locator('.photo-grid-loader')).toHaveCount(0);
+        const sources = await images.evaluateAll((all) =>
+            all.map((img) => img.getAttribute('src'))
+        );
+        for (const src of sources) {
+            expect(src).toContain(`/api/v1/thumbnails${base}/`);
+        }
+    });
+});
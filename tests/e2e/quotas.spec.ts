@@ -0,0 +1,98 @@
+import { test, expect } from '@playwright/test';
+import { postFile } from './helpers';
+
+const json = { Accept: 'application/json' };
+
+test.describe('Quotas', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `quotas-${Date.now()}`;
+        base = `/${name}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: name } });
+        const set = await request.put(`/api/v1/quotas${base}`, {
+            data: { maxBytes: 10, maxFiles: 2 },
+        });
+        expect(set.status()).toBe(200);
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('refuses uploads over the quota with the remaining budget', async ({ request }) => {
+        expect((await postFile(request, base, 'small.txt', 'hello')).status()).toBe(201);
+
+        const tooBig = await postFile(request, base, 'big.txt', 'hello world');
+        expect(tooBig.status()).toBe(507);
+        const body = await tooBig.json();
+        expect(body.error.code).toBe('insufficient_storage');
+        expect(body.error.details).toMatchObject({
+            folder: base,
+            limit: 'quotaBytes',
+            remainingBytes: 5,
+            requiredBytes: 11,
+        });
+        expect((await request.head(`/api/v1/files${base}/big.txt`)).status()).toBe(404);
+
+        expect((await postFile(request, base, 'second.txt', 'hi')).status()).toBe(201);
+        const tooMany = await postFile(request, base, 'third.txt', 'x');
+        expect(tooMany.status()).toBe(507);
+        expect((await tooMany.json()).error.details).toMatchObject({
+            limit: 'quotaFiles',
+            remainingFiles: 0,
+        });
+    });
+
+    test('checks copies and resumable uploads', async ({ request }) => {
+        const outside = `${base}-src.txt`;
+        await postFile(request, '/', outside.slice(1), 'hello world');
+        try {
+            const copied = await request.post('/api/v1/copy/files', {
+                headers: json,
+                data: { sources: [outside], destination: base },
+            });
+            expect(copied.status()).toBe(507);
+
+            const created = await request.post('/api/v1/uploads', {
+                headers: {
+                    'Tus-Resumable': '1.0.0',
+                    'Upload-Length': '100',
+                    'Upload-Metadata': [
+                        `filename ${Buffer.from('big.bin').toString('base64')}`,
+                        `rootDir ${Buffer.from(base).toString('base64')}`,
+                    ].join(','),
+                },
+            });
+            expect(created.status()).toBe(507);
+        } finally {
+            await request.delete(`/api/v1/files?rootDir=/&filePaths=${outside.slice(1)}`);
+        }
+    });
+
+    test('follows the folder and shows usage on the home page', async ({ request, page }) => {
+        await postFile(request, base, 'small.txt', 'hello');
+        const usage = await (await request.get(`/api/v1/quotas${base}`)).json();
+        expect(usage).toMatchObject({ usedBytes: 5, usedFiles: 1 });
+
+        await page.goto('/');
+        const quota = page.locator(`.storage-bar-quota[data-folder="${base}"]`);
+        await expect(quota).toContainText('5 B of 10 B');
+        await expect(quota).toContainText('1 of 2 files');
+
+        const renamed = `${base}-renamed`;
+        await request.put(`/api/v1/files${base}`, { form: { newFilePath: renamed.slice(1) } });
+        const list = await (await request.get('/api/v1/quotas')).json();
+        const folders = list.quotas.map((quota: { folder: string }) => quota.folder);
+        expect(folders).toContain(renamed);
+        expect(folders).not.toContain(base);
+        base = renamed;
+    });
+
+    test('only allows top-level folders', async ({ request }) => {
+        const nested = await request.put(`/api/v1/quotas${base}/sub`, { data: { maxBytes: 1 } });
+        expect(nested.status()).toBe(400);
+        expect((await request.delete(`/api/v1/quotas${base}`)).status()).toBe(204);
+        expect((await request.delete(`/api/v1/quotas${base}`)).status()).toBe(404);
+    });
+});
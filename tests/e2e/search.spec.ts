@@ -0,0 +1,114 @@
+import { test, expect, APIRequestContext } from '@playwright/test';
+import { upload } from './helpers';
+
+async function searchUntilFound(request: APIRequestContext, query: string, path: string) {
+    // The index is updated in the background, so poll until the file shows up
+    await expect
+        .poll(
+            async () => {
+                const response = await request.get(`/api/v1/search?q=${encodeURIComponent(query)}`);
+                const body = await response.json();
+                return body.results.map((result: { path: string }) => result.path);
+            },
+            { timeout: 10000 }
+        )
+        .toContain(path);
+}
+
+test.describe('Search', () => {
+    test('rejects an empty query', async ({ request }) => {
+        const response = await request.get('/api/v1/search?q=');
+        expect(response.status()).toBe(400);
+    });
+
+    test('rejects an unknown type filter', async ({ request }) => {
+        const response = await request.get('/api/v1/search?q=anything&type=nonsense');
+        expect(response.status()).toBe(400);
+    });
+
+    test('finds files by content and follows moves and deletes', async ({ request, page }) => {
+        const word = `zebracorn${Date.now()}`;
+        const fileName = `search-${Date.now()}.txt`;
+
+        await page.goto('/files');
+        await page.locator('input[type="file"]').setInputFiles({
+            name: fileName,
+            mimeType: 'text/plain',
+            buffer: Buffer.from(`A note mentioning the ${word} and the quick brown fox.`),
+        });
+        const fileRow = page.locator(`tr.file-table-row[data-name="${fileName}"]`);
+        await expect(fileRow).toBeVisible({ timeout: 10000 });
+
+        // Prefix queries match the start of words, with matches highlighted
+        await searchUntilFound(request, word.slice(0, 12), `/${fileName}`);
+        const prefix = await (await request.get(`/api/v1/search?q=${word.slice(0, 12)}`)).json();
+        expect(prefix.results[0].snippet).toContain(`<mark>${word}</mark>`);
+
+        // Phrase queries only match the exact phrase
+        await searchUntilFound(request, '"quick brown fox"', `/${fileName}`);
+        const wrongPhrase = await (
+            await request.get(`/api/v1/search?q=${encodeURIComponent(`"fox ${word}"`)}`)
+        ).json();
+        expect(wrongPhrase.results).toHaveLength(0);
+
+        // Type filters exclude other types
+        const images = await (await request.get(`/api/v1/search?q=${word}&type=image`)).json();
+        expect(images.results).toHaveLength(0);
+        const generic = await (await request.get(`/api/v1/search?q=${word}&type=generic`)).json();
+        expect(generic.results).toHaveLength(1);
+
+        // The search box in the file explorer shows the match
+        await page.locator('#file-search-input').fill(word);
+        const result = page.locator(`.file-search-result[data-path="/${fileName}"]`);
+        await expect(result).toBeVisible({ timeout: 10000 });
+        await expect(result.locator('mark')).toHaveText(word);
+
+        // Moving the file updates its indexed path
+        const movedName = `moved-${fileName}`;
+        const moved = await request.put(`/api/v1/files/${fileName}`, {
+            form: { newFilePath: `/${movedName}` },
+        });
+        expect(moved.ok()).toBeTruthy();
+        await searchUntilFound(request, word, `/${movedName}`);
+
+        // Deleting the file removes it from the index
+        const deleted = await request.delete(`/api/v1/files?rootDir=/&filePaths=${movedName}`);
+        expect(deleted.ok()).toBeTruthy();
+        await expect
+            .poll(
+                async () => {
+                    const body = await (await request.get(`/api/v1/search?q=${word}`)).json();
+                    return body.results.length;
+                },
+                { timeout: 10000 }
+            )
+            .toBe(0);
+    });
+
+    test('moves a folder without touching one named the same but for case', async ({
+        request,
+    }) => {
+        const word = `caseword${Date.now()}`;
+        const upper = `search-case-${Date.now()}-A`;
+        const lower = upper.slice(0, -1) + 'a';
+        for (const folderName of [upper, lower]) {
+            await request.post('/api/v1/folder/files/', { form: { folderName } });
+            await upload(request, `/${folderName}`, 'x.txt', `${word} in ${folderName}`);
+            await searchUntilFound(request, word, `/${folderName}/x.txt`);
+        }
+
+        const movedName = upper.slice(0, -1) + 'B';
+        const moved = await request.put(`/api/v1/files/${upper}`, {
+            form: { newFilePath: `/${movedName}` },
+        });
+        expect(moved.ok()).toBeTruthy();
+        await searchUntilFound(request, word, `/${movedName}/x.txt`);
+        const body = await (await request.get(`/api/v1/search?q=${word}`)).json();
+        expect(body.results.map((result: { path: string }) => result.path).sort()).toEqual([
+            `/${movedName}/x.txt`,
+            `/${lower}/x.txt`,
+        ]);
+
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${movedName}&filePaths=${lower}`);
+    });
+});
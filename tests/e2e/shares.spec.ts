@@ -0,0 +1,126 @@
+import { test, expect, APIRequestContext } from '@playwright/test';
+import { upload } from './helpers';
+
+async function createShare(request: APIRequestContext, data: Record<string, unknown>) {
+    const response = await request.post('/api/v1/shares', { data });
+    expect(response.status()).toBe(201);
+    return response.json();
+}
+
+test.describe('Shares', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `shares-${Date.now()}`;
+        base = `/${name}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: name } });
+        await request.post(`/api/v1/folder/files${base}`, { form: { folderName: 'sub' } });
+        await upload(request, base, 'note.txt', 'hello');
+        await upload(request, `${base}/sub`, 'deep.txt', 'world');
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('lists and revokes shares', async ({ request }) => {
+        const share = await createShare(request, { path: `${base}/note.txt` });
+        expect(share).toMatchObject({ path: `${base}/note.txt`, isDir: false, hasPassword: false });
+        expect(share.url).toBe(`/s/${share.token}`);
+
+        const listed = await (await request.get('/api/v1/shares')).json();
+        expect(listed.shares.map((s: { token: string }) => s.token)).toContain(share.token);
+
+        expect((await request.get(`${share.url}?download=1`)).status()).toBe(200);
+        const revoked = await request.delete(`/api/v1/shares/${share.token}`);
+        expect(revoked.status()).toBe(204);
+        expect((await request.get(share.url)).status()).toBe(404);
+    });
+
+    test('rejects forged and unknown tokens', async ({ request }) => {
+        const share = await createShare(request, { path: base });
+        const [id] = share.token.split('.');
+        expect((await request.get(`/s/${id}.forged`)).status()).toBe(404);
+        expect((await request.get('/s/nothing')).status()).toBe(404);
+        expect((await request.get(`${share.url}/../../etc/passwd`)).status()).toBe(404);
+    });
+
+    test('stops working when expired or downloaded enough', async ({ request }) => {
+        const past = await request.post('/api/v1/shares', {
+            data: { path: base, expiresAt: '2020-01-01T00:00:00Z' },
+        });
+        expect(past.status()).toBe(400);
+
+        const share = await createShare(request, { path: `${base}/note.txt`, maxDownloads: 1 });
+        const first = await request.get(`${share.url}?download=1`);
+        expect(await first.text()).toBe('hello');
+        expect((await request.get(`${share.url}?download=1`)).status()).toBe(410);
+        expect((await request.get(share.url)).status()).toBe(410);
+    });
+
+    test('counts range requests without a grant as downloads', async ({ playwright }) => {
+        const share = await createShare(playwright.request, {
+            path: `${base}/note.txt`,
+            maxDownloads: 2,
+        });
+        // Every fresh context is a client without the grant of earlier ones
+        for (const status of [206, 206, 410]) {
+            const client = await playwright.request.newContext();
+            const response = await client.get(`${share.url}?download=1`, {
+                headers: { Range: 'bytes=1-' },
+            });
+            expect(response.status()).toBe(status);
+            await client.dispose();
+        }
+    });
+
+    test('counts a download fetched in ranges once', async ({ request }) => {
+        const share = await createShare(request, { path: `${base}/note.txt`, maxDownloads: 1 });
+        expect(await (await request.get(`${share.url}?download=1`)).text()).toBe('hello');
+        const rest = await request.get(`${share.url}?download=1`, {
+            headers: { Range: 'bytes=2-' },
+        });
+        expect(rest.status()).toBe(206);
+        expect(await rest.text()).toBe('llo');
+    });
+
+    test('asks for the password', async ({ page }) => {
+        const share = await createShare(page.request, { path: base, password: 'secret' });
+        const locked = await page.request.get(`${share.url}?download=1`);
+        expect(locked.status()).toBe(401);
+
+        await page.goto(share.url);
+        await page.getByLabel('Password').fill('wrong');
+        await page.getByRole('button', { name: 'Open' }).click();
+        await expect(page.locator('.share-error')).toBeVisible();
+
+        await page.getByLabel('Password').fill('secret');
+        await page.getByRole('button', { name: 'Open' }).click();
+        await expect(page.locator('.share-entry')).toHaveCount(2);
+    });
+
+    test('browses a shared folder read-only', async ({ page }) => {
+        const share = await createShare(page.request, { path: base });
+        await page.goto(share.url);
+        await expect(page.locator('.site-body')).toHaveCount(0);
+        await page.locator('.share-entry a', { hasText: 'sub/' }).click();
+        await expect(page).toHaveURL(new RegExp(`${share.url}/sub$`));
+
+        const download = await page.request.get(`${share.url}/sub/deep.txt?download=1`);
+        expect(await download.text()).toBe('world');
+    });
+
+    test('creates a link from the context menu', async ({ page }) => {
+        await page.goto(`/files${base}`);
+        const fileRow = page.locator('tr.file-table-row[data-name="note.txt"]');
+        await fileRow.locator('.context-menu-trigger').click();
+        await fileRow.locator('.context-menu-item:has-text("Share")').dispatchEvent('click');
+        await page.locator('#ab-share-expiry').selectOption('1');
+        await page.getByRole('button', { name: 'Create link' }).click();
+
+        const url = page.locator('#ab-share-url');
+        await expect(url).toHaveValue(/\/s\/[\w-]+\.[\w-]+$/);
+        const response = await page.request.get(`${await url.inputValue()}?download=1`);
+        expect(await response.text()).toBe('hello');
+    });
+});
@@ -0,0 +1,131 @@
+import { test, expect, APIRequestContext } from '@playwright/test';
+import { upload } from './helpers';
+
+async function createTag(request: APIRequestContext, name: string) {
+    const response = await request.post('/api/v1/tags', { data: { name } });
+    expect(response.status()).toBe(201);
+    return response.json();
+}
+
+async function taggedPaths(request: APIRequestContext, id: number) {
+    const body = await (await request.get(`/api/v1/tags/${id}/files`)).json();
+    return body.files.map((file: { path: string }) => file.path);
+}
+
+test.describe('Tags', () => {
+    let base: string;
+    let tag: { id: number; name: string; color: string };
+
+    test.beforeEach(async ({ request }) => {
+        const name = `tags-${Date.now()}`;
+        base = `/${name}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: name } });
+        await request.post(`/api/v1/folder/files${base}`, { form: { folderName: 'sub' } });
+        await upload(request, base, 'note.txt', 'hello');
+        tag = await createTag(request, name);
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/tags/${tag.id}`);
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('tags and untags files', async ({ request }) => {
+        expect(tag.color).toMatch(/^#[0-9a-f]{6}$/i);
+        const duplicate = await request.post('/api/v1/tags', {
+            data: { name: tag.name.toUpperCase() },
+        });
+        expect(duplicate.status()).toBe(409);
+
+        const tagged = await request.post(`/api/v1/tags/${tag.id}/files`, {
+            data: { paths: [`${base}/note.txt`, `${base}/sub`] },
+        });
+        expect(tagged.status()).toBe(204);
+        expect(await taggedPaths(request, tag.id)).toEqual([`${base}/sub`, `${base}/note.txt`]);
+
+        const file = await (
+            await request.get(`/api/v1/files${base}/note.txt`, {
+                headers: { Accept: 'application/json' },
+            })
+        ).json();
+        expect(file.tags.map((t: { id: number }) => t.id)).toEqual([tag.id]);
+
+        const untagged = await request.delete(
+            `/api/v1/tags/${tag.id}/files?filePaths=${base}/note.txt`
+        );
+        expect(untagged.status()).toBe(204);
+        expect(await taggedPaths(request, tag.id)).toEqual([`${base}/sub`]);
+    });
+
+    test('follows files when they move and forgets them when deleted', async ({ request }) => {
+        await upload(request, `${base}/sub`, 'deep.txt', 'world');
+        await request.post(`/api/v1/tags/${tag.id}/files`, {
+            data: { paths: [`${base}/note.txt`, `${base}/sub/deep.txt`] },
+        });
+
+        await request.put(`/api/v1/files${base}/sub`, {
+            form: { newFilePath: `${base}/moved` },
+        });
+        expect(await taggedPaths(request, tag.id)).toEqual([
+            `${base}/moved/deep.txt`,
+            `${base}/note.txt`,
+        ]);
+
+        await request.delete(`/api/v1/files?rootDir=${base}&filePaths=note.txt`);
+        await expect
+            .poll(() => taggedPaths(request, tag.id))
+            .toEqual([`${base}/moved/deep.txt`]);
+    });
+
+    test('leaves folders named the same but for case alone', async ({ request }) => {
+        for (const folderName of ['Docs', 'docs']) {
+            await request.post(`/api/v1/folder/files${base}`, { form: { folderName } });
+            await upload(request, `${base}/${folderName}`, 'a.txt', folderName);
+        }
+        await request.post(`/api/v1/tags/${tag.id}/files`, {
+            data: { paths: [`${base}/Docs/a.txt`, `${base}/docs/a.txt`] },
+        });
+
+        await request.put(`/api/v1/files${base}/Docs`, {
+            form: { newFilePath: `${base}/Moved` },
+        });
+        expect((await taggedPaths(request, tag.id)).sort()).toEqual([
+            `${base}/Moved/a.txt`,
+            `${base}/docs/a.txt`,
+        ]);
+
+        await request.delete(`/api/v1/files?rootDir=${base}&filePaths=Moved`);
+        await expect.poll(() => taggedPaths(request, tag.id)).toEqual([`${base}/docs/a.txt`]);
+    });
+
+    test('shows chips and browses by tag', async ({ page }) => {
+        await page.request.post(`/api/v1/tags/${tag.id}/files`, {
+            data: { paths: [`${base}/note.txt`] },
+        });
+        await page.goto(`/files${base}`);
+        const chip = page.locator('tr.file-table-row[data-name="note.txt"] .tag-chip');
+        await expect(chip).toHaveText(tag.name);
+
+        await chip.click();
+        await expect(page).toHaveURL(new RegExp(`/tags/${tag.id}$`));
+        await expect(page.locator('.file-explorer-title')).toHaveText('Tags');
+        await expect(
+            page.locator(`tr.file-table-row[data-name="${base.slice(1)}/note.txt"]`)
+        ).toBeVisible();
+    });
+
+    test('tags files from the context menu', async ({ page }) => {
+        await page.goto(`/files${base}`);
+        const fileRow = page.locator('tr.file-table-row[data-name="note.txt"]');
+        await fileRow.locator('.context-menu-trigger').click();
+        await fileRow.locator('.context-menu-item:has-text("Tags")').dispatchEvent('click');
+        await page.locator('.ab-tag-option', { hasText: tag.name }).locator('input').check();
+        await expect.poll(() => taggedPaths(page.request, tag.id)).toEqual([`${base}/note.txt`]);
+    });
+
+    test('lists tags and 404s unknown ones', async ({ page }) => {
+        await page.goto('/tags');
+        await expect(page.locator('.tag-list-item .tag-chip', { hasText: tag.name })).toBeVisible();
+        expect((await page.request.get('/tags/999999999')).status()).toBe(404);
+    });
+});
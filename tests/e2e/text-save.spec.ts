@@ -0,0 +1,78 @@
+import { test, expect } from '@playwright/test';
+
+const json = { Accept: 'application/json' };
+
+test.describe('Saving text files', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `text-save-${Date.now()}`;
+        base = `/${name}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: name } });
+        await request.post(`/api/v1/files${base}`, {
+            multipart: {
+                files: {
+                    name: 'notes.txt',
+                    mimeType: 'text/plain',
+                    buffer: Buffer.from('one\r\ntwo\r\n'),
+                },
+            },
+        });
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('replaces the file in place, keeping its line endings', async ({ request }) => {
+        const etag = (await request.head(`/api/v1/files${base}/notes.txt`)).headers()['etag'];
+        const saved = await request.put(`/api/v1/content/files${base}/notes.txt`, {
+            headers: { ...json, 'If-Match': etag },
+            data: 'one\ntwo\nthree\n',
+        });
+        expect(saved.status()).toBe(200);
+        expect(saved.headers()['etag']).not.toBe(etag);
+
+        const content = await request.get(`/api/v1/files${base}/notes.txt`);
+        expect(await content.text()).toBe('one\r\ntwo\r\nthree\r\n');
+        const listing = await (await request.get(`/api/v1/files${base}`, { headers: json })).json();
+        expect(listing.entries).toHaveLength(1);
+    });
+
+    test('refuses saves over a version it was not loaded from', async ({ request }) => {
+        const url = `/api/v1/content/files${base}/notes.txt`;
+        const missing = await request.put(url, { headers: json, data: 'x' });
+        expect(missing.status()).toBe(428);
+
+        const etag = (await request.head(`/api/v1/files${base}/notes.txt`)).headers()['etag'];
+        const first = await request.put(url, { headers: { ...json, 'If-Match': etag }, data: 'a' });
+        expect(first.status()).toBe(200);
+        const second = await request.put(url, {
+            headers: { ...json, 'If-Match': etag },
+            data: 'b',
+        });
+        expect(second.status()).toBe(412);
+        expect((await second.json()).error.code).toBe('precondition_failed');
+        expect(second.headers()['etag']).toBe(first.headers()['etag']);
+
+        const content = await request.get(`/api/v1/files${base}/notes.txt`);
+        expect(await content.text()).toBe('a');
+    });
+
+    test('saves edits from the editor without making copies', async ({ page, request }) => {
+        await page.goto(`/files${base}`);
+        const fileRow = page.locator('tr.file-table-row[data-name="notes.txt"]');
+        await fileRow.locator('.file-table-cell--clickable').dblclick();
+        const editor = page.locator('#editor');
+        await expect(editor).toBeVisible();
+        await editor.click();
+        await page.keyboard.press('Control+End');
+        await page.keyboard.type('three');
+        await expect
+            .poll(async () => (await request.get(`/api/v1/files${base}/notes.txt`)).text())
+            .toContain('three');
+
+        const listing = await (await request.get(`/api/v1/files${base}`, { headers: json })).json();
+        expect(listing.entries).toHaveLength(1);
+    });
+});
@@ -0,0 +1,71 @@
+import { test, expect } from '@playwright/test';
+import fs from 'fs';
+
+const json = { Accept: 'application/json' };
+
+test.describe('Thumbnail cache', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `thumbnails-${Date.now()}`;
+        base = `/${name}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: name } });
+        await request.post(`/api/v1/files${base}`, {
+            multipart: {
+                files: {
+                    name: 'photo.jpg',
+                    mimeType: 'image/jpeg',
+                    buffer: fs.readFileSync('./tests/e2e/data/test-image.jpg'),
+                },
+            },
+        });
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('serves named sizes with validators', async ({ request }) => {
+        for (const size of ['grid', 'preview', 'full']) {
+            const thumbnail = await request.get(`/api/v1/thumbnails${base}/photo.jpg?size=${size}`);
+            expect(thumbnail.status()).toBe(200);
+            expect(thumbnail.headers()['content-type']).toBe('image/jpeg');
+            expect(thumbnail.headers()['etag']).toBeTruthy();
+        }
+
+        const unknown = await request.get(`/api/v1/thumbnails${base}/photo.jpg?size=huge`, {
+            headers: json,
+        });
+        expect(unknown.status()).toBe(400);
+    });
+
+    test('answers revalidation from the cache', async ({ request }) => {
+        const first = await request.get(`/api/v1/thumbnails${base}/photo.jpg`);
+        expect(first.headers()['cache-control']).toContain('no-cache');
+        const again = await request.get(`/api/v1/thumbnails${base}/photo.jpg`, {
+            headers: { 'If-None-Match': first.headers()['etag'] },
+        });
+        expect(again.status()).toBe(304);
+    });
+
+    test('links versioned thumbnails that can be cached for good', async ({ page, request }) => {
+        await page.goto(`/files${base}?view=grid`);
+        const src = await page.locator('img.grid-view-thumbnail').getAttribute('src');
+        expect(src).toContain('size=grid');
+        expect(src).toContain('v=');
+
+        const thumbnail = await request.get(src!);
+        expect(thumbnail.status()).toBe(200);
+        expect(thumbnail.headers()['cache-control']).toContain('immutable');
+    });
+
+    test('keeps thumbnails working after a move', async ({ request }) => {
+        const before = await request.get(`/api/v1/thumbnails${base}/photo.jpg`);
+        await request.put(`/api/v1/files${base}/photo.jpg`, {
+            form: { newFilePath: `${base}/moved.jpg` },
+        });
+        const after = await request.get(`/api/v1/thumbnails${base}/moved.jpg`);
+        expect(after.status()).toBe(200);
+        expect(after.headers()['etag']).not.toBe(before.headers()['etag']);
+    });
+});
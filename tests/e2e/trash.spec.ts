@@ -0,0 +1,92 @@
+import { test, expect } from '@playwright/test';
+
+test.describe('Trash', () => {
+    test('trash link is shown in the file explorer header', async ({ page }) => {
+        await page.goto('/files');
+
+        const trashLink = page.locator('#trash-link');
+        await expect(trashLink).toBeVisible();
+        await expect(trashLink).toHaveAttribute('href', '/trash');
+    });
+
+    test('loads trash page with the file explorer', async ({ page }) => {
+        await page.goto('/trash');
+
+        await expect(page.locator('#file-explorer')).toBeVisible();
+        await expect(page.locator('.file-explorer-title')).toHaveText('Trash');
+        await expect(page.locator('#empty-trash-button')).toBeVisible();
+        await expect(page.locator('#breadcrumbs')).toContainText('trash');
+
+        // Uploading and creating folders is not possible inside the trash
+        await expect(page.locator('input[type="file"]')).toHaveCount(0);
+        await expect(page.locator('#add-folder-btn')).toHaveCount(0);
+    });
+
+    test('deleted file moves to the trash and can be restored', async ({ page }) => {
+        await page.goto('/files');
+
+        const fileInput = page.locator('input[type="file"]');
+        await fileInput.setInputFiles('./tests/e2e/data/users.csv');
+
+        const fileRow = page.locator('tr.file-table-row[data-name="users.csv"]');
+        await expect(fileRow).toBeVisible({ timeout: 10000 });
+
+        // Delete the file from the file explorer
+        await fileRow.locator('.context-menu-trigger').click();
+        await fileRow.locator('.context-menu-item--danger:has-text("Delete")').dispatchEvent('click');
+        await expect(fileRow).toHaveCount(0, { timeout: 10000 });
+
+        // It should now be listed in the trash
+        await page.goto('/trash');
+        const trashRow = page.locator('tr.file-table-row[data-name="users.csv"]').first();
+        await expect(trashRow).toBeVisible();
+
+        // Restore it
+        await trashRow.locator('.context-menu-trigger').click();
+        await trashRow.locator('.context-menu-item:has-text("Restore")').dispatchEvent('click');
+        await expect(page.locator('tr.file-table-row[data-name="users.csv"]')).toHaveCount(0, {
+            timeout: 10000,
+        });
+
+        // And it should be back in the files area
+        await page.goto('/files');
+        const restoredRow = page.locator('tr.file-table-row[data-name="users.csv"]');
+        await expect(restoredRow).toBeVisible();
+
+        // Clean up: delete the file and empty it from the trash
+        await restoredRow.locator('.context-menu-trigger').click();
+        await restoredRow.locator('.context-menu-item--danger:has-text("Delete")').dispatchEvent('click');
+        await expect(restoredRow).toHaveCount(0, { timeout: 10000 });
+    });
+
+    test('empty trash permanently deletes every item', async ({ page }) => {
+        await page.goto('/files');
+
+        const fileInput = page.locator('input[type="file"]');
+        await fileInput.setInputFiles('./tests/e2e/data/data.json');
+
+        const fileRow = page.locator('tr.file-table-row[data-name="data.json"]');
+        await expect(fileRow).toBeVisible({ timeout: 10000 });
+        await fileRow.locator('.context-menu-trigger').click();
+        await fileRow.locator('.context-menu-item--danger:has-text("Delete")').dispatchEvent('click');
+        await expect(fileRow).toHaveCount(0, { timeout: 10000 });
+
+        await page.goto('/trash');
+        await expect(page.locator('tr.file-table-row[data-name="data.json"]').first()).toBeVisible();
+
+        page.once('dialog', (dialog) => dialog.accept());
+        await page.locator('#empty-trash-button').click();
+
+        await expect(page.locator('.file-explorer-empty')).toHaveText('Trash is empty', {
+            timeout: 10000,
+        });
+    });
+
+    test('trash API lists items as JSON', async ({ request }) => {
+        const response = await request.get('/api/v1/trash');
+        expect(response.ok()).toBeTruthy();
+
+        const body = await response.json();
+        expect(Array.isArray(body.items)).toBeTruthy();
+    });
+});
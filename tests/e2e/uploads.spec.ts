@@ -0,0 +1,122 @@
+import { test, expect } from '@playwright/test';
+import { createHash } from 'crypto';
+
+const tusHeaders = { 'Tus-Resumable': '1.0.0' };
+
+function encodeMetadata(metadata: Record<string, string>): string {
+    return Object.entries(metadata)
+        .map(([key, value]) => `${key} ${Buffer.from(value).toString('base64')}`)
+        .join(',');
+}
+
+test.describe('Resumable Uploads (tus)', () => {
+    test('advertises supported tus extensions', async ({ request }) => {
+        const response = await request.fetch('/api/v1/uploads', { method: 'OPTIONS' });
+        expect(response.status()).toBe(204);
+        expect(response.headers()['tus-version']).toBe('1.0.0');
+        expect(response.headers()['tus-extension']).toContain('creation');
+        expect(response.headers()['tus-extension']).toContain('checksum');
+        expect(response.headers()['tus-checksum-algorithm']).toContain('sha1');
+    });
+
+    test('rejects requests without Tus-Resumable header', async ({ request }) => {
+        const response = await request.post('/api/v1/uploads', {
+            headers: { 'Upload-Length': '1' },
+        });
+        expect(response.status()).toBe(412);
+    });
+
+    test('uploads a file in chunks and resumes from the reported offset', async ({ request, page }) => {
+        const content = Buffer.from('resumable upload content '.repeat(100));
+        const fileName = `tus-upload-${Date.now()}.txt`;
+
+        const created = await request.post('/api/v1/uploads', {
+            headers: {
+                ...tusHeaders,
+                'Upload-Length': String(content.length),
+                'Upload-Metadata': encodeMetadata({ filename: fileName, rootDir: '/' }),
+            },
+        });
+        expect(created.status()).toBe(201);
+        const location = created.headers()['location'];
+        expect(location).toMatch(/^\/api\/v1\/uploads\//);
+        expect(created.headers()['upload-expires']).toBeTruthy();
+
+        // Send the first half
+        const half = Math.floor(content.length / 2);
+        const first = await request.patch(location, {
+            headers: {
+                ...tusHeaders,
+                'Content-Type': 'application/offset+octet-stream',
+                'Upload-Offset': '0',
+            },
+            data: content.subarray(0, half),
+        });
+        expect(first.status()).toBe(204);
+        expect(first.headers()['upload-offset']).toBe(String(half));
+
+        // Resume from the offset the server reports
+        const head = await request.head(location, { headers: tusHeaders });
+        expect(head.status()).toBe(200);
+        expect(head.headers()['upload-offset']).toBe(String(half));
+        expect(head.headers()['upload-length']).toBe(String(content.length));
+
+        // A chunk with a bad checksum is rejected and discarded
+        const rest = content.subarray(half);
+        const badChecksum = await request.patch(location, {
+            headers: {
+                ...tusHeaders,
+                'Content-Type': 'application/offset+octet-stream',
+                'Upload-Offset': String(half),
+                'Upload-Checksum': `sha1 ${createHash('sha1').update('nope').digest('base64')}`,
+            },
+            data: rest,
+        });
+        expect(badChecksum.status()).toBe(460);
+
+        const last = await request.patch(location, {
+            headers: {
+                ...tusHeaders,
+                'Content-Type': 'application/offset+octet-stream',
+                'Upload-Offset': String(half),
+                'Upload-Checksum': `sha1 ${createHash('sha1').update(rest).digest('base64')}`,
+            },
+            data: rest,
+        });
+        expect(last.status()).toBe(204);
+        expect(last.headers()['upload-offset']).toBe(String(content.length));
+        expect(last.headers()['x-upload-path']).toBe(`/${fileName}`);
+
+        // The completed upload is gone from the staging area
+        const gone = await request.head(location, { headers: tusHeaders });
+        expect(gone.status()).toBe(404);
+
+        // And shows up in the file explorer
+        await page.goto('/files');
+        const fileRow = page.locator(`tr.file-table-row[data-name="${fileName}"]`);
+        await expect(fileRow).toBeVisible();
+
+        // Clean up
+        await fileRow.locator('.context-menu-trigger').click();
+        await fileRow.locator('.context-menu-item--danger:has-text("Delete")').dispatchEvent('click');
+        await expect(fileRow).toHaveCount(0, { timeout: 10000 });
+    });
+
+    test('terminates an abandoned upload', async ({ request }) => {
+        const created = await request.post('/api/v1/uploads', {
+            headers: {
+                ...tusHeaders,
+                'Upload-Length': '10',
+                'Upload-Metadata': encodeMetadata({ filename: 'abandoned.txt' }),
+            },
+        });
+        expect(created.status()).toBe(201);
+        const location = created.headers()['location'];
+
+        const terminated = await request.delete(location, { headers: tusHeaders });
+        expect(terminated.status()).toBe(204);
+
+        const head = await request.head(location, { headers: tusHeaders });
+        expect(head.status()).toBe(404);
+    });
+});
@@ -0,0 +1,105 @@
+import { test, expect } from '@playwright/test';
+
+test.describe('WebDAV', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        base = `/dav-${Date.now()}`;
+        const created = await request.fetch(`/dav${base}`, { method: 'MKCOL' });
+        expect(created.status()).toBe(201);
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('lists folders with PROPFIND', async ({ request }) => {
+        await request.put(`/dav${base}/note.txt`, { data: 'hello' });
+        const response = await request.fetch(`/dav${base}/`, {
+            method: 'PROPFIND',
+            headers: { Depth: '1' },
+        });
+        expect(response.status()).toBe(207);
+        const body = await response.text();
+        expect(body).toContain(`<D:href>/dav${base}/note.txt</D:href>`);
+        expect(body).toContain('<D:getcontentlength>5</D:getcontentlength>');
+    });
+
+    test('writes through to the files API', async ({ request }) => {
+        const put = await request.put(`/dav${base}/note.txt`, { data: 'hello' });
+        expect(put.status()).toBe(201);
+        expect(await (await request.get(`/api/v1/files${base}/note.txt`)).text()).toBe('hello');
+
+        const copied = await request.fetch(`/dav${base}/note.txt`, {
+            method: 'COPY',
+            headers: { Destination: `/dav${base}/copy.txt` },
+        });
+        expect(copied.status()).toBe(201);
+        const moved = await request.fetch(`/dav${base}/copy.txt`, {
+            method: 'MOVE',
+            headers: { Destination: `/dav${base}/moved.txt` },
+        });
+        expect(moved.status()).toBe(201);
+        expect((await request.head(`/api/v1/files${base}/copy.txt`)).status()).toBe(404);
+        expect(await (await request.get(`/api/v1/files${base}/moved.txt`)).text()).toBe('hello');
+
+        const deleted = await request.delete(`/dav${base}/moved.txt`);
+        expect(deleted.status()).toBe(204);
+        const trash = await request.get('/trash');
+        expect(await trash.text()).toContain('moved.txt');
+    });
+
+    test('writes byte ranges with partial PUT', async ({ request }) => {
+        await request.put(`/dav${base}/note.txt`, { data: 'hello world' });
+        const partial = await request.put(`/dav${base}/note.txt`, {
+            data: 'WORLD!',
+            headers: { 'Content-Range': 'bytes 6-11/*' },
+        });
+        expect(partial.status()).toBe(201);
+        expect(await (await request.get(`/dav${base}/note.txt`)).text()).toBe('hello WORLD!');
+
+        const gap = await request.put(`/dav${base}/note.txt`, {
+            data: 'x',
+            headers: { 'Content-Range': 'bytes 100-100/*' },
+        });
+        expect(gap.status()).toBe(416);
+    });
+
+    test('honours locks', async ({ request }) => {
+        await request.put(`/dav${base}/note.txt`, { data: 'hello' });
+        const lock = await request.fetch(`/dav${base}/note.txt`, {
+            method: 'LOCK',
+            headers: { Timeout: 'Second-60', 'Content-Type': 'application/xml' },
+            data:
+                '<?xml version="1.0"?><D:lockinfo xmlns:D="DAV:">' +
+                '<D:lockscope><D:exclusive/></D:lockscope><D:locktype><D:write/></D:locktype>' +
+                '</D:lockinfo>',
+        });
+        expect(lock.status()).toBe(200);
+        const token = lock.headers()['lock-token'];
+
+        const blocked = await request.put(`/dav${base}/note.txt`, { data: 'other' });
+        expect(blocked.status()).toBe(423);
+        const allowed = await request.put(`/dav${base}/note.txt`, {
+            data: 'mine',
+            headers: { If: `(${token})` },
+        });
+        expect(allowed.status()).toBe(201);
+
+        const unlocked = await request.fetch(`/dav${base}/note.txt`, {
+            method: 'UNLOCK',
+            headers: { 'Lock-Token': token },
+        });
+        expect(unlocked.status()).toBe(204);
+    });
+
+    test('stays inside the files root', async ({ request }) => {
+        await request.put(`/dav${base}/note.txt`, { data: 'hello' });
+        const escaped = await request.fetch(`/dav${base}/note.txt`, {
+            method: 'MOVE',
+            headers: { Destination: '/dav/../../escaped.txt' },
+        });
+        expect(escaped.status()).toBe(403);
+        expect((await request.delete('/dav/')).status()).toBe(405);
+    });
+});
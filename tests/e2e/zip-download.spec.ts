@@ -0,0 +1,100 @@
+import { test, expect } from '@playwright/test';
+import { upload } from './helpers';
+
+type ZipEntry = { name: string; method: number };
+
+/**
+ * List the entries of a zip archive from its central directory
+ */
+function listZip(buffer: Buffer): ZipEntry[] {
+    const end = buffer.lastIndexOf(Buffer.from([0x50, 0x4b, 0x05, 0x06]));
+    expect(end).toBeGreaterThanOrEqual(0);
+    const count = buffer.readUInt16LE(end + 10);
+    let offset = buffer.readUInt32LE(end + 16);
+    const entries: ZipEntry[] = [];
+    for (let i = 0; i < count; i++) {
+        expect(buffer.readUInt32LE(offset)).toBe(0x02014b50);
+        const nameLength = buffer.readUInt16LE(offset + 28);
+        const extraLength = buffer.readUInt16LE(offset + 30);
+        const commentLength = buffer.readUInt16LE(offset + 32);
+        entries.push({
+            name: buffer.toString('utf8', offset + 46, offset + 46 + nameLength),
+            method: buffer.readUInt16LE(offset + 10),
+        });
+        offset += 46 + nameLength + extraLength + commentLength;
+    }
+    return entries;
+}
+
+test.describe('Zip Download', () => {
+    let base: string;
+
+    test.beforeEach(async ({ request }) => {
+        const name = `zip-${Date.now()}`;
+        base = `/${name}`;
+        await request.post('/api/v1/folder/files/', { form: { folderName: name } });
+        await request.post(`/api/v1/folder/files${base}`, { form: { folderName: 'a' } });
+        await request.post(`/api/v1/folder/files${base}`, { form: { folderName: 'b' } });
+        await upload(request, `${base}/a`, 'note.txt', Buffer.from('one '.repeat(100)));
+        await upload(request, `${base}/b`, 'note.txt', Buffer.from('two '.repeat(100)));
+        await upload(request, `${base}/b`, 'photo.jpg', Buffer.alloc(2000, 7));
+    });
+
+    test.afterEach(async ({ request }) => {
+        await request.delete(`/api/v1/files?rootDir=/&filePaths=${base.slice(1)}`);
+    });
+
+    test('zips a selection from several folders', async ({ request }) => {
+        const response = await request.post('/api/v1/zip/files', {
+            data: `paths=${base}/a/note.txt&paths=${base}/b`,
+            headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
+        });
+        expect(response.status()).toBe(200);
+        expect(response.headers()['content-disposition']).toContain('files.zip');
+
+        const entries = listZip(await response.body());
+        const methods = Object.fromEntries(entries.map((entry) => [entry.name, entry.method]));
+        expect(Object.keys(methods)).toEqual(['note.txt', 'b/', 'b/note.txt', 'b/photo.jpg']);
+        // Text is deflated, photos are stored as they are
+        expect(methods['note.txt']).toBe(8);
+        expect(methods['b/photo.jpg']).toBe(0);
+    });
+
+    test('keeps files with the same name apart', async ({ request }) => {
+        const response = await request.get(
+            `/api/v1/zip/files?paths=${base}/a/note.txt&paths=${base}/b/note.txt&compression=store`
+        );
+        const entries = listZip(await response.body());
+        expect(entries).toEqual([
+            { name: 'note.txt', method: 0 },
+            { name: 'note_(1).txt', method: 0 },
+        ]);
+    });
+
+    test('lists unreadable entries in a manifest', async ({ request }) => {
+        const response = await request.get(
+            `/api/v1/zip/files?paths=${base}/a&paths=${base}/missing.txt`
+        );
+        expect(response.status()).toBe(200);
+        const names = listZip(await response.body()).map((entry) => entry.name);
+        expect(names).toEqual(['a/', 'a/note.txt', 'MANIFEST-ERRORS.txt']);
+    });
+
+    test('rejects bad requests', async ({ request }) => {
+        expect((await request.get('/api/v1/zip/files')).status()).toBe(400);
+        const compression = await request.get(`/api/v1/zip/files?paths=${base}/a&compression=lzma`);
+        expect(compression.status()).toBe(400);
+        expect((await request.get('/api/v1/zip/files?paths=../etc')).status()).toBe(403);
+    });
+
+    test('downloads a multi-selection as one zip', async ({ page }) => {
+        await page.goto(`/files${base}/b`);
+        const files = page.locator('.file-table-row.file-node');
+        await files.nth(0).click();
+        await files.nth(1).click({ modifiers: ['ControlOrMeta'] });
+
+        const download = page.waitForEvent('download');
+        await page.locator('#file-download-button').click();
+        expect((await download).suggestedFilename()).toBe('files.zip');
+    });
+});